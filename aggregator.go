@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/dbretry"
+	"github.com/olereon/Gator/internal/ratelimit"
+)
+
+const (
+	// claimLease is how long a claimed feed is kept from other workers and
+	// agg processes. It is renewed while the feed waits and is fetched, and
+	// scrapeFeed always sets the real schedule, so it only runs out if agg
+	// dies while it holds the feed.
+	claimLease = 15 * time.Minute
+
+	// pendingPerWorker bounds how many claimed feeds may wait for a worker
+	// or for their host's next slot
+	pendingPerWorker = 4
+)
+
+// aggregator is a long-lived pool of workers fed by a dispatcher that claims
+// due feeds from the database one at a time. Feeds on the same host are
+// spaced out by the host request interval without holding a worker.
+type aggregator struct {
+	s     *state
+	sc    *scraper
+	hosts *ratelimit.Limiter
+
+	jobs    chan claim
+	pending chan struct{}
+
+	mu      sync.Mutex
+	summary *cycleSummary
+}
+
+func newAggregator(s *state, sc *scraper, concurrency int, hostInterval time.Duration) *aggregator {
+	return &aggregator{
+		s:       s,
+		sc:      sc,
+		hosts:   ratelimit.New(hostInterval),
+		jobs:    make(chan claim, concurrency),
+		pending: make(chan struct{}, concurrency*pendingPerWorker),
+		summary: &cycleSummary{startedAt: time.Now().UTC()},
+	}
+}
+
+// current returns the summary of the cycle in progress
+func (a *aggregator) current() *cycleSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.summary
+}
+
+// claim is a feed claimed for fetching, with the function that stops
+// renewing its lease
+type claim struct {
+	feed    database.Feed
+	release func()
+}
+
+// claimFeed takes the next due feed off the queue. The claim moves its
+// next_fetch_at ahead by claimLease so no other worker, or another agg
+// process, picks it up again.
+func (a *aggregator) claimFeed() (claim, bool) {
+	// Postgres keeps microseconds, renewals compare against the stored lease
+	lease := time.Now().UTC().Add(claimLease).Truncate(time.Microsecond)
+	feed, err := a.s.db.ClaimNextFeedToFetch(context.Background(), sql.NullTime{Time: lease, Valid: true})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("couldn't claim a feed", "err", err)
+		}
+		return claim{}, false
+	}
+	return claim{feed: feed, release: a.holdLease(feed, lease)}, true
+}
+
+// holdLease renews the lease on a claimed feed every third of claimLease
+// until the returned function is called, so a feed that waits long for its
+// host or fetches slowly isn't claimed twice. Renewing stops by itself once
+// the feed has been rescheduled.
+func (a *aggregator) holdLease(feed database.Feed, lease time.Time) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(claimLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			until := time.Now().UTC().Add(claimLease).Truncate(time.Microsecond)
+			renewed, err := a.s.db.RenewFeedClaim(context.Background(), database.RenewFeedClaimParams{
+				Until: sql.NullTime{Time: until, Valid: true},
+				ID:    feed.ID,
+				Lease: sql.NullTime{Time: lease, Valid: true},
+			})
+			if err != nil {
+				slog.Error("couldn't renew claim on feed", "feed", feed.Name, "err", err)
+				continue
+			}
+			if renewed == 0 {
+				return
+			}
+			lease = until
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// dispatch claims due feeds and queues them for the workers, waiting for
+// poll whenever none are due
+func (a *aggregator) dispatch(poll time.Duration) {
+	for {
+		a.pending <- struct{}{}
+
+		c, ok := a.claimFeed()
+		if !ok {
+			<-a.pending
+			time.Sleep(poll)
+			continue
+		}
+
+		feed := c.feed
+		host := feedHost(feed.Url)
+		if !a.sc.breaker.Allow(host) {
+			// Push it back until the circuit closes so it doesn't hold a slot
+			<-a.pending
+			c.release()
+			a.current().skip()
+			err := a.s.db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+				ID:          feed.ID,
+				NextFetchAt: sql.NullTime{Time: a.sc.breaker.OpenUntil(host), Valid: true},
+			})
+			if err != nil {
+				slog.Error("couldn't reschedule feed", "feed", feed.Name, "err", err)
+			}
+			continue
+		}
+
+		if wait := time.Until(a.hosts.Reserve(host)); wait > 0 {
+			time.AfterFunc(wait, func() { a.jobs <- c })
+			continue
+		}
+		a.jobs <- c
+	}
+}
+
+// work fetches queued feeds until the queue is closed
+func (a *aggregator) work() {
+	for c := range a.jobs {
+		feed := c.feed
+		started := time.Now()
+		newPosts, err := scrapeFeed(a.s, a.sc, feed)
+		c.release()
+		if err != nil {
+			slog.Error("couldn't scrape feed", "feed", feed.Name, "err", err)
+		} else {
+			slog.Debug("scraped feed", "feed", feed.Name, "new_posts", newPosts, "duration", time.Since(started))
+		}
+		a.current().record(newPosts, err)
+		<-a.pending
+	}
+}
+
+// endCycle closes the cycle in progress, logs and stores its totals and
+// starts the next one
+func (a *aggregator) endCycle() {
+	a.mu.Lock()
+	c := a.summary
+	a.summary = &cycleSummary{startedAt: time.Now().UTC()}
+	a.mu.Unlock()
+	a.sc.pages.endCycle()
+
+	// Workers that picked up c just before the switch may still record into it
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	duration := time.Since(c.startedAt)
+	slog.Info("cycle", "started_at", c.startedAt, "attempted", c.attempted, "succeeded", c.succeeded,
+		"failed", c.failed, "skipped", c.skipped, "new_posts", c.newPosts, "duration", duration.Round(time.Millisecond))
+
+	_, err := a.s.db.CreateAggCycle(context.Background(), database.CreateAggCycleParams{
+		ID:             uuid.New(),
+		StartedAt:      c.startedAt,
+		DurationMs:     duration.Milliseconds(),
+		FeedsAttempted: int32(c.attempted),
+		FeedsSucceeded: int32(c.succeeded),
+		FeedsFailed:    int32(c.failed),
+		FeedsSkipped:   int32(c.skipped),
+		NewPosts:       int32(c.newPosts),
+	})
+	if err != nil {
+		slog.Error("couldn't save cycle summary", "err", err)
+	}
+}
+
+func handlerAgg(s *state, cmd command) error {
+	fullContent := false
+	var logOpts logOptions
+	var positional []string
+	for _, arg := range cmd.args {
+		if arg == "--full" {
+			fullContent = true
+		} else if !logOpts.parseLogFlag(arg) {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 {
+		return errors.New("time_between_reqs is required")
+	}
+
+	timeBetweenRequests, err := time.ParseDuration(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	logger, logFile, err := newLogger(logOpts)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	slog.SetDefault(logger)
+	watchSQLTraceToggle(s)
+
+	// agg runs for days and should outlive a database restart or failover,
+	// so its statements are repeated while the database can't be reached.
+	// Only statements that surely didn't run are repeated: one whose
+	// connection broke midway fails as before, as an increment or a lease
+	// done twice would do harm.
+	s.db = database.New(dbretry.New(s.conn, dbretry.DefaultPolicy))
+
+	// Default concurrency
+	concurrency := 5
+
+	// Parse optional concurrency argument
+	if len(positional) > 1 {
+		if c, err := strconv.Atoi(positional[1]); err == nil && c > 0 {
+			concurrency = c
+		} else {
+			return fmt.Errorf("invalid concurrency value: %s", positional[1])
+		}
+	}
+
+	sc, err := newScraper(s.cfg)
+	if err != nil {
+		return fmt.Errorf("couldn't create scraper: %w", err)
+	}
+	sc.fullContent = fullContent
+
+	if sc.search != nil {
+		// Search still works on the database while the backend is down, so
+		// this isn't fatal
+		if err := sc.search.Setup(context.Background()); err != nil {
+			slog.Error("couldn't set up search index", "err", err)
+		}
+	}
+
+	retention, err := fetchLogRetention(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	hostInterval, err := hostRequestInterval(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("collecting feeds", "workers", concurrency, "poll_interval", timeBetweenRequests)
+	announceSchema(s)
+
+	a := newAggregator(s, sc, concurrency, hostInterval)
+	sc.pages = newPageQueue(s, a.hosts)
+	for i := 0; i < concurrency; i++ {
+		go a.work()
+	}
+	go a.dispatch(timeBetweenRequests)
+
+	lastSummary := time.Now().UTC()
+
+	ticker := time.NewTicker(timeBetweenRequests)
+	for range ticker.C {
+		a.endCycle()
+		pruneFetchLog(s, retention)
+		backfillImportedStates(s)
+
+		if time.Since(lastSummary) >= 24*time.Hour {
+			sendDailySummary(s, lastSummary)
+			lastSummary = time.Now().UTC()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// followPermanentRedirect replaces the stored URL of a feed that has moved
+// permanently, so later fetches go straight to the new location. It returns
+// the feed as it is stored afterwards.
+func followPermanentRedirect(s *state, feed database.Feed, newURL string) database.Feed {
+	if newURL == feed.Url {
+		return feed
+	}
+	if err := checkFeedPolicy(s.cfg, newURL); err != nil {
+		slog.Warn("not moving feed", "feed", feed.Name, "url", newURL, "err", err)
+		return feed
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	err := s.db.SetFeedURL(ctx, database.SetFeedURLParams{
+		ID:        feed.ID,
+		Url:       newURL,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		// Another feed already has the new URL, detectAlias takes it from here
+		if err.Error() != `pq: duplicate key value violates unique constraint "feeds_url_key"` {
+			slog.Error("couldn't update feed URL", "feed", feed.Name, "err", err)
+		}
+		return feed
+	}
+
+	err = s.db.CreateFeedURLChange(ctx, database.CreateFeedURLChangeParams{
+		ID:        uuid.New(),
+		FeedID:    feed.ID,
+		ChangedAt: now,
+		OldUrl:    feed.Url,
+		NewUrl:    newURL,
+	})
+	if err != nil {
+		slog.Error("couldn't log feed URL change", "feed", feed.Name, "err", err)
+	}
+
+	slog.Info("feed moved permanently", "feed", feed.Name, "url", newURL, "old_url", feed.Url)
+	announce(s, fmt.Sprintf("feed-moved/%s/%s", feed.ID, newURL),
+		fmt.Sprintf("Feed %s moved", feed.Name),
+		fmt.Sprintf("%s redirected permanently to %s, gator fetches the new address from now on.", feed.Url, newURL))
+	feed.Url = newURL
+	return feed
+}
+
+// detectAlias records where feed actually resolved to and, if an older feed
+// resolves to the same URL, marks feed as its alias so only one of them is
+// fetched from then on
+func detectAlias(s *state, feed database.Feed, finalURL string) {
+	ctx := context.Background()
+
+	if feed.ResolvedUrl.String != finalURL {
+		err := s.db.SetFeedResolvedURL(ctx, database.SetFeedResolvedURLParams{
+			ID:          feed.ID,
+			ResolvedUrl: sql.NullString{String: finalURL, Valid: true},
+		})
+		if err != nil {
+			slog.Error("couldn't record resolved feed URL", "feed", feed.Name, "err", err)
+			return
+		}
+	}
+
+	canonical, err := s.db.GetCanonicalFeedForResolvedURL(ctx, database.GetCanonicalFeedForResolvedURLParams{
+		ResolvedUrl: sql.NullString{String: finalURL, Valid: true},
+		ID:          feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		slog.Error("couldn't look up feeds resolving to URL", "url", finalURL, "err", err)
+		return
+	}
+
+	// The older feed wins; the newer one detects the clash on its own fetch
+	if !canonical.CreatedAt.Before(feed.CreatedAt) {
+		return
+	}
+
+	err = s.db.SetFeedCanonical(ctx, database.SetFeedCanonicalParams{
+		ID:              feed.ID,
+		CanonicalFeedID: uuid.NullUUID{UUID: canonical.ID, Valid: true},
+	})
+	if err != nil {
+		slog.Error("couldn't mark feed as alias", "feed", feed.Name, "err", err)
+		return
+	}
+	slog.Info("feed resolves to the same URL as another, fetching that one only",
+		"feed", feed.Name, "canonical", canonical.Name, "hint", "gator feed merge "+feed.Url)
+}
+
+func handlerFeedMerge(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("url is required")
+	}
+
+	ctx := context.Background()
+	alias, err := s.db.GetFeedByURL(ctx, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	if !alias.CanonicalFeedID.Valid {
+		return fmt.Errorf("%s isn't an alias of another feed", alias.Name)
+	}
+	if err := checkCanManageFeed(s, alias, user); err != nil {
+		return err
+	}
+
+	canonical, err := s.db.GetFeedByID(ctx, alias.CanonicalFeedID.UUID)
+	if err != nil {
+		return fmt.Errorf("couldn't find canonical feed: %w", err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	err = q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+		FromFeedID: alias.ID,
+		ToFeedID:   canonical.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move follows: %w", err)
+	}
+
+	err = q.DeleteFeed(ctx, database.DeleteFeedParams{
+		ID:        alias.ID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't remove alias: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit transaction: %w", err)
+	}
+
+	fmt.Printf("Merged %s into %s\n", alias.Name, canonical.Name)
+	return nil
+}
+
+// warnFeedMoved tells everyone that a feed now serves a web page, with the
+// feeds the page links to, since the feed has most likely moved somewhere
+// without a redirect
+func warnFeedMoved(s *state, feed database.Feed, pageErr *rss.HTMLPageError, at time.Time) {
+	slog.Warn("feed serves a web page, feed moved?", "feed", feed.Name, "url", feed.Url, "discovered", pageErr.Feeds)
+
+	body := fmt.Sprintf("%s now serves a web page instead of a feed, gator checks it only rarely until it serves a feed again.", feed.Url)
+	if len(pageErr.Feeds) > 0 {
+		body += fmt.Sprintf("\nThe page links to %s. If that is the new feed, add it with: gator addfeed %q %s\nand remove the old one with: gator feed remove %s",
+			strings.Join(pageErr.Feeds, ", "), feed.Name, pageErr.Feeds[0], feed.Url)
+	}
+	announce(s, fmt.Sprintf("feed-page/%s/%d", feed.ID, at.Unix()), fmt.Sprintf("Feed %s moved?", feed.Name), body)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// announcementsFeedID is the gator announcements feed the migrations
+// create. It is never fetched, gator posts to it directly.
+var announcementsFeedID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+// announce posts a message for every user to the announcements feed. key
+// identifies the event: announcing the same key again does nothing, so
+// something that keeps happening is only announced once.
+func announce(s *state, key, title, body string) {
+	if s.readOnly {
+		return
+	}
+	now := time.Now().UTC()
+	var paragraphs []string
+	for _, p := range strings.Split(body, "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, "<p>"+html.EscapeString(p)+"</p>")
+		}
+	}
+	_, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Title:       title,
+		Url:         "gator:announcements/" + key,
+		Description: sql.NullString{String: strings.Join(paragraphs, "\n"), Valid: len(paragraphs) > 0},
+		PublishedAt: sql.NullTime{Time: now, Valid: true},
+		FeedID:      announcementsFeedID,
+	})
+	if err != nil && err.Error() != `pq: duplicate key value violates unique constraint "posts_url_key"` {
+		slog.Error("couldn't post announcement", "title", title, "err", err)
+	}
+}
+
+// announceSchema tells users about the schema version agg started on, once
+// per version
+func announceSchema(s *state) {
+	expected, err := loadExpectedSchema()
+	if err != nil {
+		slog.Error("couldn't read migrations", "err", err)
+		return
+	}
+	announce(s, fmt.Sprintf("schema/%d", expected.version),
+		fmt.Sprintf("Database upgraded to schema version %d", expected.version),
+		"gator is running on the newest database schema. Commands added by the upgrade are described in the README.")
+}
+
+// followAnnouncements makes a new user follow the announcements feed
+func followAnnouncements(s *state, user database.User) error {
+	_, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    announcementsFeedID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't follow announcements: %w", err)
+	}
+	return nil
+}
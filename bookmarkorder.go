@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// bookmarkSorts are the orders gator bookmarks can list in, the first is
+// the default
+var bookmarkSorts = []string{"added", "published", "title", "manual"}
+
+// handlerBookmarksMove places a bookmark in the manual order of all
+// bookmarks, or of the bookmarks of a tag with --tag
+func handlerBookmarksMove(s *state, cmd command, user database.User) error {
+	if s.readOnly {
+		return fmt.Errorf("bookmarks move: %w", errReadOnly)
+	}
+	tag := ""
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--tag=") {
+			tags, err := parseTags([]string{strings.TrimPrefix(arg, "--tag=")})
+			if err != nil {
+				return err
+			}
+			tag = tags[0]
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return errors.New("usage: gator bookmarks move <post_url|number> up|down|top|bottom|<position> [--tag=<tag>]")
+	}
+
+	post, err := resolvePost(s, user, positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+
+	ctx := context.Background()
+	bookmarks, err := s.db.GetBookmarksForUser(ctx, database.GetBookmarksForUserParams{
+		UserID:  user.ID,
+		Limit:   math.MaxInt32,
+		Column3: tag,
+		Column4: "manual",
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get bookmarks: %w", err)
+	}
+	order := bookmarks
+	from := slices.IndexFunc(order, func(b database.GetBookmarksForUserRow) bool { return b.ID == post.ID })
+	if from < 0 {
+		if tag != "" {
+			return fmt.Errorf("%s isn't a bookmark tagged %s", post.Title, tag)
+		}
+		return fmt.Errorf("%s isn't bookmarked", post.Title)
+	}
+
+	to := from
+	switch positional[1] {
+	case "up":
+		to = from - 1
+	case "down":
+		to = from + 1
+	case "top":
+		to = 0
+	case "bottom":
+		to = len(order) - 1
+	default:
+		n, err := strconv.Atoi(positional[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid position %q, use up, down, top, bottom or a number from 1", positional[1])
+		}
+		to = n - 1
+	}
+	to = max(0, min(to, len(order)-1))
+
+	moved := order[from]
+	order = slices.Delete(order, from, from+1)
+	order = slices.Insert(order, to, moved)
+
+	postIDs := make([]uuid.UUID, len(order))
+	for i, b := range order {
+		postIDs[i] = b.ID
+	}
+	err = s.db.SaveBookmarkPositions(ctx, database.SaveBookmarkPositionsParams{
+		UserID:  user.ID,
+		Tag:     tag,
+		PostIds: postIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save bookmark order: %w", err)
+	}
+
+	list := "your bookmarks"
+	if tag != "" {
+		list = "your " + tag + " bookmarks"
+	}
+	fmt.Printf("%s is now #%d of %d in %s\n", post.Title, to+1, len(order), list)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/olereon/Gator/internal/cache"
+	"github.com/olereon/Gator/internal/config"
+)
+
+const defaultCacheMaxSize = 500 << 20
+
+// newCache opens the content cache under cache_dir, by default gator's
+// directory in the user cache directory
+func newCache(cfg *config.Config) (*cache.Cache, error) {
+	dir := cfg.CacheDir
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find cache directory, set cache_dir: %w", err)
+		}
+		dir = filepath.Join(userCache, "gator")
+	}
+
+	maxSize := int64(defaultCacheMaxSize)
+	if cfg.CacheMaxSize != "" {
+		size, err := parseSize(cfg.CacheMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache_max_size: %w", err)
+		}
+		maxSize = size
+	}
+
+	return cache.New(dir, maxSize), nil
+}
+
+// handlerCache dispatches the "cache <subcommand>" family of commands
+func handlerCache(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return errors.New("subcommand is required: stats, clear")
+	}
+
+	c, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.args[0] {
+	case "stats":
+		stats, err := c.Stats()
+		if err != nil {
+			return fmt.Errorf("couldn't read cache: %w", err)
+		}
+		fmt.Printf("Cache directory: %s\n", c.Dir())
+		fmt.Printf("  Entries: %d\n", stats.Keys)
+		fmt.Printf("  Stored blobs: %d\n", stats.Blobs)
+		fmt.Printf("  Size: %s of %s\n", formatBytes(stats.Size), formatBytes(stats.MaxSize))
+		return nil
+	case "clear":
+		if err := c.Clear(); err != nil {
+			return fmt.Errorf("couldn't clear cache: %w", err)
+		}
+		fmt.Printf("Cleared %s\n", c.Dir())
+		return nil
+	default:
+		return fmt.Errorf("unknown cache subcommand: %s", cmd.args[0])
+	}
+}
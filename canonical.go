@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// setCanonicalURL records the URL a post's page names as canonical. When
+// another post of the same feed or its aliases already has that URL, both
+// are the same story reached through an AMP or mirror link, so post is
+// merged into the other one. Posts of other feeds are left alone, their
+// followers may not follow this one. It returns the ID of the post that
+// remains.
+func setCanonicalURL(s *state, post database.Post, canonical string) (uuid.UUID, error) {
+	if canonical == post.Url || (post.CanonicalUrl.Valid && canonical == post.CanonicalUrl.String) {
+		return post.ID, nil
+	}
+
+	other, err := s.db.GetOtherPostWithURL(context.Background(), database.GetOtherPostWithURLParams{
+		Url:    canonical,
+		ID:     post.ID,
+		FeedID: post.FeedID,
+	})
+	if err == nil {
+		if err := mergePost(s, post, other); err != nil {
+			return post.ID, err
+		}
+		return other.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return post.ID, fmt.Errorf("couldn't look up canonical URL: %w", err)
+	}
+
+	err = s.db.SetPostCanonicalURL(context.Background(), database.SetPostCanonicalURLParams{
+		ID:           post.ID,
+		CanonicalUrl: sql.NullString{String: canonical, Valid: true},
+		UpdatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return post.ID, fmt.Errorf("couldn't save canonical URL: %w", err)
+	}
+	return post.ID, nil
+}
+
+// mergePost moves the bookmarks, read state and tags of a duplicate post
+// over to the post it duplicates, then deletes the duplicate. It all
+// happens in one transaction, so a failure leaves both posts as they were.
+func mergePost(s *state, duplicate, into database.Post) error {
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	if err := q.MovePostBookmarks(ctx, database.MovePostBookmarksParams{
+		FromPostID: duplicate.ID,
+		ToPostID:   into.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't move bookmarks: %w", err)
+	}
+	if err := q.CopyPostReads(ctx, database.CopyPostReadsParams{
+		FromPostID: duplicate.ID,
+		ToPostID:   into.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't copy read state: %w", err)
+	}
+	if err := q.CopyPostTags(ctx, database.CopyPostTagsParams{
+		FromPostID: duplicate.ID,
+		ToPostID:   into.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't copy tags: %w", err)
+	}
+	if err := q.DeletePost(ctx, duplicate.ID); err != nil {
+		return fmt.Errorf("couldn't delete duplicate post: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit merge: %w", err)
+	}
+	return nil
+}
+
+// knownCanonically reports whether a new item's link is already the
+// canonical URL of a stored post of the feed or its aliases, so the item is
+// a copy of it
+func knownCanonically(s *state, feed database.Feed, link string) bool {
+	exists, err := s.db.CanonicalURLExists(context.Background(), database.CanonicalURLExistsParams{
+		CanonicalUrl: sql.NullString{String: link, Valid: true},
+		FeedID:       feed.ID,
+	})
+	if err != nil {
+		slog.Error("couldn't check canonical URL", "url", link, "err", err)
+		return false
+	}
+	return exists
+}
+
+// postLink is the URL to hand out for a post: its canonical URL if known
+func postLink(post database.Post) string {
+	if post.CanonicalUrl.Valid {
+		return post.CanonicalUrl.String
+	}
+	return post.Url
+}
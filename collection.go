@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// handlerCollection manages named collections, each made of the bookmarks
+// of one tag in that tag's manual order
+func handlerCollection(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: create, list, share, unshare, delete")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	if s.readOnly && sub.name != "list" {
+		return fmt.Errorf("collection %s: %w", sub.name, errReadOnly)
+	}
+	switch sub.name {
+	case "create":
+		return handlerCollectionCreate(s, sub, user)
+	case "list":
+		return handlerCollectionList(s, sub, user)
+	case "share":
+		return handlerCollectionShare(s, sub, user)
+	case "unshare":
+		return handlerCollectionUnshare(s, sub, user)
+	case "delete":
+		return handlerCollectionDelete(s, sub, user)
+	default:
+		return fmt.Errorf("unknown collection subcommand: %s", sub.name)
+	}
+}
+
+func handlerCollectionCreate(s *state, cmd command, user database.User) error {
+	description := ""
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--description=") {
+			description = strings.TrimSpace(strings.TrimPrefix(arg, "--description="))
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 || strings.TrimSpace(positional[1]) == "" {
+		return errors.New("usage: gator collection create <tag> <name> [--description=<text>]")
+	}
+	tags, err := parseTags(positional[:1])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(positional[1])
+
+	_, err = s.db.CreateCollection(context.Background(), database.CreateCollectionParams{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Tag:         tags[0],
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		if err.Error() == `pq: duplicate key value violates unique constraint "collections_user_id_tag_key"` {
+			return fmt.Errorf("tag %s already has a collection", tags[0])
+		}
+		return fmt.Errorf("couldn't create collection: %w", err)
+	}
+
+	fmt.Printf("Created collection %q from your bookmarks tagged %s.\n", name, tags[0])
+	fmt.Printf("Order it with: gator bookmarks move <post> <position> --tag=%s\n", tags[0])
+	fmt.Printf("Share it with: gator collection share %s\n", tags[0])
+	return nil
+}
+
+func handlerCollectionList(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return errors.New("usage: gator collection list")
+	}
+	collections, err := s.db.GetCollectionsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get collections: %w", err)
+	}
+	if len(collections) == 0 {
+		fmt.Println("No collections yet. Create one with: gator collection create <tag> <name>")
+		return nil
+	}
+
+	fmt.Printf("Your %d collection(s):\n", len(collections))
+	for _, c := range collections {
+		fmt.Printf("* %s (tag %s)\n", c.Name, c.Tag)
+		if c.Description != "" {
+			fmt.Printf("  %s\n", c.Description)
+		}
+		if c.ShareToken.Valid {
+			fmt.Printf("  Shared at %s\n", shareURL(s, c.ShareToken.String))
+		}
+	}
+	return nil
+}
+
+// handlerCollectionShare gives a collection a share link, replacing the one
+// it had, so sharing again cuts off everyone who had the old link
+func handlerCollectionShare(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator collection share <tag>")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("couldn't generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	n, err := s.db.SetCollectionShareToken(context.Background(), database.SetCollectionShareTokenParams{
+		UserID:     user.ID,
+		Tag:        tags[0],
+		ShareToken: sql.NullString{String: token, Valid: true},
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't share collection: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("tag %s has no collection, create one with: gator collection create %s <name>", tags[0], tags[0])
+	}
+
+	link := shareURL(s, token)
+	fmt.Printf("Anyone with the link can read the collection while gator serve runs:\n\n  %s\n  %s/rss (RSS feed)\n\n", link, link)
+	fmt.Printf("Stop sharing with: gator collection unshare %s\n", tags[0])
+	return nil
+}
+
+func handlerCollectionUnshare(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator collection unshare <tag>")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+	n, err := s.db.SetCollectionShareToken(context.Background(), database.SetCollectionShareTokenParams{
+		UserID:    user.ID,
+		Tag:       tags[0],
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unshare collection: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("tag %s has no collection", tags[0])
+	}
+	fmt.Printf("The collection of %s is no longer shared\n", tags[0])
+	return nil
+}
+
+// handlerCollectionDelete removes a collection and its share link. The
+// bookmarks and their tag stay.
+func handlerCollectionDelete(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator collection delete <tag>")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+	n, err := s.db.DeleteCollection(context.Background(), database.DeleteCollectionParams{
+		UserID: user.ID,
+		Tag:    tags[0],
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't delete collection: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("tag %s has no collection", tags[0])
+	}
+	fmt.Printf("Deleted the collection of %s, its bookmarks are kept\n", tags[0])
+	return nil
+}
+
+// shareURL is the link to a shared collection, on public_url if it is set
+func shareURL(s *state, token string) string {
+	base := strings.TrimRight(s.cfg.PublicURL, "/")
+	if base == "" {
+		base = "http://localhost" + defaultServeAddr
+	}
+	return base + "/shared/" + token
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/olereon/Gator/internal/database"
+)
+
+const (
+	defaultCompareWindow = 30 * 24 * time.Hour
+	// titleSimilarity is the share of title words two posts need in common
+	// to count as the same story
+	titleSimilarity = 0.6
+	compareExamples = 5
+)
+
+// comparedFeed is one side of feed compare
+type comparedFeed struct {
+	feed  database.Feed
+	posts []database.GetPostsForFeedSinceRow
+	keys  []string
+	words []map[string]bool
+	// only holds the posts the other feed has no match for
+	only []database.GetPostsForFeedSinceRow
+}
+
+func handlerFeedCompare(s *state, cmd command) error {
+	now := time.Now().UTC()
+	since := now.Add(-defaultCompareWindow)
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--since=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--since="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			since = t
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return errors.New("usage: gator feed compare <url1> <url2> [--since=30d]")
+	}
+
+	a, err := loadComparedFeed(s, positional[0], since)
+	if err != nil {
+		return err
+	}
+	b, err := loadComparedFeed(s, positional[1], since)
+	if err != nil {
+		return err
+	}
+	if a.feed.ID == b.feed.ID {
+		return errors.New("those are the same feed")
+	}
+
+	shared := matchStories(a, b)
+
+	days := max(now.Sub(since).Hours()/24, 1)
+	fmt.Printf("Comparing posts since %s:\n", since.Local().Format("Mon, 02 Jan 2006"))
+	for _, f := range []*comparedFeed{a, b} {
+		fmt.Printf("  %s: %d post(s), %.1f a day\n", f.feed.Name, len(f.posts), float64(len(f.posts))/days)
+	}
+	if len(a.posts) == 0 || len(b.posts) == 0 {
+		fmt.Println("Nothing to compare, fetch both feeds for a while first.")
+		return nil
+	}
+
+	fmt.Printf("\nShared stories: %d (%s of %s, %s of %s)\n",
+		shared, percent(shared, len(a.posts)), a.feed.Name, percent(shared, len(b.posts)), b.feed.Name)
+	for _, f := range []*comparedFeed{a, b} {
+		fmt.Printf("\nOnly in %s: %d\n", f.feed.Name, len(f.only))
+		for _, post := range f.only[:min(len(f.only), compareExamples)] {
+			fmt.Printf("  - %s (%s)\n", post.Title, post.PostedAt.Format("Jan 02"))
+		}
+		if len(f.only) > compareExamples {
+			fmt.Printf("  ... and %d more\n", len(f.only)-compareExamples)
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case len(a.only) == 0 && len(b.only) == 0:
+		fmt.Println("Both feeds carry the same stories, either one will do.")
+	case len(a.only) == 0:
+		fmt.Printf("%s covers everything %s has.\n", b.feed.Name, a.feed.Name)
+	case len(b.only) == 0:
+		fmt.Printf("%s covers everything %s has.\n", a.feed.Name, b.feed.Name)
+	}
+	return nil
+}
+
+func loadComparedFeed(s *state, feedURL string, since time.Time) (*comparedFeed, error) {
+	feed, err := s.db.GetFeedByURL(context.Background(), feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find feed %s: %w", feedURL, err)
+	}
+	posts, err := s.db.GetPostsForFeedSince(context.Background(), database.GetPostsForFeedSinceParams{
+		FeedID:      feed.ID,
+		PublishedAt: sql.NullTime{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get posts for %s: %w", feed.Name, err)
+	}
+
+	keys := make([]string, len(posts))
+	words := make([]map[string]bool, len(posts))
+	for i, post := range posts {
+		keys[i] = storyURLKey(post.Url)
+		words[i] = titleWords(post.Title)
+	}
+	return &comparedFeed{feed: feed, posts: posts, keys: keys, words: words}, nil
+}
+
+// matchStories pairs up posts of a and b that link to the same page or
+// have nearly the same title, each post matching at most once. It fills in
+// the posts left over on each side and returns the number of pairs.
+func matchStories(a, b *comparedFeed) int {
+	matched := make([]bool, len(b.posts))
+	shared := 0
+	for i, post := range a.posts {
+		found := -1
+		for j := range b.posts {
+			if matched[j] {
+				continue
+			}
+			if a.keys[i] != "" && a.keys[i] == b.keys[j] {
+				found = j
+				break
+			}
+			if found < 0 && wordOverlap(a.words[i], b.words[j]) >= titleSimilarity {
+				found = j
+			}
+		}
+		if found < 0 {
+			a.only = append(a.only, post)
+			continue
+		}
+		matched[found] = true
+		shared++
+	}
+	for j, post := range b.posts {
+		if !matched[j] {
+			b.only = append(b.only, post)
+		}
+	}
+	return shared
+}
+
+// storyURLKey reduces a post URL to what identifies the story: host
+// without www, path without trailing slash, and the query without utm_
+// tracking parameters. The scheme and fragment are left out.
+func storyURLKey(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	query := u.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
+		}
+	}
+	key := strings.TrimPrefix(strings.ToLower(u.Host), "www.") + strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// titleWords is the set of lower-cased words of a title, leaving out the
+// short ones that most titles have in common
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) > 2 {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// wordOverlap is the Jaccard similarity of two word sets
+func wordOverlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// fetchContent downloads the article behind post and stores its cleaned
+// content for reading offline. The canonical URL the page names is stored
+// too, which may merge post into an earlier copy of the same story, and
+// so is the page's preview if post is sparse.
+func fetchContent(s *state, client *http.Client, post database.Post) error {
+	ctx, cancel := context.WithTimeout(context.Background(), articleFetchTimeout)
+	defer cancel()
+
+	a, err := article.Fetch(ctx, client, post.Url)
+	if err != nil {
+		return err
+	}
+	if sparsePost(post) {
+		if err := savePreview(s, post.ID, a.Preview); err != nil {
+			return err
+		}
+	}
+	if a.Content == "" {
+		return errors.New("no article content found on the page")
+	}
+
+	postID := post.ID
+	if a.Canonical != "" {
+		postID, err = setCanonicalURL(s, post, a.Canonical)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.SavePostContent(context.Background(), database.SavePostContentParams{
+		PostID:    postID,
+		FetchedAt: time.Now().UTC(),
+		Content:   a.Content,
+	})
+}
+
+// fetchContents queues the posts agg just ingested for their articles, when
+// it runs with --full
+func fetchContents(sc *scraper, feed database.Feed, posts []database.Post) {
+	if !sc.fullContent {
+		return
+	}
+	for _, post := range posts {
+		sc.pages.add(pageJob{post: post, client: sc.clientFor(feed), what: "content", fetch: fetchContent})
+	}
+}
+
+// postText returns the stored article of a post as terminal text with its
+// links as footnotes, or its feed summary if the article hasn't been fetched
+func postText(s *state, postID uuid.UUID, postURL string, description sql.NullString) (string, bool) {
+	content, err := s.db.GetPostContent(context.Background(), postID)
+	if err == nil {
+		return article.Render(content.Content, postURL).String(), true
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Couldn't get stored content: %v\n", err)
+	}
+	return article.Render(description.String, postURL).String(), false
+}
+
+func handlerFetchContent(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("usage: gator fetch-content <post_url|number>...")
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+
+	for _, ref := range cmd.args {
+		post, err := resolvePost(s, user, ref)
+		if err != nil {
+			return fmt.Errorf("couldn't find post: %w", err)
+		}
+		if err := fetchContent(s, client, post); err != nil {
+			return fmt.Errorf("couldn't fetch content of %s: %w", post.Url, err)
+		}
+		fmt.Printf("Stored full text of: %s\n", post.Title)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/olereon/Gator/internal/config"
+)
+
+// configurePool sizes the connection pool from the config. Negative counts
+// are rejected rather than passed on, where they would mean something else.
+func configurePool(db *sql.DB, cfg *config.Config) error {
+	if cfg.DBMaxOpenConns < 0 {
+		return fmt.Errorf("invalid db_max_open_conns %d", cfg.DBMaxOpenConns)
+	}
+	if cfg.DBMaxIdleConns < 0 {
+		return fmt.Errorf("invalid db_max_idle_conns %d", cfg.DBMaxIdleConns)
+	}
+	if cfg.DBMaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	}
+	if cfg.DBMaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	}
+	if cfg.DBConnMaxLifetime != "" {
+		d, err := time.ParseDuration(cfg.DBConnMaxLifetime)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid db_conn_max_lifetime %q, use e.g. 30m", cfg.DBConnMaxLifetime)
+		}
+		db.SetConnMaxLifetime(d)
+	}
+	return nil
+}
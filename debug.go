@@ -0,0 +1,47 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/olereon/Gator/internal/dbtrace"
+)
+
+// extractFlag removes a global on/off flag such as --debug from args and
+// reports whether it was given
+func extractFlag(args []string, flag string) ([]string, bool) {
+	found := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// openDB opens the database. With an observer the connections report every
+// statement and row to it.
+func openDB(dsn string, o dbtrace.Observer) (*sql.DB, error) {
+	if o == nil {
+		return sql.Open("postgres", dsn)
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(dbtrace.WrapConnector(connector, o)), nil
+}
+
+// printDebugReport prints what a command cost, for --debug. It goes to
+// stderr so it doesn't mix with output that is piped somewhere.
+func printDebugReport(cmd command, started time.Time, counter *dbtrace.Counter) {
+	queries, rows := counter.Counts()
+	fmt.Fprintf(os.Stderr, "debug: %s took %s, %d SQL queries, %d rows read\n",
+		cmd.name, time.Since(started).Round(time.Millisecond), queries, rows)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/layout"
+)
+
+const (
+	defaultDomainWindow = 30 * 24 * time.Hour
+	defaultDomainLimit  = 20
+)
+
+// normalizeDomain accepts a bare domain or a link to it and returns the
+// host the way posts.domain stores it: lower-case, without www.
+func normalizeDomain(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid domain %q", value)
+		}
+		value = u.Hostname()
+	}
+	domain := strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(value), "."), "www.")
+	if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "/:?#@ ") {
+		return "", fmt.Errorf("invalid domain %q", value)
+	}
+	return domain, nil
+}
+
+// handlerMute hides everything linking to a domain, from any feed
+func handlerMute(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 || cmd.args[0] != "domain" {
+		return errors.New("usage: gator mute domain <domain>")
+	}
+	domain, err := normalizeDomain(cmd.args[1])
+	if err != nil {
+		return err
+	}
+
+	added, err := s.db.MuteDomain(context.Background(), database.MuteDomainParams{
+		UserID:    user.ID,
+		Domain:    domain,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mute domain: %w", err)
+	}
+	if added == 0 {
+		fmt.Printf("%s is already muted\n", domain)
+		return nil
+	}
+
+	fmt.Printf("Muted %s: posts linking to it or its subdomains are hidden from every feed\n", domain)
+	return nil
+}
+
+func handlerUnmute(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 || cmd.args[0] != "domain" {
+		return errors.New("usage: gator unmute domain <domain>")
+	}
+	domain, err := normalizeDomain(cmd.args[1])
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.db.UnmuteDomain(context.Background(), database.UnmuteDomainParams{
+		UserID: user.ID,
+		Domain: domain,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unmute domain: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%s isn't muted", domain)
+	}
+
+	fmt.Printf("Unmuted %s\n", domain)
+	return nil
+}
+
+// handlerDomains shows which sites the followed feeds link to most, across
+// feeds, and the muted domains
+func handlerDomains(s *state, cmd command, user database.User) error {
+	limit := defaultDomainLimit
+	now := time.Now().UTC()
+	since := now.Add(-defaultDomainWindow)
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--since=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--since="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			since = t
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = l
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	stats, err := s.db.GetDomainStats(context.Background(), database.GetDomainStatsParams{
+		UserID:      user.ID,
+		PublishedAt: sql.NullTime{Time: since, Valid: true},
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get domain stats: %w", err)
+	}
+
+	if len(stats) == 0 {
+		fmt.Printf("No posts since %s\n", since.Local().Format("Mon, 02 Jan 2006"))
+	} else {
+		fmt.Printf("Most linked domains since %s:\n", since.Local().Format("Mon, 02 Jan 2006"))
+		for _, d := range stats {
+			muted := ""
+			if d.Muted {
+				muted = " (muted)"
+			}
+			fmt.Printf("  %s %5d post(s) from %d feed(s)%s\n", layout.PadRight(d.Domain, 30), d.Posts, d.Feeds, muted)
+		}
+	}
+
+	mutedDomains, err := s.db.GetMutedDomains(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get muted domains: %w", err)
+	}
+	if len(mutedDomains) > 0 {
+		fmt.Println()
+		fmt.Printf("Muted domains (%d):\n", len(mutedDomains))
+		for _, d := range mutedDomains {
+			fmt.Printf("  %s since %s\n", d.Domain, d.CreatedAt.Format("Mon, 02 Jan 2006"))
+		}
+	}
+	return nil
+}
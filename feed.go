@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// handlerFeed dispatches the "feed <subcommand>" family of commands
+func handlerFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: compare, request, requests, categorize, set-title-rules, set-interval, set-headlines-only, set-fetch-policy, log, enable, remove, merge, disown")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	if s.readOnly && sub.name != "log" && sub.name != "compare" && sub.name != "requests" {
+		return fmt.Errorf("feed %s: %w", sub.name, errReadOnly)
+	}
+	switch sub.name {
+	case "compare":
+		return handlerFeedCompare(s, sub)
+	case "request":
+		return handlerFeedRequest(s, sub, user)
+	case "requests":
+		return handlerFeedRequests(s, sub, user)
+	case "categorize":
+		return handlerFeedCategorize(s, sub, user)
+	case "set-title-rules":
+		return handlerFeedSetTitleRules(s, sub, user)
+	case "set-interval":
+		return handlerFeedSetInterval(s, sub, user)
+	case "set-headlines-only":
+		return handlerFeedSetHeadlinesOnly(s, sub, user)
+	case "set-fetch-policy":
+		return handlerFeedSetFetchPolicy(s, sub, user)
+	case "log":
+		return handlerFeedLog(s, sub)
+	case "enable":
+		return handlerFeedEnable(s, sub, user)
+	case "remove":
+		return handlerFeedRemove(s, sub, user)
+	case "merge":
+		return handlerFeedMerge(s, sub, user)
+	case "disown":
+		return handlerFeedDisown(s, sub, user)
+	default:
+		return fmt.Errorf("unknown feed subcommand: %s", sub.name)
+	}
+}
+
+// checkCanManageFeed fails unless the user added the feed or is an admin,
+// since a feed's settings apply to all of its followers
+func checkCanManageFeed(s *state, feed database.Feed, user database.User) error {
+	if feed.UserID == user.ID || isAdmin(s, user) {
+		return nil
+	}
+	return fmt.Errorf("only the user who added %s or an admin can change it", feed.Name)
+}
+
+// handlerFeedCategorize files a feed the user follows under a category, or
+// takes it out of its category when none is given. Categories are each
+// follower's own.
+func handlerFeedCategorize(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 || len(cmd.args) > 2 {
+		return errors.New("usage: gator feed categorize <url> [category]")
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	category := sql.NullString{}
+	if len(cmd.args) == 2 && strings.TrimSpace(cmd.args[1]) != "" {
+		category = sql.NullString{String: strings.TrimSpace(cmd.args[1]), Valid: true}
+	}
+
+	n, err := s.db.SetFollowCategory(context.Background(), database.SetFollowCategoryParams{
+		Category:  category,
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set category: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you don't follow %s, follow it first with: gator follow %s", feed.Name, feed.Url)
+	}
+
+	if category.Valid {
+		fmt.Printf("%s is now in %s\n", feed.Name, category.String)
+	} else {
+		fmt.Printf("%s is no longer in a category\n", feed.Name)
+	}
+	return nil
+}
+
+func handlerFeedSetTitleRules(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		names := make([]string, len(rss.TitleRules))
+		for i, rule := range rss.TitleRules {
+			names[i] = string(rule)
+		}
+		return fmt.Errorf("url is required. Available rules: %s", strings.Join(names, ", "))
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkCanManageFeed(s, feed, user); err != nil {
+		return err
+	}
+
+	rules := []string{}
+	for _, arg := range cmd.args[1:] {
+		rule, err := rss.ParseTitleRule(arg)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, string(rule))
+	}
+
+	err = s.db.SetFeedTitleRules(context.Background(), database.SetFeedTitleRulesParams{
+		ID:         feed.ID,
+		TitleRules: rules,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set title rules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		fmt.Printf("Cleared title rules for %s\n", feed.Name)
+	} else {
+		fmt.Printf("Title rules for %s: %s\n", feed.Name, strings.Join(rules, ", "))
+	}
+	return nil
+}
+
+// handlerFeedEnable brings back a feed that was disabled after failing too
+// often, so agg fetches it again right away
+func handlerFeedEnable(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("url is required")
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkCanManageFeed(s, feed, user); err != nil {
+		return err
+	}
+	if feed.ID == announcementsFeedID {
+		return errors.New("the announcements feed isn't fetched, gator posts to it directly")
+	}
+	if !feed.DisabledAt.Valid {
+		fmt.Printf("%s isn't disabled\n", feed.Name)
+		return nil
+	}
+
+	err = s.db.EnableFeed(context.Background(), database.EnableFeedParams{
+		ID:        feed.ID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't enable feed: %w", err)
+	}
+
+	fmt.Printf("Enabled %s, agg will fetch it again right away\n", feed.Name)
+	return nil
+}
+
+func handlerFeedRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("url is required")
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	// Removing a feed hides it from every follower, so only its creator may
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can remove it", feed.Name)
+	}
+
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	err = q.DeleteFeed(ctx, database.DeleteFeedParams{
+		ID:        feed.ID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't remove feed: %w", err)
+	}
+	err = q.ReleaseFeedAliases(ctx, uuid.NullUUID{UUID: feed.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("couldn't release aliases: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit transaction: %w", err)
+	}
+
+	fmt.Printf("Removed feed %s (use 'gator undo' to restore it)\n", feed.Name)
+	return nil
+}
+
+// handlerFeedDisown hands a feed the user added over to the system user, so
+// it outlives the user's account
+func handlerFeedDisown(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("url is required")
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can disown it", feed.Name)
+	}
+
+	systemUser, err := s.db.GetSystemUser(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get system user: %w", err)
+	}
+
+	err = s.db.SetFeedOwner(ctx, database.SetFeedOwnerParams{
+		ID:        feed.ID,
+		UserID:    systemUser.ID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't change feed owner: %w", err)
+	}
+
+	fmt.Printf("%s is now owned by the system user\n", feed.Name)
+	return nil
+}
+
+// feedTitleRules converts the rules stored on feed, skipping any this
+// version of gator doesn't know
+func feedTitleRules(feed database.Feed) []rss.TitleRule {
+	rules := make([]rss.TitleRule, 0, len(feed.TitleRules))
+	for _, name := range feed.TitleRules {
+		if rule, err := rss.ParseTitleRule(name); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/olereon/Gator/internal/config"
+)
+
+// domainMatches reports whether host is domain or one of its subdomains
+func domainMatches(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// checkFeedPolicy rejects feed URLs whose host is on blocked_domains or,
+// when allowed_domains is set, not on it
+func checkFeedPolicy(cfg *config.Config, feedURL string) error {
+	if len(cfg.BlockedDomains) == 0 && len(cfg.AllowedDomains) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid feed URL %q", feedURL)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+
+	for _, domain := range cfg.BlockedDomains {
+		if domainMatches(host, domain) {
+			return fmt.Errorf("feeds from %s are blocked on this installation", host)
+		}
+	}
+
+	if len(cfg.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range cfg.AllowedDomains {
+		if domainMatches(host, domain) {
+			return nil
+		}
+	}
+	if len(cfg.Admins) > 0 {
+		return fmt.Errorf("%w, ask an admin for it with: gator feed request %s", notAllowedError{host}, feedURL)
+	}
+	return notAllowedError{host}
+}
+
+// notAllowedError is checkFeedPolicy's error for a feed outside
+// allowed_domains, which an admin can still approve
+type notAllowedError struct {
+	host string
+}
+
+func (e notAllowedError) Error() string {
+	return fmt.Sprintf("feeds from %s aren't allowed on this installation", e.host)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/webhook"
+)
+
+const (
+	feedRequestApproved = "approved"
+	feedRequestDenied   = "denied"
+)
+
+func isAdmin(s *state, user database.User) bool {
+	return slices.Contains(s.cfg.Admins, user.Name)
+}
+
+// handlerFeedRequest asks the admins for a feed outside allowed_domains
+func handlerFeedRequest(s *state, cmd command, user database.User) error {
+	name := ""
+	reason := ""
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--name=") {
+			name = strings.TrimSpace(strings.TrimPrefix(arg, "--name="))
+		} else if strings.HasPrefix(arg, "--reason=") {
+			reason = strings.TrimSpace(strings.TrimPrefix(arg, "--reason="))
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: gator feed request <url> [--name=<name>] [--reason=<why>]")
+	}
+	feedURL := positional[0]
+	if len(s.cfg.Admins) == 0 {
+		return errors.New(`no admins to ask, add "admins": ["<user name>"] to ~/.gatorconfig.json`)
+	}
+
+	// Only allowed_domains can be waived, blocked domains stay blocked
+	err := checkFeedPolicy(s.cfg, feedURL)
+	var notAllowed notAllowedError
+	if err != nil && !errors.As(err, &notAllowed) {
+		return err
+	}
+	if err == nil {
+		return fmt.Errorf("no need to ask, add it with: gator addfeed <name> %s", feedURL)
+	}
+	if name == "" {
+		name = feedURL
+	}
+
+	request, err := s.db.CreateFeedRequest(context.Background(), database.CreateFeedRequestParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Url:       feedURL,
+		Name:      name,
+		Reason:    reason,
+	})
+	if err != nil {
+		if err.Error() == `pq: duplicate key value violates unique constraint "feed_requests_pending_key"` {
+			return errors.New("you already asked for this feed, see: gator feed requests")
+		}
+		return fmt.Errorf("couldn't create feed request: %w", err)
+	}
+
+	notify(s, webhook.EventFeedRequested, feedRequestData(request, user.Name, ""))
+	fmt.Printf("Asked the admins for %s. See how it goes with: gator feed requests\n", feedURL)
+	return nil
+}
+
+// handlerFeedRequests lists the pending requests for admins, and each
+// user's own requests for everyone else. Admins approve and deny them by
+// their number in the list.
+func handlerFeedRequests(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		if isAdmin(s, user) {
+			return listPendingFeedRequests(s)
+		}
+		return listOwnFeedRequests(s, user)
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	switch sub.name {
+	case "approve", "deny":
+	case "mine":
+		return listOwnFeedRequests(s, user)
+	default:
+		return fmt.Errorf("unknown feed requests subcommand: %s", sub.name)
+	}
+
+	if s.readOnly {
+		return fmt.Errorf("feed requests %s: %w", sub.name, errReadOnly)
+	}
+	if !isAdmin(s, user) {
+		return fmt.Errorf("only admins can %s feed requests", sub.name)
+	}
+	note := ""
+	var positional []string
+	for _, arg := range sub.args {
+		if strings.HasPrefix(arg, "--note=") {
+			note = strings.TrimSpace(strings.TrimPrefix(arg, "--note="))
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gator feed requests %s <number> [--note=<text>]", sub.name)
+	}
+	n, err := strconv.Atoi(positional[0])
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid request number: %s", positional[0])
+	}
+
+	requests, err := s.db.GetPendingFeedRequests(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't get feed requests: %w", err)
+	}
+	if n > len(requests) {
+		return fmt.Errorf("no request #%d, there are %d pending", n, len(requests))
+	}
+	request := requests[n-1]
+
+	if sub.name == "approve" {
+		return approveFeedRequest(s, request, user, note)
+	}
+	return decideFeedRequest(s, request, user, feedRequestDenied, note)
+}
+
+func listPendingFeedRequests(s *state) error {
+	requests, err := s.db.GetPendingFeedRequests(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't get feed requests: %w", err)
+	}
+	if len(requests) == 0 {
+		fmt.Println("No pending feed requests.")
+		return nil
+	}
+
+	fmt.Printf("%d pending feed request(s):\n", len(requests))
+	for i, r := range requests {
+		fmt.Printf("%d. %s (%s) from %s on %s\n", i+1, r.Name, r.Url, r.UserName, r.CreatedAt.Local().Format("2006-01-02"))
+		if r.Reason != "" {
+			fmt.Printf("   %s\n", r.Reason)
+		}
+	}
+	fmt.Println("Decide with: gator feed requests approve|deny <number> [--note=<text>]")
+	return nil
+}
+
+func listOwnFeedRequests(s *state, user database.User) error {
+	requests, err := s.db.GetFeedRequestsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed requests: %w", err)
+	}
+	if len(requests) == 0 {
+		fmt.Println("You haven't asked for any feeds. Ask with: gator feed request <url> --reason=<why>")
+		return nil
+	}
+
+	fmt.Printf("Your %d feed request(s):\n", len(requests))
+	for _, r := range requests {
+		fmt.Printf("* %s (%s): %s", r.Name, r.Url, r.Status)
+		if r.DecidedAt.Valid {
+			fmt.Printf(" on %s", r.DecidedAt.Time.Local().Format("2006-01-02"))
+		}
+		fmt.Println()
+		if r.Note != "" {
+			fmt.Printf("  %s\n", r.Note)
+		}
+	}
+	return nil
+}
+
+// approveFeedRequest adds the feed, unless gator has it already, and makes
+// the requester follow it
+func approveFeedRequest(s *state, request database.GetPendingFeedRequestsRow, admin database.User, note string) error {
+	// Policy may have changed since the request, blocked domains still win
+	err := checkFeedPolicy(s.cfg, request.Url)
+	var notAllowed notAllowedError
+	if err != nil && !errors.As(err, &notAllowed) {
+		return err
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, request.Url)
+	if errors.Is(err, sql.ErrNoRows) {
+		feed, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      request.Name,
+			Url:       request.Url,
+			UserID:    request.UserID,
+		})
+		if err == nil {
+			notify(s, webhook.EventFeedAdded, webhook.FeedData{
+				ID:      feed.ID.String(),
+				Name:    feed.Name,
+				URL:     feed.Url,
+				AddedBy: request.UserName,
+			})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't add feed: %w", err)
+	}
+
+	_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    request.UserID,
+		FeedID:    feed.ID,
+	})
+	if err != nil && err.Error() != `pq: duplicate key value violates unique constraint "feed_follows_user_id_feed_id_key"` {
+		return fmt.Errorf("couldn't create feed follow: %w", err)
+	}
+
+	return decideFeedRequest(s, request, admin, feedRequestApproved, note)
+}
+
+func decideFeedRequest(s *state, request database.GetPendingFeedRequestsRow, admin database.User, status, note string) error {
+	n, err := s.db.DecideFeedRequest(context.Background(), database.DecideFeedRequestParams{
+		ID:        request.ID,
+		Status:    status,
+		DecidedBy: uuid.NullUUID{UUID: admin.ID, Valid: true},
+		DecidedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		Note:      note,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't decide feed request: %w", err)
+	}
+	if n == 0 {
+		return errors.New("the request was decided in the meantime")
+	}
+
+	decided := database.FeedRequest{
+		ID:     request.ID,
+		Url:    request.Url,
+		Name:   request.Name,
+		Reason: request.Reason,
+		Status: status,
+		Note:   note,
+	}
+	notify(s, webhook.EventFeedRequestDecided, feedRequestData(decided, request.UserName, admin.Name))
+	fmt.Printf("%s the request of %s for %s\n", strings.ToUpper(status[:1])+status[1:], request.UserName, request.Url)
+	return nil
+}
+
+func feedRequestData(request database.FeedRequest, requestedBy, decidedBy string) webhook.FeedRequestData {
+	return webhook.FeedRequestData{
+		ID:          request.ID.String(),
+		URL:         request.Url,
+		Name:        request.Name,
+		Reason:      request.Reason,
+		RequestedBy: requestedBy,
+		Status:      request.Status,
+		DecidedBy:   decidedBy,
+		Note:        request.Note,
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const (
+	defaultFetchLogRetention = 30 * 24 * time.Hour
+	defaultFetchLogLimit     = 20
+)
+
+// fetchLogRetention returns how long fetch attempts are kept in the log
+func fetchLogRetention(cfg *config.Config) (time.Duration, error) {
+	if cfg.FetchLogRetention == "" {
+		return defaultFetchLogRetention, nil
+	}
+	d, err := time.ParseDuration(cfg.FetchLogRetention)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fetch_log_retention: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("fetch_log_retention must be positive, got %s", d)
+	}
+	return d, nil
+}
+
+// logFetch records a single fetch attempt of feed. rssFeed is nil when the
+// fetch failed.
+func logFetch(s *state, feed database.Feed, started time.Time, rssFeed *rss.RSSFeed, newPosts int, fetchErr error) {
+	params := database.CreateFetchLogParams{
+		ID:         uuid.New(),
+		FeedID:     feed.ID,
+		FetchedAt:  started,
+		DurationMs: time.Since(started).Milliseconds(),
+		NewPosts:   int32(newPosts),
+	}
+	if rssFeed != nil {
+		params.StatusCode = sql.NullInt32{Int32: int32(rssFeed.StatusCode), Valid: true}
+		params.Bytes = sql.NullInt32{Int32: int32(rssFeed.Size), Valid: true}
+	}
+	if fetchErr != nil {
+		var statusErr *rss.StatusError
+		if errors.As(fetchErr, &statusErr) {
+			params.StatusCode = sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+		}
+		params.ErrorKind = sql.NullString{String: string(rss.ClassifyError(fetchErr)), Valid: true}
+		params.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	if err := s.db.CreateFetchLog(context.Background(), params); err != nil {
+		slog.Error("couldn't log fetch", "feed", feed.Name, "err", err)
+	}
+}
+
+// pruneFetchLog drops fetch attempts older than retention
+func pruneFetchLog(s *state, retention time.Duration) {
+	_, err := s.db.DeleteFetchLogBefore(context.Background(), time.Now().UTC().Add(-retention))
+	if err != nil {
+		slog.Error("couldn't prune fetch log", "err", err)
+	}
+}
+
+// formatBytes renders a response size for humans
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func handlerFeedLog(s *state, cmd command) error {
+	limit := int32(defaultFetchLogLimit)
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--last=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--last="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --last: %s", arg)
+			}
+			limit = int32(l)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: gator feed log <url> [--last=N]")
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	entries, err := s.db.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{
+		FeedID: feed.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get fetch log: %w", err)
+	}
+
+	changes, err := s.db.GetFeedURLChanges(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get URL changes: %w", err)
+	}
+	for _, c := range changes {
+		fmt.Printf("* %s  moved permanently from %s to %s\n",
+			c.ChangedAt.Format("Mon, 02 Jan 2006 15:04:05 MST"), c.OldUrl, c.NewUrl)
+	}
+	if len(changes) > 0 {
+		fmt.Println()
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No fetches of %s recorded yet.\n", feed.Name)
+		return nil
+	}
+
+	fmt.Printf("Last %d fetch(es) of %s:\n", len(entries), feed.Name)
+	for _, e := range entries {
+		status := "-"
+		if e.StatusCode.Valid {
+			status = strconv.Itoa(int(e.StatusCode.Int32))
+		}
+		took := time.Duration(e.DurationMs) * time.Millisecond
+		when := e.FetchedAt.Format("Mon, 02 Jan 2006 15:04:05 MST")
+
+		if e.Error.Valid {
+			fmt.Printf("* %s  %s  failed in %s (%s): %s\n", when, status, took, e.ErrorKind.String, e.Error.String)
+			continue
+		}
+		fmt.Printf("* %s  %s  %s in %s, %d new posts\n", when, status, formatBytes(int64(e.Bytes.Int32)), took, e.NewPosts)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// parseSize parses a byte count such as 512KB, 10MB or 1048576
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, use e.g. 512KB or 10MB", s)
+	}
+	return n * factor, nil
+}
+
+// fetchPolicy returns the configured timeout, retries and size limit for
+// feed fetches
+func fetchPolicy(cfg *config.Config) (rss.FetchPolicy, error) {
+	policy := rss.DefaultFetchPolicy
+
+	timeout, err := fetchTimeout(cfg)
+	if err != nil {
+		return rss.FetchPolicy{}, err
+	}
+	policy.Timeout = timeout
+
+	if cfg.FetchRetries != nil {
+		if *cfg.FetchRetries < 0 {
+			return rss.FetchPolicy{}, fmt.Errorf("fetch_retries can't be negative, got %d", *cfg.FetchRetries)
+		}
+		policy.Retries = *cfg.FetchRetries
+	}
+
+	if cfg.MaxFeedSize != "" {
+		size, err := parseSize(cfg.MaxFeedSize)
+		if err != nil {
+			return rss.FetchPolicy{}, fmt.Errorf("invalid max_feed_size: %w", err)
+		}
+		policy.MaxBodySize = size
+	}
+
+	return policy, nil
+}
+
+// policyFor applies a feed's own overrides to the configured fetch policy
+func (sc *scraper) policyFor(feed database.Feed) rss.FetchPolicy {
+	policy := sc.policy
+	if feed.FetchTimeoutSeconds.Valid {
+		policy.Timeout = time.Duration(feed.FetchTimeoutSeconds.Int32) * time.Second
+	}
+	if feed.FetchRetries.Valid {
+		policy.Retries = int(feed.FetchRetries.Int32)
+	}
+	if feed.MaxBodyBytes.Valid {
+		policy.MaxBodySize = feed.MaxBodyBytes.Int64
+	}
+	return policy
+}
+
+func handlerFeedSetFetchPolicy(s *state, cmd command, user database.User) error {
+	usage := errors.New("usage: gator feed set-fetch-policy <url> [--timeout=<duration|auto>] [--retries=<n|auto>] [--max-size=<size|auto>]")
+
+	var positional, flags []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--") {
+			flags = append(flags, arg)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 || len(flags) == 0 {
+		return usage
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkCanManageFeed(s, feed, user); err != nil {
+		return err
+	}
+
+	timeout, retries, maxSize := feed.FetchTimeoutSeconds, feed.FetchRetries, feed.MaxBodyBytes
+	for _, arg := range flags {
+		name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		switch name {
+		case "timeout":
+			timeout = sql.NullInt32{}
+			if value != "auto" {
+				d, err := time.ParseDuration(value)
+				if err != nil || d < time.Second {
+					return fmt.Errorf("invalid --timeout %q, use a duration of at least 1s or auto", value)
+				}
+				timeout = sql.NullInt32{Int32: int32(d.Seconds()), Valid: true}
+			}
+		case "retries":
+			retries = sql.NullInt32{}
+			if value != "auto" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return fmt.Errorf("invalid --retries %q, use a number or auto", value)
+				}
+				retries = sql.NullInt32{Int32: int32(n), Valid: true}
+			}
+		case "max-size":
+			maxSize = sql.NullInt64{}
+			if value != "auto" {
+				size, err := parseSize(value)
+				if err != nil {
+					return fmt.Errorf("invalid --max-size: %w", err)
+				}
+				maxSize = sql.NullInt64{Int64: size, Valid: true}
+			}
+		default:
+			return usage
+		}
+	}
+
+	err = s.db.SetFeedFetchPolicy(ctx, database.SetFeedFetchPolicyParams{
+		ID:                  feed.ID,
+		FetchTimeoutSeconds: timeout,
+		FetchRetries:        retries,
+		MaxBodyBytes:        maxSize,
+		UpdatedAt:           time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set fetch policy: %w", err)
+	}
+
+	fmt.Printf("Fetch policy for %s: %s\n", feed.Name, describeFetchPolicy(timeout, retries, maxSize))
+	return nil
+}
+
+// describeFetchPolicy summarizes a feed's overrides, "defaults" if it has none
+func describeFetchPolicy(timeout, retries sql.NullInt32, maxSize sql.NullInt64) string {
+	var parts []string
+	if timeout.Valid {
+		parts = append(parts, fmt.Sprintf("timeout %s", time.Duration(timeout.Int32)*time.Second))
+	}
+	if retries.Valid {
+		parts = append(parts, fmt.Sprintf("%d retries", retries.Int32))
+	}
+	if maxSize.Valid {
+		parts = append(parts, fmt.Sprintf("max size %s", formatBytes(maxSize.Int64)))
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+const (
+	feverAPIVersion = 3
+	// feverMaxItems is how many items a Fever client gets per request, as
+	// the API defines
+	feverMaxItems = 50
+)
+
+// feverFeedGroup lists the feeds of one group as the API wants them, with
+// the IDs joined by commas
+type feverFeedGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// handleFever answers the Fever API, which mobile readers such as Reeder
+// and Unread sync with. Clients log in with the gator user name as the
+// email and one of the user's api_tokens as the password. Feed categories
+// are the Fever groups.
+func (a *apiServer) handleFever(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	user, err := a.feverUser(r.Context(), r.Form.Get("api_key"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err == nil {
+		resp["auth"] = 1
+		err = a.fever(r, user, resp)
+	}
+	if err != nil {
+		var apiErr apiError
+		if !errors.As(err, &apiErr) {
+			slog.Error("couldn't answer Fever request", "query", r.URL.RawQuery, "err", err)
+			apiErr = apiError{http.StatusInternalServerError, "internal error"}
+		}
+		resp["error"] = apiErr.message
+		writeJSON(w, apiErr.status, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// feverUser finds the user whose api_key, the MD5 of "name:token", was
+// sent. It returns sql.ErrNoRows for an unknown key.
+func (a *apiServer) feverUser(ctx context.Context, apiKey string) (database.User, error) {
+	apiKey = strings.ToLower(apiKey)
+	hash := hashAPIToken(apiKey)
+	user, err := a.s.db.GetUserByFeverKeyHash(ctx, hash)
+	if err == nil && !user.IsSystem {
+		a.touchToken(ctx, hash)
+		return user, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("couldn't get user: %w", err)
+	}
+
+	name := ""
+	for token, userName := range a.s.cfg.APITokens {
+		if subtle.ConstantTimeCompare([]byte(feverKey(userName, token)), []byte(apiKey)) == 1 {
+			name = userName
+		}
+	}
+	if name == "" {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	user, err = a.s.db.GetUserByName(ctx, name)
+	if err == nil && user.IsSystem {
+		return database.User{}, sql.ErrNoRows
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("couldn't get user: %w", err)
+	}
+	return user, err
+}
+
+// fever fills resp with what the request asked for. Marks come first, so
+// lists asked for in the same request already reflect them.
+func (a *apiServer) fever(r *http.Request, user database.User, resp map[string]any) error {
+	ctx := r.Context()
+	if err := numberNewSyncItems(ctx, a.s); err != nil {
+		return err
+	}
+	feeds, err := a.s.db.GetSyncFeedsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds: %w", err)
+	}
+
+	var refreshed time.Time
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(refreshed) {
+			refreshed = feed.LastFetchedAt.Time
+		}
+	}
+	resp["last_refreshed_on_time"] = unixOrZero(refreshed)
+
+	has := func(key string) bool {
+		_, ok := r.Form[key]
+		return ok
+	}
+
+	if has("mark") {
+		if a.s.readOnly {
+			return apiError{http.StatusForbidden, errReadOnly.Error()}
+		}
+		if err := a.feverMark(r, user, feeds); err != nil {
+			return err
+		}
+	}
+
+	if has("groups") || has("feeds") {
+		resp["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+	if has("groups") {
+		groups := []feverGroup{}
+		seen := map[int64]bool{}
+		for _, feed := range feeds {
+			if id := feverGroupID(feed.Category); id != 0 && !seen[id] {
+				seen[id] = true
+				groups = append(groups, feverGroup{ID: id, Title: feed.Category.String})
+			}
+		}
+		resp["groups"] = groups
+	}
+	if has("feeds") {
+		result := make([]feverFeed, len(feeds))
+		for i, feed := range feeds {
+			result[i] = feverFeed{
+				ID:                feed.Number,
+				Title:             feed.Name,
+				URL:               feed.Url,
+				LastUpdatedOnTime: unixOrZero(feed.LastFetchedAt.Time),
+			}
+		}
+		resp["feeds"] = result
+	}
+	if has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if has("links") {
+		resp["links"] = []any{}
+	}
+
+	if has("items") {
+		items, err := a.feverItems(r, user)
+		if err != nil {
+			return err
+		}
+		resp["items"] = items
+		total, err := a.s.db.CountSyncItems(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't count items: %w", err)
+		}
+		resp["total_items"] = total
+	}
+	if has("unread_item_ids") {
+		ids, err := a.s.db.GetUnreadSyncNumbers(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't get unread items: %w", err)
+		}
+		resp["unread_item_ids"] = joinNumbers(ids)
+	}
+	if has("saved_item_ids") {
+		ids, err := a.s.db.GetSavedSyncNumbers(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't get saved items: %w", err)
+		}
+		resp["saved_item_ids"] = joinNumbers(ids)
+	}
+	return nil
+}
+
+// numberNewSyncItems gives the posts and feeds stored since the last sync
+// request their sync numbers. A read-only server can't, and only serves
+// what earlier requests numbered.
+func numberNewSyncItems(ctx context.Context, s *state) error {
+	if s.readOnly {
+		return nil
+	}
+	if err := s.db.NumberNewFeeds(ctx); err != nil {
+		return fmt.Errorf("couldn't number feeds: %w", err)
+	}
+	if err := s.db.NumberNewPosts(ctx); err != nil {
+		return fmt.Errorf("couldn't number posts: %w", err)
+	}
+	return nil
+}
+
+// feverItems returns up to feverMaxItems items after since_id, before
+// max_id (newest first) or among the comma-separated with_ids
+func (a *apiServer) feverItems(r *http.Request, user database.User) ([]feverItem, error) {
+	params := database.GetSyncItemsParams{
+		UserID:   user.ID,
+		WithIds:  []int64{},
+		FeedIds:  []uuid.UUID{},
+		MaxItems: feverMaxItems,
+	}
+	var err error
+	if value := r.Form.Get("since_id"); value != "" {
+		if params.SinceID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, apiError{http.StatusBadRequest, "invalid since_id: " + value}
+		}
+	}
+	if value := r.Form.Get("max_id"); value != "" {
+		if params.MaxID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, apiError{http.StatusBadRequest, "invalid max_id: " + value}
+		}
+		params.NewestFirst = true
+	}
+	if value := r.Form.Get("with_ids"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				return nil, apiError{http.StatusBadRequest, "invalid with_ids: " + value}
+			}
+			params.WithIds = append(params.WithIds, id)
+		}
+	}
+
+	rows, err := a.s.db.GetSyncItems(r.Context(), params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get items: %w", err)
+	}
+	items := make([]feverItem, len(rows))
+	for i, row := range rows {
+		title, description, _ := previewed(a.s, row.ID, row.Title, row.Description)
+		created := row.CreatedAt
+		if row.PublishedAt.Valid {
+			created = row.PublishedAt.Time
+		}
+		items[i] = feverItem{
+			ID:            row.Number,
+			FeedID:        row.FeedNumber,
+			Title:         title,
+			HTML:          description.String,
+			URL:           row.Url,
+			IsSaved:       boolInt(row.IsSaved),
+			IsRead:        boolInt(row.IsRead),
+			CreatedOnTime: created.Unix(),
+		}
+	}
+	return items, nil
+}
+
+// feverMark applies mark=item|feed|group with as=read|unread|saved|unsaved
+func (a *apiServer) feverMark(r *http.Request, user database.User, feeds []database.GetSyncFeedsForUserRow) error {
+	ctx := r.Context()
+	mark, as := r.Form.Get("mark"), r.Form.Get("as")
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return apiError{http.StatusBadRequest, "invalid id: " + r.Form.Get("id")}
+	}
+
+	if mark == "item" {
+		postIDs, err := a.s.db.GetVisiblePostIDsForNumbers(ctx, database.GetVisiblePostIDsForNumbersParams{
+			Numbers: []int64{id},
+			UserID:  user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't look up item: %w", err)
+		}
+		if len(postIDs) == 0 {
+			return apiError{http.StatusNotFound, "item not found"}
+		}
+		switch as {
+		case "read", "unread":
+			return setRead(ctx, a.s, user, postIDs[0], as == "read")
+		case "saved", "unsaved":
+			return setBookmarked(ctx, a.s, user, postIDs[0], as == "saved")
+		default:
+			return apiError{http.StatusBadRequest, "invalid as: " + as}
+		}
+	}
+
+	if mark != "feed" && mark != "group" {
+		return apiError{http.StatusBadRequest, "invalid mark: " + mark}
+	}
+	if as != "read" {
+		return apiError{http.StatusBadRequest, "feeds and groups can only be marked read"}
+	}
+	// before is the client's last refresh, so items that arrived since then
+	// stay unread
+	before := time.Now().UTC()
+	if value := r.Form.Get("before"); value != "" {
+		ts, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return apiError{http.StatusBadRequest, "invalid before: " + value}
+		}
+		before = time.Unix(ts, 0).UTC()
+	}
+
+	// Group 0 stands for every feed
+	var feedIDs []uuid.UUID
+	for _, feed := range feeds {
+		if (mark == "feed" && feed.Number == id) ||
+			(mark == "group" && (id == 0 || feverGroupID(feed.Category) == id)) {
+			feedIDs = append(feedIDs, feed.ID)
+		}
+	}
+	if len(feedIDs) == 0 {
+		return nil
+	}
+
+	_, err = a.s.db.MarkFeedPostsReadBefore(ctx, database.MarkFeedPostsReadBeforeParams{
+		UserID:  user.ID,
+		ReadAt:  time.Now().UTC(),
+		Column3: feedIDs,
+		Column4: before,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark posts as read: %w", err)
+	}
+	return nil
+}
+
+// feverGroupID gives a feed category a stable group ID derived from its
+// name, since categories have no ID of their own. Feeds without one get 0,
+// which no category can.
+func feverGroupID(category sql.NullString) int64 {
+	name := strings.ToLower(strings.TrimSpace(category.String))
+	if name == "" {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int64(h.Sum32()&0x7fffffff) + 1
+}
+
+func feverFeedsGroups(feeds []database.GetSyncFeedsForUserRow) []feverFeedGroup {
+	var order []int64
+	members := map[int64][]int64{}
+	for _, feed := range feeds {
+		id := feverGroupID(feed.Category)
+		if id == 0 {
+			continue
+		}
+		if _, ok := members[id]; !ok {
+			order = append(order, id)
+		}
+		members[id] = append(members[id], feed.Number)
+	}
+
+	groups := make([]feverFeedGroup, len(order))
+	for i, id := range order {
+		groups[i] = feverFeedGroup{GroupID: id, FeedIDs: joinNumbers(members[id])}
+	}
+	return groups
+}
+
+func joinNumbers(numbers []int64) string {
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// headlinesOnly reports whether posts from feed are stored without their
+// description, to save space. The feed's own setting wins over the config.
+func headlinesOnly(cfg *config.Config, feed database.Feed) bool {
+	if feed.HeadlinesOnly.Valid {
+		return feed.HeadlinesOnly.Bool
+	}
+	return cfg.HeadlinesOnly
+}
+
+func handlerFeedSetHeadlinesOnly(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return errors.New("usage: gator feed set-headlines-only <url> on|off|auto")
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkCanManageFeed(s, feed, user); err != nil {
+		return err
+	}
+
+	setting := sql.NullBool{}
+	switch cmd.args[1] {
+	case "on":
+		setting = sql.NullBool{Bool: true, Valid: true}
+	case "off":
+		setting = sql.NullBool{Bool: false, Valid: true}
+	case "auto":
+	default:
+		return fmt.Errorf("invalid setting %q, use on, off or auto", cmd.args[1])
+	}
+
+	err = s.db.SetFeedHeadlinesOnly(context.Background(), database.SetFeedHeadlinesOnlyParams{
+		ID:            feed.ID,
+		HeadlinesOnly: setting,
+		UpdatedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set headlines-only mode: %w", err)
+	}
+
+	feed.HeadlinesOnly = setting
+	if headlinesOnly(s.cfg, feed) {
+		fmt.Printf("New posts from %s will be stored as headlines only\n", feed.Name)
+	} else {
+		fmt.Printf("New posts from %s will be stored with their description\n", feed.Name)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/opml"
+	"github.com/olereon/Gator/internal/webhook"
+)
+
+// handlerImport creates and follows every feed in an OPML file. Feeds that
+// already exist are followed rather than created again. New follows are
+// filed under the OPML folders the feeds were in.
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator import <file.opml>")
+	}
+
+	file, err := os.Open(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't open %s: %w", cmd.args[0], err)
+	}
+	defer file.Close()
+
+	feeds, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse OPML: %w", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("No feeds found in the OPML file.")
+		return nil
+	}
+
+	ctx := context.Background()
+	created, followed, failed := 0, 0, 0
+	for i, entry := range feeds {
+		prefix := fmt.Sprintf("[%d/%d] %s:", i+1, len(feeds), entry.Title)
+
+		if err := checkFeedPolicy(s.cfg, entry.XMLURL); err != nil {
+			fmt.Printf("%s skipped: %v\n", prefix, err)
+			failed++
+			continue
+		}
+
+		feed, err := s.db.GetFeedByURL(ctx, entry.XMLURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+				Name:      entry.Title,
+				Url:       entry.XMLURL,
+				UserID:    user.ID,
+			})
+			if err == nil {
+				created++
+				notify(s, webhook.EventFeedAdded, webhook.FeedData{
+					ID:      feed.ID.String(),
+					Name:    feed.Name,
+					URL:     feed.Url,
+					AddedBy: user.Name,
+				})
+			}
+		}
+		if err != nil {
+			fmt.Printf("%s couldn't add feed: %v\n", prefix, err)
+			failed++
+			continue
+		}
+
+		_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			if err.Error() == `pq: duplicate key value violates unique constraint "feed_follows_user_id_feed_id_key"` {
+				fmt.Printf("%s already following\n", prefix)
+				continue
+			}
+			fmt.Printf("%s couldn't follow feed: %v\n", prefix, err)
+			failed++
+			continue
+		}
+		followed++
+		if entry.Category != "" {
+			// The folders of the other reader become the follow's category
+			_, err = s.db.SetFollowCategory(ctx, database.SetFollowCategoryParams{
+				Category:  sql.NullString{String: entry.Category, Valid: true},
+				UpdatedAt: time.Now().UTC(),
+				UserID:    user.ID,
+				FeedID:    feed.ID,
+			})
+			if err != nil {
+				fmt.Printf("%s following, couldn't set category %s: %v\n", prefix, entry.Category, err)
+				continue
+			}
+			fmt.Printf("%s following, in %s\n", prefix, entry.Category)
+			continue
+		}
+		fmt.Printf("%s following\n", prefix)
+	}
+
+	fmt.Printf("Imported %d feeds: %d created, %d newly followed, %d failed\n", len(feeds), created, followed, failed)
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/readstate"
+)
+
+const (
+	// importedStateBatch is how many states are saved per statement
+	importedStateBatch = 1000
+	// importedStateRetention is how long an imported state waits for its
+	// post to be fetched. Feeds only carry recent items, so older articles
+	// never show up.
+	importedStateRetention = 30 * 24 * time.Hour
+)
+
+// handlerImportState brings over which articles were read or starred in
+// another reader. Posts gator already has are updated now, the rest as agg
+// fetches them.
+func handlerImportState(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("usage: gator import-state <export.json|export.zip>...")
+	}
+
+	var items []readstate.Item
+	for _, name := range cmd.args {
+		fileItems, err := readStateFile(name)
+		if err != nil {
+			return err
+		}
+		items = append(items, fileItems...)
+	}
+
+	// Unread articles are unread in gator too, only the rest need keeping
+	var urls []string
+	var read, starred []bool
+	for _, item := range readstate.Merge(items) {
+		if item.Read || item.Starred {
+			urls = append(urls, item.URL)
+			read = append(read, item.Read)
+			starred = append(starred, item.Starred)
+		}
+	}
+	if len(urls) == 0 {
+		fmt.Println("No read or starred articles found.")
+		return nil
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for start := 0; start < len(urls); start += importedStateBatch {
+		end := min(start+importedStateBatch, len(urls))
+		err := s.db.SaveImportedStates(ctx, database.SaveImportedStatesParams{
+			UserID:     user.ID,
+			Column2:    urls[start:end],
+			Column3:    read[start:end],
+			Column4:    starred[start:end],
+			ImportedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't save imported states: %w", err)
+		}
+	}
+
+	marked, bookmarked, err := applyImportedStates(s, uuid.NullUUID{UUID: user.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	waiting, err := s.db.CountImportedStates(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't count imported states: %w", err)
+	}
+
+	fmt.Printf("Imported %d read or starred article(s): marked %d post(s) read and bookmarked %d.\n", len(urls), marked, bookmarked)
+	if waiting > 0 {
+		fmt.Printf("%d article(s) aren't in gator yet and will be updated as agg fetches them, for up to %d days.\n",
+			waiting, int(importedStateRetention.Hours()/24))
+	}
+	return nil
+}
+
+// readStateFile parses one export, or every JSON file in a zip such as the
+// one FreshRSS exports
+func readStateFile(name string) ([]readstate.Item, error) {
+	if strings.EqualFold(path.Ext(name), ".zip") {
+		archive, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't open %s: %w", name, err)
+		}
+		defer archive.Close()
+
+		var items []readstate.Item
+		for _, f := range archive.File {
+			if !strings.EqualFold(path.Ext(f.Name), ".json") {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("couldn't open %s in %s: %w", f.Name, name, err)
+			}
+			fileItems, err := readstate.Parse(r)
+			r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("couldn't parse %s in %s: %w", f.Name, name, err)
+			}
+			items = append(items, fileItems...)
+		}
+		return items, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open %s: %w", name, err)
+	}
+	defer file.Close()
+	items, err := readstate.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %w", name, err)
+	}
+	return items, nil
+}
+
+// applyImportedStates marks read and bookmarks the posts that imported
+// states are waiting for, for one user or everyone, then forgets the
+// states that are done with or too old
+func applyImportedStates(s *state, userID uuid.NullUUID) (marked, bookmarked int64, err error) {
+	ctx := context.Background()
+	marked, err = s.db.ApplyImportedReads(ctx, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't apply imported read states: %w", err)
+	}
+	bookmarked, err = s.db.ApplyImportedStars(ctx, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't apply imported starred states: %w", err)
+	}
+	err = s.db.DeleteAppliedImportedStates(ctx, time.Now().UTC().Add(-importedStateRetention))
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't delete applied imported states: %w", err)
+	}
+	return marked, bookmarked, nil
+}
+
+// backfillImportedStates is agg's pass over imported states, for the posts
+// fetched since the last one
+func backfillImportedStates(s *state) {
+	marked, bookmarked, err := applyImportedStates(s, uuid.NullUUID{})
+	if err != nil {
+		slog.Error("couldn't backfill imported states", "err", err)
+		return
+	}
+	if marked > 0 || bookmarked > 0 {
+		slog.Info("applied imported states", "marked_read", marked, "bookmarked", bookmarked)
+	}
+}
@@ -0,0 +1,182 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxPageSize caps how much of an article page is read
+const maxPageSize = 5 << 20
+
+// Article is the readable part of a web page
+type Article struct {
+	URL   string
+	Title string
+	// Canonical is the URL the page names as its own with rel=canonical or
+	// og:url, such as the regular page of an AMP or mirrored copy. It is
+	// empty if the page names none.
+	Canonical string
+	// Preview is what the page offers link previews to show
+	Preview Preview
+	// Content is a sanitized HTML fragment that is also well-formed XHTML
+	Content string
+}
+
+// Fetch downloads the page at pageURL and extracts its article
+func Fetch(ctx context.Context, client *http.Client, pageURL string) (*Article, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return Extract(io.LimitReader(resp.Body, maxPageSize), resp.Request.URL.String())
+}
+
+// Extract picks the main content out of an HTML page. Links and images are
+// made absolute against pageURL.
+func Extract(r io.Reader, pageURL string) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Article{URL: pageURL}
+	if title := findFirst(doc, atom.Title); title != nil && title.FirstChild != nil {
+		a.Title = strings.TrimSpace(title.FirstChild.Data)
+	}
+	base, _ := url.Parse(pageURL)
+	a.Canonical = canonicalURL(doc, base)
+	a.Preview = preview(doc, base, a.Title)
+
+	root := mainContent(doc)
+	if root == nil {
+		return a, nil
+	}
+
+	a.Content = renderChildren(root, base)
+	return a, nil
+}
+
+// canonicalURL returns the page's <link rel="canonical">, or failing that
+// its og:url, as an absolute web URL
+func canonicalURL(doc *html.Node, base *url.URL) string {
+	var canonical, ogURL string
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch {
+		case n.DataAtom == atom.Link && canonical == "":
+			for _, rel := range strings.Fields(strings.ToLower(attr(n, "rel"))) {
+				if rel == "canonical" {
+					canonical = attr(n, "href")
+				}
+			}
+		case n.DataAtom == atom.Meta && ogURL == "" && attr(n, "property") == "og:url":
+			ogURL = attr(n, "content")
+		}
+	})
+
+	for _, href := range []string{canonical, ogURL} {
+		if link := footnoteLink(href, base); strings.HasPrefix(link, "http") {
+			return link
+		}
+	}
+	return ""
+}
+
+// Sanitize cleans an HTML fragment such as a feed item description the same
+// way page content is cleaned
+func Sanitize(fragment, baseURL string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+
+	base, _ := url.Parse(baseURL)
+	return renderChildren(context, base)
+}
+
+// mainContent prefers the <article> with the most text, then <main>, then
+// the whole <body>
+func mainContent(doc *html.Node) *html.Node {
+	var best *html.Node
+	bestLen := 0
+	walk(doc, func(n *html.Node) {
+		if n.DataAtom != atom.Article {
+			return
+		}
+		if l := len(strings.TrimSpace(textOf(n))); l > bestLen {
+			best, bestLen = n, l
+		}
+	})
+	if best != nil {
+		return best
+	}
+	if n := findFirst(doc, atom.Main); n != nil {
+		return n
+	}
+	return findFirst(doc, atom.Body)
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(n *html.Node) {
+		if n.Type == html.TextNode && !dropped[n.Parent.DataAtom] {
+			b.WriteString(n.Data)
+		}
+	})
+	return b.String()
+}
+
+func renderChildren(n *html.Node, base *url.URL) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if clean := sanitize(c, base); clean != nil {
+			html.Render(&buf, clean)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
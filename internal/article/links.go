@@ -0,0 +1,44 @@
+package article
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Link is an outbound link of an HTML fragment with its anchor text
+type Link struct {
+	URL  string
+	Text string
+}
+
+// Links returns the web links of an HTML fragment in the order they
+// appear, resolved against baseURL, each URL once. Links back to baseURL
+// itself and in-page anchors are left out.
+func Links(content, baseURL string) []Link {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), context)
+	if err != nil {
+		return nil
+	}
+	base, _ := url.Parse(baseURL)
+
+	var links []Link
+	seen := map[string]bool{baseURL: true}
+	for _, n := range nodes {
+		walk(n, func(n *html.Node) {
+			if n.Type != html.ElementNode || n.DataAtom != atom.A {
+				return
+			}
+			link := footnoteLink(attr(n, "href"), base)
+			if link == "" || strings.HasPrefix(link, "mailto:") || seen[link] {
+				return
+			}
+			seen[link] = true
+			links = append(links, Link{URL: link, Text: strings.Join(strings.Fields(textOf(n)), " ")})
+		})
+	}
+	return links
+}
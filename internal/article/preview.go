@@ -0,0 +1,54 @@
+package article
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Preview is a page's Open Graph title, description and image, falling
+// back to its <title> and meta description
+type Preview struct {
+	Title       string
+	Description string
+	// Image is an absolute web URL, or empty
+	Image string
+}
+
+// IsZero reports whether the page offered nothing to preview it with
+func (p Preview) IsZero() bool {
+	return p.Title == "" && p.Description == "" && p.Image == ""
+}
+
+func preview(doc *html.Node, base *url.URL, pageTitle string) Preview {
+	var p Preview
+	var metaDescription string
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Meta {
+			return
+		}
+		content := strings.TrimSpace(attr(n, "content"))
+		switch {
+		case attr(n, "property") == "og:title" && p.Title == "":
+			p.Title = content
+		case attr(n, "property") == "og:description" && p.Description == "":
+			p.Description = content
+		case attr(n, "property") == "og:image" && p.Image == "":
+			if link := footnoteLink(content, base); strings.HasPrefix(link, "http") {
+				p.Image = link
+			}
+		case strings.EqualFold(attr(n, "name"), "description") && metaDescription == "":
+			metaDescription = content
+		}
+	})
+
+	if p.Title == "" {
+		p.Title = pageTitle
+	}
+	if p.Description == "" {
+		p.Description = metaDescription
+	}
+	return p
+}
@@ -0,0 +1,136 @@
+package article
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Rendered is an HTML fragment turned into terminal text. Links are marked
+// with [n] in Text and listed in Links, Links[0] being [1].
+type Rendered struct {
+	Text  string
+	Links []string
+}
+
+// Footnotes returns the links as "[n] url" lines, only those whose marker
+// appears in text, which may be a shortened Text
+func (r Rendered) Footnotes(text string) []string {
+	var notes []string
+	for i, link := range r.Links {
+		marker := fmt.Sprintf("[%d]", i+1)
+		if strings.Contains(text, marker) {
+			notes = append(notes, marker+" "+link)
+		}
+	}
+	return notes
+}
+
+// String returns the text followed by all of its footnotes
+func (r Rendered) String() string {
+	notes := r.Footnotes(r.Text)
+	if len(notes) == 0 {
+		return r.Text
+	}
+	return r.Text + "\n\n" + strings.Join(notes, "\n")
+}
+
+// Render turns an HTML fragment such as a feed description into plain text
+// for the terminal. Entities are decoded, list items get a bullet, bold and
+// italic text is marked with * and _, and links become numbered footnotes
+// resolved against baseURL. Text that isn't HTML comes back unchanged apart
+// from whitespace.
+func Render(content, baseURL string) Rendered {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), context)
+	if err != nil {
+		return Rendered{Text: content}
+	}
+	base, _ := url.Parse(baseURL)
+
+	var r Rendered
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case n.Type != html.ElementNode:
+		case dropped[n.DataAtom]:
+			return
+		case n.DataAtom == atom.Li:
+			b.WriteString("\n- ")
+			defer b.WriteString("\n")
+		case n.DataAtom == atom.Img:
+			if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+				fmt.Fprintf(&b, "[image: %s]", alt)
+			}
+			return
+		case n.DataAtom == atom.Strong || n.DataAtom == atom.B:
+			b.WriteString("*")
+			defer b.WriteString("*")
+		case n.DataAtom == atom.Em || n.DataAtom == atom.I:
+			b.WriteString("_")
+			defer b.WriteString("_")
+		case n.DataAtom == atom.A:
+			if link := footnoteLink(attr(n, "href"), base); link != "" && strings.TrimSpace(textOf(n)) != link {
+				r.Links = append(r.Links, link)
+				defer fmt.Fprintf(&b, "[%d]", len(r.Links))
+			}
+		case blocks[n.DataAtom]:
+			b.WriteString("\n")
+			defer b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	for _, n := range nodes {
+		visit(n)
+	}
+
+	// Collapse runs of whitespace within lines and drop empty lines
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" && line != "-" {
+			lines = append(lines, line)
+		}
+	}
+	r.Text = strings.Join(lines, "\n")
+	return r
+}
+
+// footnoteLink resolves href for a footnote, leaving out in-page anchors
+// and anything that isn't a web or mail link
+func footnoteLink(href string, base *url.URL) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return u.String()
+	default:
+		return ""
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package article
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// dropped elements are removed together with everything inside them
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Svg: true, atom.Math: true,
+	atom.Head: true, atom.Title: true, atom.Link: true, atom.Meta: true,
+}
+
+// allowed elements are kept along with the listed attributes. Anything else
+// is replaced by its children.
+var allowed = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Small: nil, atom.Mark: nil,
+	atom.Code: nil, atom.Pre: nil, atom.Kbd: nil, atom.Blockquote: nil, atom.Q: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil,
+	atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"}, atom.Caption: nil,
+	atom.Figure: nil, atom.Figcaption: nil,
+	atom.A:   {"href", "title"},
+	atom.Img: {"src", "alt", "title", "width", "height"},
+}
+
+// urlAttrs must hold http(s) or mailto URLs
+var urlAttrs = map[string]bool{"href": true, "src": true}
+
+// sanitize returns a cleaned copy of n, or nil if nothing of it is kept.
+// Unwrapped elements come back as a detached container whose children are
+// rendered in its place.
+func sanitize(n *html.Node, base *url.URL) *html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return &html.Node{Type: html.TextNode, Data: n.Data}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if dropped[n.DataAtom] {
+		return nil
+	}
+
+	attrs, ok := allowed[n.DataAtom]
+	var out *html.Node
+	if ok {
+		out = &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+		for _, attr := range n.Attr {
+			if attr.Namespace != "" || !contains(attrs, attr.Key) {
+				continue
+			}
+			if urlAttrs[attr.Key] {
+				resolved, ok := safeURL(attr.Val, base)
+				if !ok {
+					continue
+				}
+				attr.Val = resolved
+			}
+			out.Attr = append(out.Attr, html.Attribute{Key: attr.Key, Val: attr.Val})
+		}
+		// An image without a usable source is of no use
+		if n.DataAtom == atom.Img && !hasAttr(out, "src") {
+			return nil
+		}
+	} else {
+		out = &html.Node{Type: html.DocumentNode}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		clean := sanitize(c, base)
+		if clean == nil {
+			continue
+		}
+		if clean.Type == html.DocumentNode {
+			// Splice in the children of unwrapped elements
+			for gc := clean.FirstChild; gc != nil; {
+				next := gc.NextSibling
+				clean.RemoveChild(gc)
+				out.AppendChild(gc)
+				gc = next
+			}
+			continue
+		}
+		out.AppendChild(clean)
+	}
+
+	if out.Type == html.DocumentNode && out.FirstChild == nil {
+		return nil
+	}
+	return out
+}
+
+func safeURL(raw string, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return u.String(), true
+	}
+	return "", false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// StripImages removes images from sanitized content, for formats that can't
+// load remote resources
+func StripImages(content string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), context)
+	if err != nil {
+		return content
+	}
+
+	var buf strings.Builder
+	for _, n := range nodes {
+		removeImages(n)
+		html.Render(&buf, n)
+	}
+	return buf.String()
+}
+
+func removeImages(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && c.DataAtom == atom.Img {
+			n.RemoveChild(c)
+		} else {
+			removeImages(c)
+		}
+		c = next
+	}
+}
@@ -0,0 +1,85 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type hostState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// Breaker tracks consecutive failures per host and stops requests to a host
+// for a cool-down period once it has failed too many times in a row
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	hosts     map[string]*hostState
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*hostState),
+	}
+}
+
+// Allow reports whether a request to host may be attempted right now
+func (b *Breaker) Allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h, ok := b.hosts[host]
+	if !ok {
+		return true
+	}
+	return !time.Now().Before(h.openUntil)
+}
+
+// OpenUntil returns the time until which requests to host are blocked
+func (b *Breaker) OpenUntil(host string) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if h, ok := b.hosts[host]; ok {
+		return h.openUntil
+	}
+	return time.Time{}
+}
+
+func (b *Breaker) RecordSuccess(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.hosts, host)
+}
+
+// RecordFailure counts a failure for host and returns true if this failure
+// opened the circuit. Once the cool-down has elapsed a single further failure
+// opens it again.
+func (b *Breaker) RecordFailure(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostState{}
+		b.hosts[host] = h
+	}
+
+	// Another worker already tripped the circuit for this host
+	if time.Now().Before(h.openUntil) {
+		return false
+	}
+
+	h.failures++
+	if h.failures < b.threshold {
+		return false
+	}
+
+	h.openUntil = time.Now().Add(b.cooldown)
+	return true
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores blobs by the SHA-256 of their content, so the same bytes are
+// kept once however many keys point at them. The least recently used blobs
+// are evicted once the cache grows past its size limit.
+type Cache struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+}
+
+type Stats struct {
+	Blobs   int
+	Keys    int
+	Size    int64
+	MaxSize int64
+}
+
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) blobPath(sum string) string {
+	return filepath.Join(c.dir, "blobs", sum[:2], sum)
+}
+
+func (c *Cache) keyPath(key string) string {
+	return filepath.Join(c.dir, "keys", hash([]byte(key)))
+}
+
+// Get returns the blob stored under key
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sum, err := os.ReadFile(c.keyPath(key))
+	if err != nil || len(sum) != sha256.Size*2 {
+		return nil, false
+	}
+	path := c.blobPath(string(sum))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// The blob was evicted, forget the key as well
+		os.Remove(c.keyPath(key))
+		return nil, false
+	}
+
+	// Eviction goes by modification time, so reading counts as using it
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put stores data under key, evicting old blobs if the cache is full
+func (c *Cache) Put(key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sum := hash(data)
+	path := c.blobPath(sum)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := writeFile(path, data); err != nil {
+			return err
+		}
+	} else {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	}
+
+	if err := writeFile(c.keyPath(key), []byte(sum)); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// writeFile writes data through a rename so readers never see a partial blob
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type blob struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) blobs() ([]blob, error) {
+	var blobs []blob
+	err := filepath.WalkDir(filepath.Join(c.dir, "blobs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blob{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return blobs, err
+}
+
+// evict removes the least recently used blobs until the cache fits its
+// size limit. Keys pointing at them are dropped on their next Get.
+func (c *Cache) evict() error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	blobs, err := c.blobs()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, b := range blobs {
+		total += b.size
+	}
+	if total <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].modTime.Before(blobs[j].modTime)
+	})
+	for _, b := range blobs {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= b.size
+	}
+	return nil
+}
+
+func (c *Cache) Stats() (Stats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{MaxSize: c.maxSize}
+	blobs, err := c.blobs()
+	if err != nil {
+		return Stats{}, err
+	}
+	for _, b := range blobs {
+		stats.Blobs++
+		stats.Size += b.size
+	}
+
+	keys, err := os.ReadDir(filepath.Join(c.dir, "keys"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Stats{}, err
+	}
+	stats.Keys = len(keys)
+	return stats, nil
+}
+
+// Clear removes everything in the cache
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(filepath.Join(c.dir, "blobs")); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(c.dir, "keys"))
+}
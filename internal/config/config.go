@@ -2,66 +2,378 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
 const configFileName = ".gatorconfig.json"
 
+// xdgConfigName is where the config lives under XDG_CONFIG_HOME
+const xdgConfigName = "gator/config.json"
+
+// SchemaVersion is the config layout this build understands. Bump it when a
+// setting is renamed or changes meaning.
+const SchemaVersion = 1
+
 type Config struct {
-	DBUrl           string `json:"db_url"`
+	// ConfigVersion is the SchemaVersion of the gator that last wrote the
+	// file, 0 for files from before versioning
+	ConfigVersion int `json:"config_version,omitempty"`
+
+	// Profile is the profile the settings were read from, empty for the
+	// top level of the file. Logins are saved to it.
+	Profile string `json:"-"`
+
+	DBUrl string `json:"db_url"`
+	// DBMaxOpenConns and DBMaxIdleConns size the connection pool, 0 keeps
+	// the defaults: unlimited and 2
+	DBMaxOpenConns    int    `json:"db_max_open_conns,omitempty"`
+	DBMaxIdleConns    int    `json:"db_max_idle_conns,omitempty"`
+	DBConnMaxLifetime string `json:"db_conn_max_lifetime,omitempty"`
+
 	CurrentUserName string `json:"current_user_name"`
+	// SessionToken proves the current user logged in with their password,
+	// for users who have one
+	SessionToken string `json:"session_token,omitempty"`
+	CABundle     string `json:"ca_bundle,omitempty"`
+	PreferIPv4   bool   `json:"prefer_ipv4,omitempty"`
+	DNSResolver  string `json:"dns_resolver,omitempty"`
+
+	// ReadOnly refuses every command that would change the database, like
+	// passing --read-only
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	HostOverrides map[string]string `json:"host_overrides,omitempty"`
+
+	// BlockedDomains can't be added as feeds; when AllowedDomains is set
+	// nothing else can. Subdomains are included.
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+
+	// Admins are the users who decide feed requests and add system feeds
+	Admins []string `json:"admins,omitempty"`
+
+	// BlockPrivateNetworks keeps feed fetches away from internal services,
+	// AllowedNetworks lists CIDR ranges that are reachable anyway
+	BlockPrivateNetworks bool     `json:"block_private_networks,omitempty"`
+	AllowedNetworks      []string `json:"allowed_networks,omitempty"`
+
+	Webhooks            []Webhook `json:"webhooks,omitempty"`
+	FeedBrokenThreshold int       `json:"feed_broken_threshold,omitempty"`
+	MinFetchInterval    string    `json:"min_fetch_interval,omitempty"`
+	MaxFetchInterval    string    `json:"max_fetch_interval,omitempty"`
+	FetchTimeout        string    `json:"fetch_timeout,omitempty"`
+	FetchRetries        *int      `json:"fetch_retries,omitempty"`
+	MaxFeedSize         string    `json:"max_feed_size,omitempty"`
+	HeadlinesOnly       bool      `json:"headlines_only,omitempty"`
+	FetchLogRetention   string    `json:"fetch_log_retention,omitempty"`
+	HostRequestInterval string    `json:"host_request_interval,omitempty"`
+	CacheDir            string    `json:"cache_dir,omitempty"`
+	CacheMaxSize        string    `json:"cache_max_size,omitempty"`
+
+	// TTSCommand is run once per post to speak it, with {in} replaced by a
+	// text file and {out} by the audio file to write
+	TTSCommand []string `json:"tts_command,omitempty"`
+	TTSFormat  string   `json:"tts_format,omitempty"`
+
+	// Sinks receive every post agg ingests, as JSONL
+	Sinks []Sink `json:"sinks,omitempty"`
+
+	// SearchBackend is an optional external index used by gator search
+	SearchBackend *SearchBackend `json:"search_backend,omitempty"`
+
+	// SQLTraceFile is where --sql-trace logs statements, rotated once it
+	// reaches SQLTraceMaxSize
+	SQLTraceFile    string `json:"sql_trace_file,omitempty"`
+	SQLTraceMaxSize string `json:"sql_trace_max_size,omitempty"`
+
+	// PublicURL is where others reach gator serve, for share links
+	PublicURL string `json:"public_url,omitempty"`
+
+	// APITokens maps each bearer token gator serve accepts to the name of
+	// the user it acts as
+	APITokens map[string]string `json:"api_tokens,omitempty"`
+}
+
+// SearchBackend points at a Meilisearch or Elasticsearch server
+type SearchBackend struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Index  string `json:"index,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// Sink is either a JSONL file ("file", with Path) or a program that gets
+// each batch of posts on its standard input ("command", with Command)
+type Sink struct {
+	Type    string   `json:"type"`
+	Path    string   `json:"path,omitempty"`
+	Command []string `json:"command,omitempty"`
+}
+
+type Webhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
 }
 
-func Read() (Config, error) {
-	fullPath, err := getConfigFilePath()
+// Read loads the config file. With a profile, the settings under
+// "profiles" of that name override the ones at the top level, so a profile
+// only needs what differs, usually db_url and current_user_name.
+// DATABASE_URL overrides db_url, and with it set the file may be missing.
+func Read(profile string) (Config, error) {
+	fullPath, err := Path()
 	if err != nil {
 		return Config{}, err
 	}
+	envDBUrl := os.Getenv("DATABASE_URL")
 
-	file, err := os.Open(fullPath)
+	data, err := os.ReadFile(fullPath)
+	if errors.Is(err, os.ErrNotExist) && envDBUrl != "" {
+		data, err = []byte("{}"), nil
+	}
 	if err != nil {
 		return Config{}, err
 	}
-	defer file.Close()
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Config{}, err
+	}
+	if profile != "" {
+		var profiles map[string]map[string]json.RawMessage
+		if raw["profiles"] != nil {
+			if err := json.Unmarshal(raw["profiles"], &profiles); err != nil {
+				return Config{}, fmt.Errorf("couldn't parse profiles: %w", err)
+			}
+		}
+		settings, ok := profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("no profile named %q in %s", profile, fullPath)
+		}
+		for key, value := range settings {
+			raw[key] = value
+		}
+		data, err = json.Marshal(raw)
+		if err != nil {
+			return Config{}, err
+		}
+	}
 
 	var cfg Config
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&cfg)
-	if err != nil {
+	if err := json.Unmarshal(data, &cfg); err != nil {
 		return Config{}, err
 	}
+	cfg.Profile = profile
+	if envDBUrl != "" {
+		cfg.DBUrl = envDBUrl
+	}
 
 	return cfg, nil
 }
 
 func (cfg *Config) SetUser(userName string) error {
+	return cfg.SetLogin(userName, "")
+}
+
+// SetLogin switches to userName with the token of a login session, or
+// none for users without a password
+func (cfg *Config) SetLogin(userName, sessionToken string) error {
 	cfg.CurrentUserName = userName
-	return write(*cfg)
+	cfg.SessionToken = sessionToken
+	err := cfg.update(func(raw map[string]json.RawMessage) error {
+		value, err := json.Marshal(userName)
+		if err != nil {
+			return err
+		}
+		raw["current_user_name"] = value
+		if sessionToken == "" {
+			delete(raw, "session_token")
+			return nil
+		}
+		value, err = json.Marshal(sessionToken)
+		if err != nil {
+			return err
+		}
+		raw["session_token"] = value
+		return nil
+	})
+	return err
 }
 
-func getConfigFilePath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+// Path returns where the config file is: GATOR_CONFIG if set, otherwise
+// ~/.gatorconfig.json if it exists, otherwise gator/config.json in
+// XDG_CONFIG_HOME (~/.config by default). New files go to
+// ~/.gatorconfig.json unless XDG_CONFIG_HOME is set or there is no home
+// directory.
+func Path() (string, error) {
+	if path := os.Getenv("GATOR_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	home, homeErr := os.UserHomeDir()
+	legacy := ""
+	if homeErr == nil {
+		legacy = filepath.Join(home, configFileName)
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+
+	xdgHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgHome == "" {
+		if homeErr != nil {
+			return "", homeErr
+		}
+		xdg := filepath.Join(home, ".config", xdgConfigName)
+		if _, err := os.Stat(xdg); err == nil {
+			return xdg, nil
+		}
+		return legacy, nil
+	}
+	return filepath.Join(xdgHome, xdgConfigName), nil
+}
+
+// update applies change to the settings of the config's profile, or to the
+// top level without one
+func (cfg *Config) update(change func(raw map[string]json.RawMessage) error) error {
+	if cfg.Profile == "" {
+		return update(change)
 	}
+	return update(func(raw map[string]json.RawMessage) error {
+		profiles := map[string]map[string]json.RawMessage{}
+		if raw["profiles"] != nil {
+			if err := json.Unmarshal(raw["profiles"], &profiles); err != nil {
+				return fmt.Errorf("couldn't parse profiles: %w", err)
+			}
+		}
+		settings := profiles[cfg.Profile]
+		if settings == nil {
+			settings = map[string]json.RawMessage{}
+		}
+		if err := change(settings); err != nil {
+			return err
+		}
+		profiles[cfg.Profile] = settings
 
-	return filepath.Join(home, configFileName), nil
+		value, err := json.Marshal(profiles)
+		if err != nil {
+			return err
+		}
+		raw["profiles"] = value
+		return nil
+	})
 }
 
-func write(cfg Config) error {
-	fullPath, err := getConfigFilePath()
+// update applies change to the config file as it is on disk, so settings
+// written by another gator process in the meantime, or keys this version
+// doesn't know (from newer gator versions or other tools), are kept. The
+// file is locked for the whole read-modify-write and replaced atomically.
+func update(change func(raw map[string]json.RawMessage) error) error {
+	fullPath, err := Path()
 	if err != nil {
 		return err
 	}
+	// An XDG config directory may not exist yet
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0700); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(fullPath + ".lock")
+	if err != nil {
+		return fmt.Errorf("couldn't lock config: %w", err)
+	}
+	defer unlock()
+
+	raw := map[string]json.RawMessage{}
+	data, err := os.ReadFile(fullPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("couldn't parse config: %w", err)
+		}
+	}
+
+	if err := change(raw); err != nil {
+		return err
+	}
 
-	file, err := os.Create(fullPath)
+	// Never downgrade the version of a file written by a newer gator, its
+	// settings are kept as they are
+	var version int
+	json.Unmarshal(raw["config_version"], &version)
+	if version < SchemaVersion {
+		raw["config_version"] = json.RawMessage(fmt.Sprint(SchemaVersion))
+	}
+
+	out, err := json.MarshalIndent(raw, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	return writeAtomic(fullPath, append(out, '\n'), hasSession(raw))
+}
+
+// hasSession reports whether the config holds a login session, at the top
+// level or in a profile
+func hasSession(raw map[string]json.RawMessage) bool {
+	if raw["session_token"] != nil {
+		return true
+	}
+	var profiles map[string]map[string]json.RawMessage
+	json.Unmarshal(raw["profiles"], &profiles)
+	for _, settings := range profiles {
+		if settings["session_token"] != nil {
+			return true
+		}
+	}
+	return false
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(cfg)
-}
\ No newline at end of file
+// writeAtomic replaces the file at path with data through a rename, so
+// readers never see a partly written file. A private file is readable only
+// by its owner from the moment it appears, so other users on the machine
+// can't borrow a login session.
+func writeAtomic(path string, data []byte, private bool) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if private {
+		mode &^= 0077
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Make the rename itself survive a crash. Not every platform can sync
+	// a directory, the file is in place either way.
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
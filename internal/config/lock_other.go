@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// lockFile is a no-op where flock isn't available. Writes are still atomic,
+// but concurrent updates can overwrite each other.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
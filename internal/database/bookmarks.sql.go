@@ -11,12 +11,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createBookmark = `-- name: CreateBookmark :one
 INSERT INTO bookmarks (id, created_at, updated_at, user_id, post_id)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, user_id, post_id
+RETURNING id, created_at, updated_at, user_id, post_id, deleted_at
 `
 
 type CreateBookmarkParams struct {
@@ -42,55 +43,217 @@ func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams)
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.PostID,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteBookmark = `-- name: DeleteBookmark :exec
-DELETE FROM bookmarks
-WHERE user_id = $1 AND post_id = $2
+UPDATE bookmarks
+SET deleted_at = $3
+WHERE user_id = $1 AND post_id = $2 AND deleted_at IS NULL
 `
 
 type DeleteBookmarkParams struct {
-	UserID uuid.UUID
-	PostID uuid.UUID
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	DeletedAt sql.NullTime
 }
 
 func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) error {
-	_, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.PostID)
+	_, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.PostID, arg.DeletedAt)
+	return err
+}
+
+const deleteBookmarkPositions = `-- name: DeleteBookmarkPositions :exec
+DELETE FROM bookmark_positions WHERE user_id = $1 AND tag = $2
+`
+
+type DeleteBookmarkPositionsParams struct {
+	UserID uuid.UUID
+	Tag    string
+}
+
+func (q *Queries) DeleteBookmarkPositions(ctx context.Context, arg DeleteBookmarkPositionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBookmarkPositions, arg.UserID, arg.Tag)
 	return err
 }
 
+const getAllBookmarksForUser = `-- name: GetAllBookmarksForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name, feeds.url AS feed_url, bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+INNER JOIN posts ON bookmarks.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE bookmarks.user_id = $1 AND bookmarks.deleted_at IS NULL
+ORDER BY bookmarks.created_at ASC
+`
+
+type GetAllBookmarksForUserRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+	FeedUrl         string
+	BookmarkedAt    time.Time
+}
+
+func (q *Queries) GetAllBookmarksForUser(ctx context.Context, userID uuid.UUID) ([]GetAllBookmarksForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllBookmarksForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllBookmarksForUserRow
+	for rows.Next() {
+		var i GetAllBookmarksForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.BookmarkedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkPositionsForUser = `-- name: GetBookmarkPositionsForUser :many
+SELECT bookmark_positions.tag, posts.url, bookmark_positions.position
+FROM bookmark_positions
+INNER JOIN posts ON posts.id = bookmark_positions.post_id
+INNER JOIN bookmarks ON bookmarks.post_id = bookmark_positions.post_id
+  AND bookmarks.user_id = bookmark_positions.user_id
+  AND bookmarks.deleted_at IS NULL
+WHERE bookmark_positions.user_id = $1
+ORDER BY bookmark_positions.tag, bookmark_positions.position
+`
+
+type GetBookmarkPositionsForUserRow struct {
+	Tag      string
+	Url      string
+	Position int32
+}
+
+// Positions of the user's current bookmarks, per tag
+func (q *Queries) GetBookmarkPositionsForUser(ctx context.Context, userID uuid.UUID) ([]GetBookmarkPositionsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkPositionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookmarkPositionsForUserRow
+	for rows.Next() {
+		var i GetBookmarkPositionsForUserRow
+		if err := rows.Scan(&i.Tag, &i.Url, &i.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBookmarksForUser = `-- name: GetBookmarksForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name, bookmarks.created_at AS bookmarked_at
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name, bookmarks.created_at AS bookmarked_at
 FROM bookmarks
 INNER JOIN posts ON bookmarks.post_id = posts.id
 INNER JOIN feeds ON posts.feed_id = feeds.id
-WHERE bookmarks.user_id = $1
-ORDER BY bookmarks.created_at DESC
+LEFT JOIN bookmark_positions ON bookmark_positions.user_id = bookmarks.user_id
+  AND bookmark_positions.post_id = bookmarks.post_id AND bookmark_positions.tag = $3::TEXT
+WHERE bookmarks.user_id = $1 AND bookmarks.deleted_at IS NULL AND feeds.deleted_at IS NULL
+AND ($3::TEXT = '' OR EXISTS (
+  SELECT 1 FROM post_tags WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id AND post_tags.tag = $3
+))
+ORDER BY
+  CASE WHEN $4::TEXT = 'manual' THEN bookmark_positions.position END ASC NULLS LAST,
+  CASE WHEN $4::TEXT = 'published' THEN posts.published_at END DESC NULLS LAST,
+  CASE WHEN $4::TEXT = 'title' THEN lower(posts.title) END ASC,
+  bookmarks.created_at DESC
 LIMIT $2
 `
 
 type GetBookmarksForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID  uuid.UUID
+	Limit   int32
+	Column3 string
+	Column4 string
 }
 
 type GetBookmarksForUserRow struct {
-	ID           uuid.UUID
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	Title        string
-	Url          string
-	Description  sql.NullString
-	PublishedAt  sql.NullTime
-	FeedID       uuid.UUID
-	FeedName     string
-	BookmarkedAt time.Time
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+	BookmarkedAt    time.Time
 }
 
+// Only bookmarks of posts tagged $3 unless it is empty, and none from
+// removed feeds. Sorting by manual puts the bookmarks placed in $3's order
+// first, the rest newest first.
 func (q *Queries) GetBookmarksForUser(ctx context.Context, arg GetBookmarksForUserParams) ([]GetBookmarksForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getBookmarksForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getBookmarksForUser,
+		arg.UserID,
+		arg.Limit,
+		arg.Column3,
+		arg.Column4,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +270,15 @@ func (q *Queries) GetBookmarksForUser(ctx context.Context, arg GetBookmarksForUs
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
 			&i.FeedName,
 			&i.BookmarkedAt,
 		); err != nil {
@@ -124,9 +296,12 @@ func (q *Queries) GetBookmarksForUser(ctx context.Context, arg GetBookmarksForUs
 }
 
 const getPostByURL = `-- name: GetPostByURL :one
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts WHERE url = $1
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, duration_seconds, episode, season, image_url, episode_type, embed_url, view_count, domain, canonical_url FROM posts WHERE url = $1 OR canonical_url = $1
+ORDER BY url = $1 DESC
+LIMIT 1
 `
 
+// A post's canonical URL finds it as well as the URL from its feed
 func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
 	row := q.db.QueryRowContext(ctx, getPostByURL, url)
 	var i Post
@@ -139,6 +314,15 @@ func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.Season,
+		&i.ImageUrl,
+		&i.EpisodeType,
+		&i.EmbedUrl,
+		&i.ViewCount,
+		&i.Domain,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
@@ -146,7 +330,7 @@ func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
 const isPostBookmarked = `-- name: IsPostBookmarked :one
 SELECT EXISTS(
     SELECT 1 FROM bookmarks
-    WHERE user_id = $1 AND post_id = $2
+    WHERE user_id = $1 AND post_id = $2 AND deleted_at IS NULL
 ) AS is_bookmarked
 `
 
@@ -161,3 +345,54 @@ func (q *Queries) IsPostBookmarked(ctx context.Context, arg IsPostBookmarkedPara
 	err := row.Scan(&is_bookmarked)
 	return is_bookmarked, err
 }
+
+const renameBookmarkPositions = `-- name: RenameBookmarkPositions :exec
+UPDATE bookmark_positions SET tag = $1::TEXT
+WHERE user_id = $2::UUID AND tag = $3::TEXT
+AND NOT EXISTS (
+  SELECT 1 FROM bookmark_positions existing
+  WHERE existing.user_id = $2::UUID AND existing.tag = $1::TEXT
+)
+`
+
+type RenameBookmarkPositionsParams struct {
+	ToTag   string
+	UserID  uuid.UUID
+	FromTag string
+}
+
+// A tag that already has an order keeps it
+func (q *Queries) RenameBookmarkPositions(ctx context.Context, arg RenameBookmarkPositionsParams) error {
+	_, err := q.db.ExecContext(ctx, renameBookmarkPositions, arg.ToTag, arg.UserID, arg.FromTag)
+	return err
+}
+
+const restoreBookmark = `-- name: RestoreBookmark :exec
+UPDATE bookmarks
+SET deleted_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) RestoreBookmark(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreBookmark, id)
+	return err
+}
+
+const saveBookmarkPositions = `-- name: SaveBookmarkPositions :exec
+INSERT INTO bookmark_positions (user_id, tag, post_id, position)
+SELECT $1::UUID, $2::TEXT, ordered.post_id, ordered.position
+FROM unnest($3::UUID[]) WITH ORDINALITY AS ordered(post_id, position)
+ON CONFLICT (user_id, tag, post_id) DO UPDATE SET position = EXCLUDED.position
+`
+
+type SaveBookmarkPositionsParams struct {
+	UserID  uuid.UUID
+	Tag     string
+	PostIds []uuid.UUID
+}
+
+// Places the posts in the order given, in the list of the tag
+func (q *Queries) SaveBookmarkPositions(ctx context.Context, arg SaveBookmarkPositionsParams) error {
+	_, err := q.db.ExecContext(ctx, saveBookmarkPositions, arg.UserID, arg.Tag, pq.Array(arg.PostIds))
+	return err
+}
@@ -7,19 +7,21 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, deleted_at, category
 )
 SELECT 
-    iff.id, iff.created_at, iff.updated_at, iff.user_id, iff.feed_id,
+    iff.id, iff.created_at, iff.updated_at, iff.user_id, iff.feed_id, iff.deleted_at, iff.category,
     users.name AS user_name,
     feeds.name AS feed_name
 FROM inserted_feed_follow iff
@@ -41,6 +43,8 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	DeletedAt sql.NullTime
+	Category  sql.NullString
 	UserName  string
 	FeedName  string
 }
@@ -60,6 +64,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.DeletedAt,
+		&i.Category,
 		&i.UserName,
 		&i.FeedName,
 	)
@@ -67,32 +73,58 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 }
 
 const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
-DELETE FROM feed_follows
-USING feeds
+UPDATE feed_follows
+SET deleted_at = $3
+FROM feeds
 WHERE feed_follows.feed_id = feeds.id
   AND feed_follows.user_id = $1
   AND feeds.url = $2
+  AND feed_follows.deleted_at IS NULL
+  AND feeds.deleted_at IS NULL
 `
 
 type DeleteFeedFollowParams struct {
-	UserID uuid.UUID
-	Url    string
+	UserID    uuid.UUID
+	Url       string
+	DeletedAt sql.NullTime
 }
 
 func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url, arg.DeletedAt)
+	return err
+}
+
+const deleteMovedFeedFollows = `-- name: DeleteMovedFeedFollows :exec
+DELETE FROM feed_follows moved
+USING feed_follows original
+WHERE original.feed_id = $1::UUID
+  AND original.deleted_at IS NULL
+  AND moved.feed_id = $2::UUID
+  AND moved.user_id = original.user_id
+  AND moved.created_at = original.created_at
+`
+
+type DeleteMovedFeedFollowsParams struct {
+	FromFeedID uuid.UUID
+	ToFeedID   uuid.UUID
+}
+
+// Deletes the follows feed merge copied from an alias to its canonical feed,
+// recognised by the created_at they kept from the alias's follows
+func (q *Queries) DeleteMovedFeedFollows(ctx context.Context, arg DeleteMovedFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMovedFeedFollows, arg.FromFeedID, arg.ToFeedID)
 	return err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT 
-    ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id,
+    ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id, ff.deleted_at, ff.category,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM feed_follows ff
 INNER JOIN users ON users.id = ff.user_id
 INNER JOIN feeds ON feeds.id = ff.feed_id
-WHERE ff.user_id = $1
+WHERE ff.user_id = $1 AND ff.deleted_at IS NULL AND feeds.deleted_at IS NULL
 ORDER BY feeds.name ASC
 `
 
@@ -102,6 +134,8 @@ type GetFeedFollowsForUserRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	DeletedAt sql.NullTime
+	Category  sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -121,6 +155,8 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.DeletedAt,
+			&i.Category,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -136,3 +172,192 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const getFollowedFeedIDs = `-- name: GetFollowedFeedIDs :many
+SELECT feeds.id FROM feeds
+WHERE feeds.deleted_at IS NULL
+AND (cardinality($2::TEXT[]) = 0 OR feeds.name ILIKE ANY($2::TEXT[]))
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+`
+
+type GetFollowedFeedIDsParams struct {
+	UserID  uuid.UUID
+	Column2 []string
+}
+
+// Following an alias of a feed counts as following the feed itself
+func (q *Queries) GetFollowedFeedIDs(ctx context.Context, arg GetFollowedFeedIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedIDs, arg.UserID, pq.Array(arg.Column2))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsForUser = `-- name: GetFollowedFeedsForUser :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.last_error_kind, feeds.last_error, feeds.last_error_at, feeds.insecure_skip_verify, feeds.consecutive_failures, feeds.title_rules, feeds.deleted_at, feeds.next_fetch_at, feeds.resolved_url, feeds.canonical_feed_id, feeds.fetch_interval_seconds, feeds.fetch_timeout_seconds, feeds.fetch_retries, feeds.max_body_bytes, feeds.last_http_status, feeds.disabled_at, feeds.headlines_only, ff.created_at AS followed_at, ff.category
+FROM feed_follows ff
+INNER JOIN feeds ON feeds.id = ff.feed_id
+WHERE ff.user_id = $1 AND ff.deleted_at IS NULL AND feeds.deleted_at IS NULL
+ORDER BY ff.created_at ASC
+`
+
+type GetFollowedFeedsForUserRow struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	LastErrorKind        sql.NullString
+	LastError            sql.NullString
+	LastErrorAt          sql.NullTime
+	InsecureSkipVerify   bool
+	ConsecutiveFailures  int32
+	TitleRules           []string
+	DeletedAt            sql.NullTime
+	NextFetchAt          sql.NullTime
+	ResolvedUrl          sql.NullString
+	CanonicalFeedID      uuid.NullUUID
+	FetchIntervalSeconds sql.NullInt32
+	FetchTimeoutSeconds  sql.NullInt32
+	FetchRetries         sql.NullInt32
+	MaxBodyBytes         sql.NullInt64
+	LastHttpStatus       sql.NullInt32
+	DisabledAt           sql.NullTime
+	HeadlinesOnly        sql.NullBool
+	FollowedAt           time.Time
+	Category             sql.NullString
+}
+
+func (q *Queries) GetFollowedFeedsForUser(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsForUserRow
+	for rows.Next() {
+		var i GetFollowedFeedsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastErrorKind,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.InsecureSkipVerify,
+			&i.ConsecutiveFailures,
+			pq.Array(&i.TitleRules),
+			&i.DeletedAt,
+			&i.NextFetchAt,
+			&i.ResolvedUrl,
+			&i.CanonicalFeedID,
+			&i.FetchIntervalSeconds,
+			&i.FetchTimeoutSeconds,
+			&i.FetchRetries,
+			&i.MaxBodyBytes,
+			&i.LastHttpStatus,
+			&i.DisabledAt,
+			&i.HeadlinesOnly,
+			&i.FollowedAt,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+SELECT gen_random_uuid(), ff.created_at, NOW(), ff.user_id, $1::UUID, ff.category
+FROM feed_follows ff
+WHERE ff.feed_id = $2::UUID
+  AND ff.deleted_at IS NULL
+ON CONFLICT DO NOTHING
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const restoreFeedFollow = `-- name: RestoreFeedFollow :exec
+UPDATE feed_follows
+SET deleted_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) RestoreFeedFollow(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreFeedFollow, id)
+	return err
+}
+
+const setFollowCategory = `-- name: SetFollowCategory :execrows
+UPDATE feed_follows SET category = $1, updated_at = $2
+WHERE user_id = $3 AND deleted_at IS NULL
+AND feed_id IN (
+  SELECT feeds.id FROM feeds
+  WHERE feeds.id = $4::UUID OR feeds.canonical_feed_id = $4::UUID
+)
+`
+
+type SetFollowCategoryParams struct {
+	Category  sql.NullString
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+// Files the user's follow of a feed, or of an alias of it, under a category
+func (q *Queries) SetFollowCategory(ctx context.Context, arg SetFollowCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFollowCategory,
+		arg.Category,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
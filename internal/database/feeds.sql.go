@@ -7,24 +7,88 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimNextFeedToFetch = `-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = (
+    SELECT id FROM feeds
+    WHERE deleted_at IS NULL
+      AND disabled_at IS NULL
+      AND canonical_feed_id IS NULL
+      -- next_fetch_at holds UTC wall-clock times written by gator
+      AND (next_fetch_at IS NULL OR next_fetch_at <= (NOW() AT TIME ZONE 'UTC'))
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only
+`
+
+func (q *Queries) ClaimNextFeedToFetch(ctx context.Context, nextFetchAt sql.NullTime) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeedToFetch, nextFetchAt)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
+	)
+	return i, err
+}
+
+const clearFeedError = `-- name: ClearFeedError :exec
+UPDATE feeds
+SET last_error_kind = NULL, last_error = NULL, last_http_status = NULL, last_error_at = NULL,
+    consecutive_failures = 0
+WHERE id = $1
+`
+
+func (q *Queries) ClearFeedError(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearFeedError, id)
+	return err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, insecure_skip_verify)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Name               string
+	Url                string
+	UserID             uuid.UUID
+	InsecureSkipVerify bool
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -35,7 +99,158 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.InsecureSkipVerify,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
+	)
+	return i, err
+}
+
+const createFeedURLChange = `-- name: CreateFeedURLChange :exec
+INSERT INTO feed_url_changes (id, feed_id, changed_at, old_url, new_url)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateFeedURLChangeParams struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	ChangedAt time.Time
+	OldUrl    string
+	NewUrl    string
+}
+
+func (q *Queries) CreateFeedURLChange(ctx context.Context, arg CreateFeedURLChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createFeedURLChange,
+		arg.ID,
+		arg.FeedID,
+		arg.ChangedAt,
+		arg.OldUrl,
+		arg.NewUrl,
+	)
+	return err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+UPDATE feeds
+SET deleted_at = $2
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type DeleteFeedParams struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) DeleteFeed(ctx context.Context, arg DeleteFeedParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, arg.ID, arg.DeletedAt)
+	return err
+}
+
+const disableFeed = `-- name: DisableFeed :exec
+UPDATE feeds
+SET disabled_at = COALESCE(disabled_at, NOW())
+WHERE id = $1
+`
+
+func (q *Queries) DisableFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, id)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :exec
+UPDATE feeds
+SET disabled_at = NULL, consecutive_failures = 0, next_fetch_at = NULL, updated_at = $2
+WHERE id = $1
+`
+
+type EnableFeedParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) EnableFeed(ctx context.Context, arg EnableFeedParams) error {
+	_, err := q.db.ExecContext(ctx, enableFeed, arg.ID, arg.UpdatedAt)
+	return err
+}
+
+const getCanonicalFeedForResolvedURL = `-- name: GetCanonicalFeedForResolvedURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds
+WHERE resolved_url = $1
+  AND id <> $2
+  AND deleted_at IS NULL
+  AND canonical_feed_id IS NULL
+ORDER BY created_at ASC
+LIMIT 1
+`
+
+type GetCanonicalFeedForResolvedURLParams struct {
+	ResolvedUrl sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) GetCanonicalFeedForResolvedURL(ctx context.Context, arg GetCanonicalFeedForResolvedURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getCanonicalFeedForResolvedURL, arg.ResolvedUrl, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
 	)
+	return i, err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -45,12 +260,29 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds WHERE url = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -64,24 +296,212 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
 	)
 	return i, err
 }
 
+const getFeedURLChanges = `-- name: GetFeedURLChanges :many
+SELECT id, feed_id, changed_at, old_url, new_url FROM feed_url_changes
+WHERE feed_id = $1
+ORDER BY changed_at DESC
+`
+
+func (q *Queries) GetFeedURLChanges(ctx context.Context, feedID uuid.UUID) ([]FeedUrlChange, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedURLChanges, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedUrlChange
+	for rows.Next() {
+		var i FeedUrlChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.ChangedAt,
+			&i.OldUrl,
+			&i.NewUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsCreatedByUser = `-- name: GetFeedsCreatedByUser :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetFeedsCreatedByUser(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsCreatedByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastErrorKind,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.InsecureSkipVerify,
+			&i.ConsecutiveFailures,
+			pq.Array(&i.TitleRules),
+			&i.DeletedAt,
+			&i.NextFetchAt,
+			&i.ResolvedUrl,
+			&i.CanonicalFeedID,
+			&i.FetchIntervalSeconds,
+			&i.FetchTimeoutSeconds,
+			&i.FetchRetries,
+			&i.MaxBodyBytes,
+			&i.LastHttpStatus,
+			&i.DisabledAt,
+				&i.HeadlinesOnly,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsWithErrors = `-- name: GetFeedsWithErrors :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds
+WHERE last_error_kind IS NOT NULL AND deleted_at IS NULL
+ORDER BY last_error_at DESC
+`
+
+func (q *Queries) GetFeedsWithErrors(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsWithErrors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastErrorKind,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.InsecureSkipVerify,
+			&i.ConsecutiveFailures,
+			pq.Array(&i.TitleRules),
+			&i.DeletedAt,
+			&i.NextFetchAt,
+			&i.ResolvedUrl,
+			&i.CanonicalFeedID,
+			&i.FetchIntervalSeconds,
+			&i.FetchTimeoutSeconds,
+			&i.FetchRetries,
+			&i.MaxBodyBytes,
+			&i.LastHttpStatus,
+			&i.DisabledAt,
+				&i.HeadlinesOnly,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedsWithUsers = `-- name: GetFeedsWithUsers :many
 SELECT 
+    feeds.id,
     feeds.name AS feed_name,
     feeds.url AS feed_url,
-    users.name AS user_name
+    feeds.insecure_skip_verify,
+    feeds.title_rules,
+    feeds.fetch_interval_seconds,
+    feeds.fetch_timeout_seconds,
+    feeds.fetch_retries,
+    feeds.max_body_bytes,
+    feeds.disabled_at,
+    feeds.consecutive_failures,
+    feeds.last_error,
+    feeds.last_http_status,
+    feeds.headlines_only,
+    users.name AS user_name,
+    COALESCE(canonical.name, '') AS canonical_feed_name
 FROM feeds
 INNER JOIN users ON feeds.user_id = users.id
+LEFT JOIN feeds canonical ON canonical.id = feeds.canonical_feed_id
+WHERE feeds.deleted_at IS NULL
 ORDER BY feeds.name ASC
 `
 
 type GetFeedsWithUsersRow struct {
-	FeedName string
-	FeedUrl  string
-	UserName string
+	ID                   uuid.UUID
+	FeedName             string
+	FeedUrl              string
+	InsecureSkipVerify   bool
+	TitleRules           []string
+	FetchIntervalSeconds sql.NullInt32
+	FetchTimeoutSeconds  sql.NullInt32
+	FetchRetries         sql.NullInt32
+	MaxBodyBytes         sql.NullInt64
+	DisabledAt           sql.NullTime
+	ConsecutiveFailures  int32
+	LastError            sql.NullString
+	LastHttpStatus       sql.NullInt32
+	HeadlinesOnly        sql.NullBool
+	UserName             string
+	CanonicalFeedName    string
 }
 
 func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]GetFeedsWithUsersRow, error) {
@@ -93,7 +513,24 @@ func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]GetFeedsWithUsersRow
 	var items []GetFeedsWithUsersRow
 	for rows.Next() {
 		var i GetFeedsWithUsersRow
-		if err := rows.Scan(&i.FeedName, &i.FeedUrl, &i.UserName); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.InsecureSkipVerify,
+			pq.Array(&i.TitleRules),
+			&i.FetchIntervalSeconds,
+			&i.FetchTimeoutSeconds,
+			&i.FetchRetries,
+			&i.MaxBodyBytes,
+			&i.DisabledAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastHttpStatus,
+				&i.HeadlinesOnly,
+			&i.UserName,
+			&i.CanonicalFeedName,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -108,7 +545,8 @@ func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]GetFeedsWithUsersRow
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error_kind, last_error, last_error_at, insecure_skip_verify, consecutive_failures, title_rules, deleted_at, next_fetch_at, resolved_url, canonical_feed_id, fetch_interval_seconds, fetch_timeout_seconds, fetch_retries, max_body_bytes, last_http_status, disabled_at, headlines_only FROM feeds
+WHERE deleted_at IS NULL
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
@@ -124,54 +562,260 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastErrorKind,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.InsecureSkipVerify,
+		&i.ConsecutiveFailures,
+		pq.Array(&i.TitleRules),
+		&i.DeletedAt,
+		&i.NextFetchAt,
+		&i.ResolvedUrl,
+		&i.CanonicalFeedID,
+		&i.FetchIntervalSeconds,
+		&i.FetchTimeoutSeconds,
+		&i.FetchRetries,
+		&i.MaxBodyBytes,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+		&i.HeadlinesOnly,
 	)
 	return i, err
 }
 
-const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1
+const markFeedFetched = `-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = NOW(), updated_at = NOW()
+WHERE id = $1
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+	return err
+}
+
+const releaseFeedAliases = `-- name: ReleaseFeedAliases :exec
+UPDATE feeds
+SET canonical_feed_id = NULL, next_fetch_at = NULL, updated_at = NOW()
+WHERE canonical_feed_id = $1
+`
+
+// Aliases aren't fetched, so once their canonical feed is removed or disabled
+// they go back to being fetched on their own
+func (q *Queries) ReleaseFeedAliases(ctx context.Context, canonicalFeedID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedAliases, canonicalFeedID)
+	return err
+}
+
+const renewFeedClaim = `-- name: RenewFeedClaim :execrows
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = $2 AND next_fetch_at = $3
+`
+
+type RenewFeedClaimParams struct {
+	Until sql.NullTime
+	ID    uuid.UUID
+	Lease sql.NullTime
+}
+
+// Moves the lease of a claimed feed ahead, unless the feed was rescheduled
+// since
+func (q *Queries) RenewFeedClaim(ctx context.Context, arg RenewFeedClaimParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renewFeedClaim, arg.Until, arg.ID, arg.Lease)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []Feed
-	for rows.Next() {
-		var i Feed
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.Url,
-			&i.UserID,
-			&i.LastFetchedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const restoreFeed = `-- name: RestoreFeed :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
+SET deleted_at = NULL
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+func (q *Queries) RestoreFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreFeed, id)
+	return err
+}
+
+const setFeedCanonical = `-- name: SetFeedCanonical :exec
+UPDATE feeds
+SET canonical_feed_id = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedCanonicalParams struct {
+	ID              uuid.UUID
+	CanonicalFeedID uuid.NullUUID
+}
+
+func (q *Queries) SetFeedCanonical(ctx context.Context, arg SetFeedCanonicalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCanonical, arg.ID, arg.CanonicalFeedID)
+	return err
+}
+
+const setFeedError = `-- name: SetFeedError :one
+UPDATE feeds
+SET last_error_kind = $2, last_error = $3, last_http_status = $4, last_error_at = NOW(),
+    consecutive_failures = consecutive_failures + 1
+WHERE id = $1
+RETURNING consecutive_failures
+`
+
+type SetFeedErrorParams struct {
+	ID             uuid.UUID
+	LastErrorKind  sql.NullString
+	LastError      sql.NullString
+	LastHttpStatus sql.NullInt32
+}
+
+func (q *Queries) SetFeedError(ctx context.Context, arg SetFeedErrorParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, setFeedError,
+		arg.ID,
+		arg.LastErrorKind,
+		arg.LastError,
+		arg.LastHttpStatus,
+	)
+	var consecutive_failures int32
+	err := row.Scan(&consecutive_failures)
+	return consecutive_failures, err
+}
+
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :exec
+UPDATE feeds SET fetch_interval_seconds = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedFetchIntervalParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+	UpdatedAt            time.Time
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds, arg.UpdatedAt)
+	return err
+}
+
+const setFeedFetchPolicy = `-- name: SetFeedFetchPolicy :exec
+UPDATE feeds
+SET fetch_timeout_seconds = $2, fetch_retries = $3, max_body_bytes = $4, updated_at = $5
+WHERE id = $1
+`
+
+type SetFeedFetchPolicyParams struct {
+	ID                  uuid.UUID
+	FetchTimeoutSeconds sql.NullInt32
+	FetchRetries        sql.NullInt32
+	MaxBodyBytes        sql.NullInt64
+	UpdatedAt           time.Time
+}
+
+func (q *Queries) SetFeedFetchPolicy(ctx context.Context, arg SetFeedFetchPolicyParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchPolicy,
+		arg.ID,
+		arg.FetchTimeoutSeconds,
+		arg.FetchRetries,
+		arg.MaxBodyBytes,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const setFeedHeadlinesOnly = `-- name: SetFeedHeadlinesOnly :exec
+UPDATE feeds SET headlines_only = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedHeadlinesOnlyParams struct {
+	ID            uuid.UUID
+	HeadlinesOnly sql.NullBool
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) SetFeedHeadlinesOnly(ctx context.Context, arg SetFeedHeadlinesOnlyParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedHeadlinesOnly, arg.ID, arg.HeadlinesOnly, arg.UpdatedAt)
+	return err
+}
+
+const setFeedNextFetchAt = `-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1
+`
+
+type SetFeedNextFetchAtParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) SetFeedNextFetchAt(ctx context.Context, arg SetFeedNextFetchAtParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchAt, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const setFeedOwner = `-- name: SetFeedOwner :exec
+UPDATE feeds SET user_id = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedOwnerParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg SetFeedOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedOwner, arg.ID, arg.UserID, arg.UpdatedAt)
+	return err
+}
+
+const setFeedResolvedURL = `-- name: SetFeedResolvedURL :exec
+UPDATE feeds
+SET resolved_url = $2
+WHERE id = $1
+`
+
+type SetFeedResolvedURLParams struct {
+	ID          uuid.UUID
+	ResolvedUrl sql.NullString
+}
+
+func (q *Queries) SetFeedResolvedURL(ctx context.Context, arg SetFeedResolvedURLParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedResolvedURL, arg.ID, arg.ResolvedUrl)
+	return err
+}
+
+const setFeedTitleRules = `-- name: SetFeedTitleRules :exec
+UPDATE feeds
+SET title_rules = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedTitleRulesParams struct {
+	ID         uuid.UUID
+	TitleRules []string
+}
+
+func (q *Queries) SetFeedTitleRules(ctx context.Context, arg SetFeedTitleRulesParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedTitleRules, arg.ID, pq.Array(arg.TitleRules))
+	return err
+}
+
+const setFeedURL = `-- name: SetFeedURL :exec
+UPDATE feeds SET url = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedURLParams struct {
+	ID        uuid.UUID
+	Url       string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedURL(ctx context.Context, arg SetFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedURL, arg.ID, arg.Url, arg.UpdatedAt)
 	return err
 }
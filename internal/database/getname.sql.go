@@ -10,7 +10,7 @@ import (
 )
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, created_at, updated_at, name FROM users WHERE name = $1
+SELECT id, created_at, updated_at, name, is_system FROM users WHERE name = $1
 `
 
 func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error) {
@@ -21,6 +21,7 @@ func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.IsSystem,
 	)
 	return i, err
 }
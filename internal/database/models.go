@@ -11,22 +11,79 @@ import (
 	"github.com/google/uuid"
 )
 
+type AggCycle struct {
+	ID             uuid.UUID
+	StartedAt      time.Time
+	DurationMs     int64
+	FeedsAttempted int32
+	FeedsSucceeded int32
+	FeedsFailed    int32
+	FeedsSkipped   int32
+	NewPosts       int32
+}
+
+type ApiToken struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Name         string
+	TokenHash    string
+	FeverKeyHash string
+	CreatedAt    time.Time
+	LastUsedAt   sql.NullTime
+}
+
 type Bookmark struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	PostID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+type BookmarkPosition struct {
+	UserID   uuid.UUID
+	Tag      string
+	PostID   uuid.UUID
+	Position int32
+}
+
+type Collection struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Tag         string
+	Name        string
+	Description string
+	ShareToken  sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	LastErrorKind        sql.NullString
+	LastError            sql.NullString
+	LastErrorAt          sql.NullTime
+	InsecureSkipVerify   bool
+	ConsecutiveFailures  int32
+	TitleRules           []string
+	DeletedAt            sql.NullTime
+	NextFetchAt          sql.NullTime
+	ResolvedUrl          sql.NullString
+	CanonicalFeedID      uuid.NullUUID
+	FetchIntervalSeconds sql.NullInt32
+	FetchTimeoutSeconds  sql.NullInt32
+	FetchRetries         sql.NullInt32
+	MaxBodyBytes         sql.NullInt64
+	LastHttpStatus       sql.NullInt32
+	DisabledAt           sql.NullTime
+	HeadlinesOnly        sql.NullBool
 }
 
 type FeedFollow struct {
@@ -35,17 +92,165 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	DeletedAt sql.NullTime
+	Category  sql.NullString
+}
+
+type FeedNumber struct {
+	Number int64
+	FeedID uuid.UUID
+}
+
+type FeedRequest struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Url       string
+	Name      string
+	Reason    string
+	Status    string
+	DecidedBy uuid.NullUUID
+	DecidedAt sql.NullTime
+	Note      string
+}
+
+type FeedUrlChange struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	ChangedAt time.Time
+	OldUrl    string
+	NewUrl    string
+}
+
+type FetchLog struct {
+	ID         uuid.UUID
+	FeedID     uuid.UUID
+	FetchedAt  time.Time
+	StatusCode sql.NullInt32
+	Bytes      sql.NullInt32
+	DurationMs int64
+	NewPosts   int32
+	ErrorKind  sql.NullString
+	Error      sql.NullString
+}
+
+type HiddenPost struct {
+	UserID   uuid.UUID
+	PostID   uuid.UUID
+	HiddenAt time.Time
+}
+
+type ImportedState struct {
+	UserID     uuid.UUID
+	Url        string
+	IsRead     bool
+	IsStarred  bool
+	ImportedAt time.Time
+}
+
+type ListedPost struct {
+	UserID   uuid.UUID
+	Position int32
+	PostID   uuid.UUID
+}
+
+type LoginSession struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedDomain struct {
+	UserID    uuid.UUID
+	Domain    string
+	CreatedAt time.Time
 }
 
 type Post struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+}
+
+type PostContent struct {
+	PostID    uuid.UUID
+	FetchedAt time.Time
+	Content   string
+}
+
+type PostLink struct {
+	PostID   uuid.UUID
+	Position int32
+	Url      string
+	Text     string
+}
+
+type PostNumber struct {
+	Number int64
+	PostID uuid.UUID
+}
+
+type PostOpen struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	PostID   uuid.UUID
+	OpenedAt time.Time
+	Source   string
+}
+
+type PostPreview struct {
+	PostID      uuid.UUID
+	FetchedAt   time.Time
+	Title       string
+	Description string
+	ImageUrl    string
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type QuarantinedItem struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	FeedID      uuid.UUID
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	Reason      string
+}
+
+type Rule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Pattern   string
+	Action    string
+	Tag       sql.NullString
 }
 
 type User struct {
@@ -53,4 +258,11 @@ type User struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
+	IsSystem  bool
+}
+
+type UserPassword struct {
+	UserID       uuid.UUID
+	PasswordHash string
+	UpdatedAt    time.Time
 }
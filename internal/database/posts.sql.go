@@ -11,23 +11,111 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const canonicalURLExists = `-- name: CanonicalURLExists :one
+SELECT EXISTS (
+  SELECT 1 FROM posts WHERE canonical_url = $1
+  AND feed_id IN (
+    -- Only the same feed or its aliases, posts of other feeds have followers
+    -- of their own
+    SELECT family.id FROM feeds family, feeds own
+    WHERE own.id = $2::UUID
+    AND COALESCE(family.canonical_feed_id, family.id) = COALESCE(own.canonical_feed_id, own.id)
+  )
+)
+`
+
+type CanonicalURLExistsParams struct {
+	CanonicalUrl sql.NullString
+	FeedID       uuid.UUID
+}
+
+func (q *Queries) CanonicalURLExists(ctx context.Context, arg CanonicalURLExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canonicalURLExists, arg.CanonicalUrl, arg.FeedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const copyPostReads = `-- name: CopyPostReads :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT source.user_id, $1::UUID, source.read_at
+FROM post_reads source
+WHERE source.post_id = $2::UUID
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type CopyPostReadsParams struct {
+	ToPostID   uuid.UUID
+	FromPostID uuid.UUID
+}
+
+func (q *Queries) CopyPostReads(ctx context.Context, arg CopyPostReadsParams) error {
+	_, err := q.db.ExecContext(ctx, copyPostReads, arg.ToPostID, arg.FromPostID)
+	return err
+}
+
+const copyPostTags = `-- name: CopyPostTags :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT source.user_id, $1::UUID, source.tag, source.created_at
+FROM post_tags source
+WHERE source.post_id = $2::UUID
+ON CONFLICT (user_id, post_id, tag) DO NOTHING
+`
+
+type CopyPostTagsParams struct {
+	ToPostID   uuid.UUID
+	FromPostID uuid.UUID
+}
+
+func (q *Queries) CopyPostTags(ctx context.Context, arg CopyPostTagsParams) error {
+	_, err := q.db.ExecContext(ctx, copyPostTags, arg.ToPostID, arg.FromPostID)
+	return err
+}
+
+const countPostsForFeedSince = `-- name: CountPostsForFeedSince :one
+SELECT COUNT(*) FROM posts
+WHERE feed_id = $1
+AND COALESCE(published_at, created_at) >= $2
+`
+
+type CountPostsForFeedSinceParams struct {
+	FeedID      uuid.UUID
+	PublishedAt sql.NullTime
+}
+
+func (q *Queries) CountPostsForFeedSince(ctx context.Context, arg CountPostsForFeedSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForFeedSince, arg.FeedID, arg.PublishedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id,
+  duration_seconds, episode, season, image_url, episode_type, embed_url, view_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, duration_seconds, episode, season, image_url, episode_type, embed_url, view_count, domain, canonical_url
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -40,7 +128,163 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.DurationSeconds,
+		arg.Episode,
+		arg.Season,
+		arg.ImageUrl,
+		arg.EpisodeType,
+		arg.EmbedUrl,
+		arg.ViewCount,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.Season,
+		&i.ImageUrl,
+		&i.EpisodeType,
+		&i.EmbedUrl,
+		&i.ViewCount,
+		&i.Domain,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const createPosts = `-- name: CreatePosts :many
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id,
+  duration_seconds, episode, season, image_url, episode_type, embed_url, view_count)
+SELECT item.id, $1::TIMESTAMP, $1::TIMESTAMP, item.title, item.url,
+  NULLIF(item.description, ''), NULLIF(item.published_at, '0001-01-01'::TIMESTAMP), $2::UUID,
+  NULLIF(item.duration_seconds, 0), NULLIF(item.episode, 0), NULLIF(item.season, 0),
+  NULLIF(item.image_url, ''), NULLIF(item.episode_type, ''), NULLIF(item.embed_url, ''), NULLIF(item.view_count, 0)
+FROM unnest(
+  $3::UUID[], $4::TEXT[], $5::TEXT[], $6::TEXT[],
+  $7::TIMESTAMP[], $8::INTEGER[], $9::INTEGER[],
+  $10::INTEGER[], $11::TEXT[], $12::TEXT[],
+  $13::TEXT[], $14::BIGINT[]
+) AS item(id, title, url, description, published_at, duration_seconds, episode, season,
+  image_url, episode_type, embed_url, view_count)
+ON CONFLICT (url) DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, duration_seconds, episode, season, image_url, episode_type, embed_url, view_count, domain, canonical_url
+`
+
+type CreatePostsParams struct {
+	CreatedAt        time.Time
+	FeedID           uuid.UUID
+	Ids              []uuid.UUID
+	Titles           []string
+	Urls             []string
+	Descriptions     []string
+	PublishedAts     []time.Time
+	DurationsSeconds []int32
+	Episodes         []int32
+	Seasons          []int32
+	ImageUrls        []string
+	EpisodeTypes     []string
+	EmbedUrls        []string
+	ViewCounts       []int64
+}
+
+// Saves a feed's items in one statement, skipping the URLs already stored.
+// Empty strings, zeros and the zero time stand for NULL.
+func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, createPosts,
+		arg.CreatedAt,
+		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.DurationsSeconds),
+		pq.Array(arg.Episodes),
+		pq.Array(arg.Seasons),
+		pq.Array(arg.ImageUrls),
+		pq.Array(arg.EpisodeTypes),
+		pq.Array(arg.EmbedUrls),
+		pq.Array(arg.ViewCounts),
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePost = `-- name: DeletePost :exec
+DELETE FROM posts WHERE id = $1
+`
+
+func (q *Queries) DeletePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePost, id)
+	return err
+}
+
+const getOtherPostWithURL = `-- name: GetOtherPostWithURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, duration_seconds, episode, season, image_url, episode_type, embed_url, view_count, domain, canonical_url FROM posts
+WHERE (url = $1 OR canonical_url = $1) AND id <> $2
+AND feed_id IN (
+  -- Only the same feed or its aliases, posts of other feeds have followers
+  -- of their own
+  SELECT family.id FROM feeds family, feeds own
+  WHERE own.id = $3::UUID
+  AND COALESCE(family.canonical_feed_id, family.id) = COALESCE(own.canonical_feed_id, own.id)
+)
+ORDER BY created_at
+LIMIT 1
+`
+
+type GetOtherPostWithURLParams struct {
+	Url    string
+	ID     uuid.UUID
+	FeedID uuid.UUID
+}
+
+// The post a canonical URL belongs to in the same feed, other than the one
+// it was found for
+func (q *Queries) GetOtherPostWithURL(ctx context.Context, arg GetOtherPostWithURLParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getOtherPostWithURL, arg.Url, arg.ID, arg.FeedID)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -51,16 +295,143 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.Season,
+		&i.ImageUrl,
+		&i.EpisodeType,
+		&i.EmbedUrl,
+		&i.ViewCount,
+		&i.Domain,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
 
+const getPostsForFeedSince = `-- name: GetPostsForFeedSince :many
+SELECT id, title, url, COALESCE(published_at, created_at)::TIMESTAMP AS posted_at
+FROM posts
+WHERE feed_id = $1
+AND COALESCE(published_at, created_at) >= $2
+ORDER BY posted_at DESC
+`
+
+type GetPostsForFeedSinceParams struct {
+	FeedID      uuid.UUID
+	PublishedAt sql.NullTime
+}
+
+type GetPostsForFeedSinceRow struct {
+	ID       uuid.UUID
+	Title    string
+	Url      string
+	PostedAt time.Time
+}
+
+func (q *Queries) GetPostsForFeedSince(ctx context.Context, arg GetPostsForFeedSinceParams) ([]GetPostsForFeedSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForFeedSince, arg.FeedID, arg.PublishedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForFeedSinceRow
+	for rows.Next() {
+		var i GetPostsForFeedSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PostedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForIndex = `-- name: GetPostsForIndex :many
+SELECT posts.id, posts.feed_id, posts.title, posts.description, posts.url, posts.published_at,
+  feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+ORDER BY posts.id
+LIMIT $1 OFFSET $2
+`
+
+type GetPostsForIndexParams struct {
+	Limit  int32
+	Offset int32
+}
+
+type GetPostsForIndexRow struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Description sql.NullString
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    string
+}
+
+func (q *Queries) GetPostsForIndex(ctx context.Context, arg GetPostsForIndexParams) ([]GetPostsForIndexRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForIndex, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForIndexRow
+	for rows.Next() {
+		var i GetPostsForIndexRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Description,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
 ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
 LIMIT $2
 `
@@ -71,17 +442,27 @@ type GetPostsForUserParams struct {
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
 }
 
+// Hide posts linking to a domain the user muted, or one of its subdomains
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
 	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
 	if err != nil {
@@ -100,6 +481,219 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByIDs = `-- name: GetPostsForUserByIDs :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND posts.id = ANY($2::uuid[])
+AND (NOT $3::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+`
+
+type GetPostsForUserByIDsParams struct {
+	UserID  uuid.UUID
+	Column2 []uuid.UUID
+	Column3 bool
+}
+
+type GetPostsForUserByIDsRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+	IsRead          bool
+}
+
+// Hide posts linking to a domain the user muted, or one of its subdomains
+func (q *Queries) GetPostsForUserByIDs(ctx context.Context, arg GetPostsForUserByIDsParams) ([]GetPostsForUserByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByIDs, arg.UserID, pq.Array(arg.Column2), arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByIDsRow
+	for rows.Next() {
+		var i GetPostsForUserByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
+			&i.FeedName,
+			&i.IsRead,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserSince = `-- name: GetPostsForUserSince :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+AND COALESCE(posts.published_at, posts.created_at) >= $2
+AND (NOT $4::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+ORDER BY feeds.name, COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT $3
+`
+
+type GetPostsForUserSinceParams struct {
+	UserID      uuid.UUID
+	PublishedAt sql.NullTime
+	Limit       int32
+	Column4     bool
+}
+
+type GetPostsForUserSinceRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+}
+
+// Hide posts linking to a domain the user muted, or one of its subdomains
+func (q *Queries) GetPostsForUserSince(ctx context.Context, arg GetPostsForUserSinceParams) ([]GetPostsForUserSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserSince,
+		arg.UserID,
+		arg.PublishedAt,
+		arg.Limit,
+		arg.Column4,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserSinceRow
+	for rows.Next() {
+		var i GetPostsForUserSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
 			&i.FeedName,
 		); err != nil {
 			return nil, err
@@ -116,12 +710,54 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 }
 
 const getPostsForUserWithPagination = `-- name: GetPostsForUserWithPagination :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read,
+  COALESCE((
+    SELECT array_agg(post_tags.tag ORDER BY post_tags.tag) FROM post_tags
+    WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id
+  ), '{}')::TEXT[] AS tags,
+  EXISTS (
+    SELECT 1 FROM bookmarks
+    WHERE bookmarks.user_id = $1 AND bookmarks.post_id = posts.id AND bookmarks.deleted_at IS NULL
+  ) AS is_bookmarked
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-AND ($2::TEXT = '' OR feeds.name ILIKE '%' || $2 || '%')
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+AND (cardinality($2::TEXT[]) = 0 OR feeds.name ILIKE ANY($2::TEXT[]))
+AND NOT (feeds.name ILIKE ANY($8::TEXT[]))
+AND (NOT $9::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+AND ($10::TEXT = '' OR EXISTS (
+  SELECT 1 FROM post_tags WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id AND post_tags.tag = $10
+))
+AND ($11::TEXT = '' OR EXISTS (
+  -- Categories are the user's own, on their follow of the feed or an alias
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1 AND feed_follows.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+  AND lower(feed_follows.category) = lower($11::TEXT)
+))
+AND ($6::INTEGER = 0 OR posts.duration_seconds >= $6)
+AND ($7::INTEGER = 0 OR posts.duration_seconds <= $7)
 ORDER BY 
   CASE WHEN $3 = 'title' THEN posts.title END ASC,
   CASE WHEN $3 = 'title_desc' THEN posts.title END DESC,
@@ -129,37 +765,70 @@ ORDER BY
   CASE WHEN $3 = 'published_desc' OR $3 = '' THEN posts.published_at END DESC NULLS LAST,
   CASE WHEN $3 = 'feed' THEN feeds.name END ASC,
   CASE WHEN $3 = 'feed_desc' THEN feeds.name END DESC,
+  CASE WHEN $3 = 'episode' THEN posts.season END ASC NULLS LAST,
+  CASE WHEN $3 = 'episode' THEN posts.episode END ASC NULLS LAST,
+  CASE WHEN $3 = 'episode_desc' THEN posts.season END DESC NULLS LAST,
+  CASE WHEN $3 = 'episode_desc' THEN posts.episode END DESC NULLS LAST,
+  CASE WHEN $3 = 'duration' THEN posts.duration_seconds END ASC NULLS LAST,
+  CASE WHEN $3 = 'duration_desc' THEN posts.duration_seconds END DESC NULLS LAST,
   posts.created_at DESC
 LIMIT $4 OFFSET $5
 `
 
 type GetPostsForUserWithPaginationParams struct {
-	UserID  uuid.UUID
-	Column2 string
-	Column3 interface{}
-	Limit   int32
-	Offset  int32
+	UserID   uuid.UUID
+	Column2  []string
+	Column3  interface{}
+	Limit    int32
+	Offset   int32
+	Column6  int32
+	Column7  int32
+	Column8  []string
+	Column9  bool
+	Column10 string
+	Column11 string
 }
 
 type GetPostsForUserWithPaginationRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+	IsRead          bool
+	Tags            []string
+	IsBookmarked    bool
 }
 
+// Hide posts linking to a domain the user muted, or one of its subdomains
+// Feed filters are ILIKE patterns, empty include list means all feeds
+// Duration bounds are in seconds, 0 means unbounded
 func (q *Queries) GetPostsForUserWithPagination(ctx context.Context, arg GetPostsForUserWithPaginationParams) ([]GetPostsForUserWithPaginationRow, error) {
 	rows, err := q.db.QueryContext(ctx, getPostsForUserWithPagination,
 		arg.UserID,
-		arg.Column2,
+		pq.Array(arg.Column2),
 		arg.Column3,
 		arg.Limit,
 		arg.Offset,
+		arg.Column6,
+		arg.Column7,
+		pq.Array(arg.Column8),
+		arg.Column9,
+		arg.Column10,
+		arg.Column11,
 	)
 	if err != nil {
 		return nil, err
@@ -177,7 +846,19 @@ func (q *Queries) GetPostsForUserWithPagination(ctx context.Context, arg GetPost
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
 			&i.FeedName,
+			&i.IsRead,
+			pq.Array(&i.Tags),
+			&i.IsBookmarked,
 		); err != nil {
 			return nil, err
 		}
@@ -192,17 +873,62 @@ func (q *Queries) GetPostsForUserWithPagination(ctx context.Context, arg GetPost
 	return items, nil
 }
 
+const movePostBookmarks = `-- name: MovePostBookmarks :exec
+UPDATE bookmarks SET post_id = $1::UUID
+WHERE bookmarks.post_id = $2::UUID
+AND (bookmarks.deleted_at IS NOT NULL OR NOT EXISTS (
+  SELECT 1 FROM bookmarks target
+  WHERE target.user_id = bookmarks.user_id AND target.post_id = $1::UUID
+  AND target.deleted_at IS NULL
+))
+`
+
+type MovePostBookmarksParams struct {
+	ToPostID   uuid.UUID
+	FromPostID uuid.UUID
+}
+
+// Users who bookmarked both posts keep the bookmark they already have on
+// the target
+func (q *Queries) MovePostBookmarks(ctx context.Context, arg MovePostBookmarksParams) error {
+	_, err := q.db.ExecContext(ctx, movePostBookmarks, arg.ToPostID, arg.FromPostID)
+	return err
+}
+
 const searchPostsForUser = `-- name: SearchPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.duration_seconds, posts.episode, posts.season, posts.image_url, posts.episode_type, posts.embed_url, posts.view_count, posts.domain, posts.canonical_url, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
 AND (
   posts.title ILIKE '%' || $2 || '%' 
   OR posts.description ILIKE '%' || $2 || '%'
   OR feeds.name ILIKE '%' || $2 || '%'
 )
+AND (cardinality($4::TEXT[]) = 0 OR feeds.name ILIKE ANY($4::TEXT[]))
+AND (posts.published_at >= $5 OR $5 IS NULL)
+AND (posts.published_at < $6 OR $6 IS NULL)
+AND (NOT $7::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
 ORDER BY 
   CASE WHEN posts.title ILIKE '%' || $2 || '%' THEN 1 END,
   CASE WHEN feeds.name ILIKE '%' || $2 || '%' THEN 2 END,
@@ -213,25 +939,49 @@ LIMIT $3
 `
 
 type SearchPostsForUserParams struct {
-	UserID  uuid.UUID
-	Column2 sql.NullString
-	Limit   int32
+	UserID        uuid.UUID
+	Column2       sql.NullString
+	Limit         int32
+	Column4       []string
+	PublishedAt   sql.NullTime
+	PublishedAt_2 sql.NullTime
+	Column7       bool
 }
 
 type SearchPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	EpisodeType     sql.NullString
+	EmbedUrl        sql.NullString
+	ViewCount       sql.NullInt64
+	Domain          sql.NullString
+	CanonicalUrl    sql.NullString
+	FeedName        string
+	IsRead          bool
 }
 
+// Hide posts linking to a domain the user muted, or one of its subdomains
+// Feed filters are ILIKE patterns, empty means all feeds
 func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchPostsForUser, arg.UserID, arg.Column2, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.UserID,
+		arg.Column2,
+		arg.Limit,
+		pq.Array(arg.Column4),
+		arg.PublishedAt,
+		arg.PublishedAt_2,
+		arg.Column7,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +998,17 @@ func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUser
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.EpisodeType,
+			&i.EmbedUrl,
+			&i.ViewCount,
+			&i.Domain,
+			&i.CanonicalUrl,
 			&i.FeedName,
+			&i.IsRead,
 		); err != nil {
 			return nil, err
 		}
@@ -262,3 +1022,19 @@ func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUser
 	}
 	return items, nil
 }
+
+const setPostCanonicalURL = `-- name: SetPostCanonicalURL :exec
+UPDATE posts SET canonical_url = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetPostCanonicalURLParams struct {
+	ID           uuid.UUID
+	CanonicalUrl sql.NullString
+	UpdatedAt    time.Time
+}
+
+func (q *Queries) SetPostCanonicalURL(ctx context.Context, arg SetPostCanonicalURLParams) error {
+	_, err := q.db.ExecContext(ctx, setPostCanonicalURL, arg.ID, arg.CanonicalUrl, arg.UpdatedAt)
+	return err
+}
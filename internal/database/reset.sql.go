@@ -9,11 +9,30 @@ import (
 	"context"
 )
 
+const deleteAllFeeds = `-- name: DeleteAllFeeds :exec
+DELETE FROM feeds WHERE id <> '00000000-0000-0000-0000-000000000002'
+`
+
+// The announcements feed stays, new users follow it
+func (q *Queries) DeleteAllFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllFeeds)
+	return err
+}
+
 const deleteAllUsers = `-- name: DeleteAllUsers :exec
-DELETE FROM users
+DELETE FROM users WHERE NOT is_system
 `
 
 func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, deleteAllUsers)
 	return err
 }
+
+const deleteAnnouncements = `-- name: DeleteAnnouncements :exec
+DELETE FROM posts WHERE feed_id = '00000000-0000-0000-0000-000000000002'
+`
+
+func (q *Queries) DeleteAnnouncements(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAnnouncements)
+	return err
+}
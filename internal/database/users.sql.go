@@ -20,7 +20,7 @@ VALUES (
     $3,
     $4
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, is_system
 `
 
 type CreateUserParams struct {
@@ -43,12 +43,30 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.IsSystem,
+	)
+	return i, err
+}
+
+const getSystemUser = `-- name: GetSystemUser :one
+SELECT id, created_at, updated_at, name, is_system FROM users WHERE is_system LIMIT 1
+`
+
+func (q *Queries) GetSystemUser(ctx context.Context) (User, error) {
+	row := q.db.QueryRowContext(ctx, getSystemUser)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.IsSystem,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name FROM users ORDER BY name ASC
+SELECT id, created_at, updated_at, name, is_system FROM users WHERE NOT is_system ORDER BY name ASC
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -65,6 +83,7 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.IsSystem,
 		); err != nil {
 			return nil, err
 		}
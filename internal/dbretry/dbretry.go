@@ -0,0 +1,129 @@
+package dbretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DBTX is what the generated queries run their statements on
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Policy is how often and how patiently a statement is repeated
+type Policy struct {
+	// Attempts is how many times a statement runs at most
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for each further
+	// one up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultPolicy rides out a database restart of about a minute
+var DefaultPolicy = Policy{
+	Attempts:   8,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// DB runs statements on a DBTX, repeating the ones that fail with a
+// transient error. Those never ran, or ran in a transaction the server
+// rolled back, so repeating them is safe whatever they do.
+type DB struct {
+	db     DBTX
+	policy Policy
+}
+
+func New(db DBTX, policy Policy) *DB {
+	return &DB{db: db, policy: policy}
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := d.retry(ctx, func() (err error) {
+		result, err = d.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	var stmt *sql.Stmt
+	err := d.retry(ctx, func() (err error) {
+		stmt, err = d.db.PrepareContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := d.retry(ctx, func() (err error) {
+		rows, err = d.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext repeats the query while it fails. Its row reports the
+// last error on Scan, as usual.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	d.retry(ctx, func() error {
+		row = d.db.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+func (d *DB) retry(ctx context.Context, run func() error) error {
+	backoff := d.policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= d.policy.Attempts || !Transient(err) {
+			return err
+		}
+
+		slog.Warn("database statement failed, retrying", "attempt", attempt, "in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.policy.MaxBackoff)
+	}
+}
+
+// Transient reports whether err means the statement didn't run and may
+// succeed when repeated: no connection could be made, the server refused it
+// while shutting down or starting up, or it gave up on a serialization
+// failure or deadlock. A connection lost while the statement ran isn't
+// transient, the statement may have been done with only the answer lost.
+func Transient(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", "40P01", "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	// database/sql only sees ErrBadConn when the driver knows nothing was
+	// sent
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
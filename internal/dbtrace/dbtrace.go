@@ -0,0 +1,212 @@
+package dbtrace
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+// Observer is told about every statement run through a wrapped connector
+// and every row read from its results. It is called from whichever
+// goroutine uses the connection, so it must be safe for concurrent use.
+type Observer interface {
+	Statement(query string, args []driver.NamedValue, took time.Duration, err error)
+	Row()
+}
+
+// Counter is an Observer that counts statements and rows
+type Counter struct {
+	queries atomic.Int64
+	rows    atomic.Int64
+}
+
+func (c *Counter) Statement(string, []driver.NamedValue, time.Duration, error) {
+	c.queries.Add(1)
+}
+
+func (c *Counter) Row() {
+	c.rows.Add(1)
+}
+
+// Counts returns how many statements ran and rows were read so far
+func (c *Counter) Counts() (queries, rows int64) {
+	return c.queries.Load(), c.rows.Load()
+}
+
+// Reset starts counting from zero again
+func (c *Counter) Reset() {
+	c.queries.Store(0)
+	c.rows.Store(0)
+}
+
+// WrapConnector returns a connector whose connections report the
+// statements run through them, and the rows read from their results, to o.
+// They otherwise behave exactly like c's.
+func WrapConnector(c driver.Connector, o Observer) driver.Connector {
+	return &connector{Connector: c, o: o}
+}
+
+type connector struct {
+	driver.Connector
+	o Observer
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, o: c.o}, nil
+}
+
+// conn passes everything on to the driver's connection. Optional
+// interfaces the driver doesn't implement answer driver.ErrSkip, or the
+// closest fallback, so database/sql takes the same path it would without
+// the wrapper.
+type conn struct {
+	driver.Conn
+	o Observer
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	c.o.Statement(query, args, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &countedRows{Rows: rows, o: c.o}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	c.o.Statement(query, args, time.Since(start), err)
+	return result, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = p.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query, o: c.o}, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	o     Observer
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	s.o.Statement(s.query, args, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &countedRows{Rows: rows, o: s.o}, nil
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	s.o.Statement(s.query, args, time.Since(start), err)
+	return result, err
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, driver.ErrSkip
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+type countedRows struct {
+	driver.Rows
+	o Observer
+}
+
+func (r *countedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.o.Row()
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package dbtrace
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// LogFile is a log file that is rotated once it grows past a size: the
+// file is renamed to path.1, older copies move up to path.2 and so on,
+// and the oldest is dropped. It isn't created until something is written.
+type LogFile struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewLogFile returns a log file at path that keeps backups old copies of
+// at most maxSize bytes each
+func NewLogFile(path string, maxSize int64, backups int) *LogFile {
+	return &LogFile{path: path, maxSize: maxSize, backups: backups}
+}
+
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return 0, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return 0, err
+		}
+		f.file, f.size = file, info.Size()
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *LogFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	for i := f.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.backups == 0 {
+		return os.Remove(f.path)
+	}
+	return os.Rename(f.path, f.path+".1")
+}
+
+// Close closes the file, if it was opened
+func (f *LogFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
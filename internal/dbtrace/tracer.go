@@ -0,0 +1,108 @@
+package dbtrace
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// secretColumns are the words that make a statement's string arguments
+// secret: a statement mentioning any of them has them all redacted
+var secretColumns = regexp.MustCompile(`(?i)password|token|secret|api_key`)
+
+// queryName picks the sqlc name out of the comment generated queries start
+// with
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// Tracer is an Observer that logs every statement with its arguments and
+// duration while it is enabled. It starts out disabled.
+type Tracer struct {
+	logger  *slog.Logger
+	enabled atomic.Bool
+}
+
+// NewTracer returns a tracer that logs to logger
+func NewTracer(logger *slog.Logger) *Tracer {
+	return &Tracer{logger: logger}
+}
+
+// SetEnabled turns tracing on or off
+func (t *Tracer) SetEnabled(enabled bool) {
+	t.enabled.Store(enabled)
+}
+
+// Toggle flips tracing on or off and returns whether it is now on
+func (t *Tracer) Toggle() bool {
+	for {
+		old := t.enabled.Load()
+		if t.enabled.CompareAndSwap(old, !old) {
+			return !old
+		}
+	}
+}
+
+func (t *Tracer) Statement(query string, args []driver.NamedValue, took time.Duration, err error) {
+	if !t.enabled.Load() {
+		return
+	}
+
+	attrs := []any{"duration", took}
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		attrs = append(attrs, "name", m[1])
+		query = query[strings.IndexByte(query+"\n", '\n'):]
+	}
+	attrs = append(attrs, "query", strings.Join(strings.Fields(query), " "), "args", formatArgs(query, args))
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	t.logger.Info("sql", attrs...)
+}
+
+func (t *Tracer) Row() {}
+
+// formatArgs renders statement arguments for the log, with strings
+// redacted if the statement touches secrets
+func formatArgs(query string, args []driver.NamedValue) string {
+	secret := secretColumns.MatchString(query)
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.Value.(type) {
+		case string:
+			if secret {
+				parts[i] = "[redacted]"
+			} else {
+				parts[i] = fmt.Sprintf("%q", v)
+			}
+		case []byte:
+			if secret {
+				parts[i] = "[redacted]"
+			} else {
+				parts[i] = fmt.Sprintf("%q", v)
+			}
+		case time.Time:
+			parts[i] = v.Format(time.RFC3339Nano)
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// Multi passes everything on to each of its observers
+type Multi []Observer
+
+func (m Multi) Statement(query string, args []driver.NamedValue, took time.Duration, err error) {
+	for _, o := range m {
+		o.Statement(query, args, took, err)
+	}
+}
+
+func (m Multi) Row() {
+	for _, o := range m {
+		o.Row()
+	}
+}
@@ -0,0 +1,137 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Chapter is one section of a book. Body must be well-formed XHTML.
+type Chapter struct {
+	Title string
+	// Subtitle is shown under the chapter title, e.g. the source feed
+	Subtitle string
+	// Section groups consecutive chapters in the table of contents
+	Section string
+	Body    string
+}
+
+// Book is an EPUB 3 book that also carries an NCX table of contents for
+// older readers
+type Book struct {
+	Title    string
+	Author   string
+	Language string
+	Created  time.Time
+	Chapters []Chapter
+}
+
+type chapterFile struct {
+	ID       string
+	Href     string
+	Title    string
+	Subtitle string
+	Body     template.HTML
+	Order    int
+}
+
+type section struct {
+	Title    string
+	Order    int
+	Chapters []chapterFile
+}
+
+type bookData struct {
+	ID       string
+	Title    string
+	Author   string
+	Language string
+	Modified string
+	Chapters []chapterFile
+	Sections []section
+}
+
+// Write writes the book as an EPUB archive
+func (b *Book) Write(w io.Writer) error {
+	data := bookData{
+		ID:       "urn:uuid:" + uuid.New().String(),
+		Title:    b.Title,
+		Author:   b.Author,
+		Language: b.Language,
+		Modified: b.Created.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if data.Language == "" {
+		data.Language = "en"
+	}
+	if b.Created.IsZero() {
+		data.Modified = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	}
+	for i, ch := range b.Chapters {
+		// A section shares the play order of its first chapter, which is
+		// where it points to
+		if i == 0 || ch.Section != b.Chapters[i-1].Section {
+			data.Sections = append(data.Sections, section{Title: ch.Section, Order: i + 1})
+		}
+
+		file := chapterFile{
+			ID:       fmt.Sprintf("chapter%d", i+1),
+			Href:     fmt.Sprintf("chapter%d.xhtml", i+1),
+			Title:    ch.Title,
+			Subtitle: ch.Subtitle,
+			Body:     template.HTML(ch.Body),
+			Order:    i + 1,
+		}
+		data.Chapters = append(data.Chapters, file)
+		last := &data.Sections[len(data.Sections)-1]
+		last.Chapters = append(last.Chapters, file)
+	}
+
+	z := zip.NewWriter(w)
+
+	// The mimetype must be the first entry and stored uncompressed
+	mimetype, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data any
+	}{
+		{"META-INF/container.xml", containerTmpl, nil},
+		{"OEBPS/content.opf", opfTmpl, data},
+		{"OEBPS/nav.xhtml", navTmpl, data},
+		{"OEBPS/toc.ncx", ncxTmpl, data},
+	}
+	for _, ch := range data.Chapters {
+		files = append(files, struct {
+			name string
+			tmpl *template.Template
+			data any
+		}{"OEBPS/" + ch.Href, chapterTmpl, ch})
+	}
+
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, xml.Header); err != nil {
+			return err
+		}
+		if err := f.tmpl.Execute(fw, f.data); err != nil {
+			return fmt.Errorf("couldn't write %s: %w", f.name, err)
+		}
+	}
+
+	return z.Close()
+}
@@ -0,0 +1,110 @@
+package epub
+
+import "html/template"
+
+// The templates leave out the XML declaration, which html/template would
+// escape. Book.Write adds it.
+
+var containerTmpl = template.Must(template.New("container").Parse(`<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var opfTmpl = template.Must(template.New("opf").Parse(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.ID}}</dc:identifier>
+    <dc:title>{{.Title}}</dc:title>
+    <dc:language>{{.Language}}</dc:language>
+    {{- if .Author}}
+    <dc:creator>{{.Author}}</dc:creator>
+    {{- end}}
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    {{- range .Chapters}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+  </manifest>
+  <spine toc="ncx">
+    {{- range .Chapters}}
+    <itemref idref="{{.ID}}"/>
+    {{- end}}
+  </spine>
+</package>
+`))
+
+var navTmpl = template.Must(template.New("nav").Parse(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{.Language}}">
+<head><title>{{.Title}}</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{.Title}}</h1>
+    <ol>
+      {{- range .Sections}}
+      {{- if .Title}}
+      <li><span>{{.Title}}</span>
+        <ol>
+          {{- range .Chapters}}
+          <li><a href="{{.Href}}">{{.Title}}</a></li>
+          {{- end}}
+        </ol>
+      </li>
+      {{- else}}
+      {{- range .Chapters}}
+      <li><a href="{{.Href}}">{{.Title}}</a></li>
+      {{- end}}
+      {{- end}}
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var ncxTmpl = template.Must(template.New("ncx").Parse(`<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="{{.ID}}"/>
+  </head>
+  <docTitle><text>{{.Title}}</text></docTitle>
+  <navMap>
+    {{- range $i, $section := .Sections}}
+    {{- if $section.Title}}
+    <navPoint id="section{{$i}}" playOrder="{{$section.Order}}">
+      <navLabel><text>{{$section.Title}}</text></navLabel>
+      <content src="{{(index $section.Chapters 0).Href}}"/>
+      {{- range $section.Chapters}}
+      <navPoint id="nav-{{.ID}}" playOrder="{{.Order}}">
+        <navLabel><text>{{.Title}}</text></navLabel>
+        <content src="{{.Href}}"/>
+      </navPoint>
+      {{- end}}
+    </navPoint>
+    {{- else}}
+    {{- range $section.Chapters}}
+    <navPoint id="nav-{{.ID}}" playOrder="{{.Order}}">
+      <navLabel><text>{{.Title}}</text></navLabel>
+      <content src="{{.Href}}"/>
+    </navPoint>
+    {{- end}}
+    {{- end}}
+    {{- end}}
+  </navMap>
+</ncx>
+`))
+
+var chapterTmpl = template.Must(template.New("chapter").Parse(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>{{.Title}}</title></head>
+<body>
+  <h1>{{.Title}}</h1>
+  {{- if .Subtitle}}
+  <p><em>{{.Subtitle}}</em></p>
+  {{- end}}
+  {{.Body}}
+</body>
+</html>
+`))
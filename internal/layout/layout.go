@@ -0,0 +1,106 @@
+package layout
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+const ellipsis = "..."
+
+// Width returns the number of terminal columns s occupies, counting wide
+// east-asian characters and emoji as two columns
+func Width(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// Truncate shortens s to at most width columns, appending "..." when it had
+// to cut. It never splits a grapheme cluster.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	// Columns too narrow for any text get as much of the ellipsis as fits
+	if width <= len(ellipsis) {
+		return ellipsis[:max(width, 0)]
+	}
+
+	limit := width - len(ellipsis)
+	var b strings.Builder
+	used := 0
+	state := -1
+	rest := s
+	for len(rest) > 0 {
+		var cluster string
+		var w int
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > limit {
+			break
+		}
+		b.WriteString(cluster)
+		used += w
+	}
+
+	return strings.TrimRight(b.String(), " ") + ellipsis
+}
+
+// PadRight pads s with spaces so it occupies exactly width columns,
+// truncating it first if it's too wide
+func PadRight(s string, width int) string {
+	s = Truncate(s, width)
+	if pad := width - Width(s); pad > 0 {
+		s += strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// Wrap breaks s into lines of at most width columns, at spaces where it
+// can. Existing line breaks are kept and words wider than a line are split.
+func Wrap(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for Width(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				head, tail := splitAt(word, width)
+				lines = append(lines, head)
+				word = tail
+			}
+			switch {
+			case line == "":
+				line = word
+			case Width(line)+1+Width(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitAt cuts s after at most width columns, between grapheme clusters
+func splitAt(s string, width int) (string, string) {
+	used := 0
+	state := -1
+	rest := s
+	for len(rest) > 0 {
+		_, next, w, newState := uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > width && used > 0 {
+			break
+		}
+		used += w
+		rest, state = next, newState
+	}
+	return s[:len(s)-len(rest)], rest
+}
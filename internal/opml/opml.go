@@ -0,0 +1,69 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Feed is a subscription listed in an OPML file
+type Feed struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	// Category is the path of the enclosing folders, e.g. "Tech/Go"
+	Category string
+}
+
+type document struct {
+	Body struct {
+		Outlines []outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr"`
+	XMLURL   string    `xml:"xmlUrl,attr"`
+	HTMLURL  string    `xml:"htmlUrl,attr"`
+	Outlines []outline `xml:"outline"`
+}
+
+// Parse returns every feed in an OPML document, including those nested in
+// folders
+func Parse(r io.Reader) ([]Feed, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var feeds []Feed
+	var collect func(outlines []outline, path []string)
+	collect = func(outlines []outline, path []string) {
+		for _, o := range outlines {
+			title := strings.TrimSpace(o.Title)
+			if title == "" {
+				title = strings.TrimSpace(o.Text)
+			}
+
+			if url := strings.TrimSpace(o.XMLURL); url != "" {
+				if title == "" {
+					title = url
+				}
+				feeds = append(feeds, Feed{
+					Title:    title,
+					XMLURL:   url,
+					HTMLURL:  strings.TrimSpace(o.HTMLURL),
+					Category: strings.Join(path, "/"),
+				})
+			}
+
+			if len(o.Outlines) > 0 {
+				collect(o.Outlines, append(path[:len(path):len(path)], title))
+			}
+		}
+	}
+	collect(doc.Body.Outlines, nil)
+
+	return feeds, nil
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter spaces out requests to the same host by a fixed interval, handing
+// out slots in the order they are asked for
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func New(interval time.Duration) *Limiter {
+	return &Limiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Reserve books the next free slot for host and returns when it starts,
+// which is now if the host hasn't been used within the interval
+func (l *Limiter) Reserve(host string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	slot := now
+	if next, ok := l.next[host]; ok && next.After(now) {
+		slot = next
+	}
+	l.next[host] = slot.Add(l.interval)
+
+	// Forget hosts whose last slot has passed so the map doesn't keep every
+	// host ever seen
+	for h, next := range l.next {
+		if !next.After(now) {
+			delete(l.next, h)
+		}
+	}
+	return slot
+}
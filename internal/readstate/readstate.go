@@ -0,0 +1,138 @@
+package readstate
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Item is the state of one article in another reader, identified by its
+// link
+type Item struct {
+	URL     string
+	Read    bool
+	Starred bool
+}
+
+// miniflux is the answer of Miniflux's GET /v1/entries
+type miniflux struct {
+	Entries []minifluxEntry `json:"entries"`
+}
+
+type minifluxEntry struct {
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+	Starred bool   `json:"starred"`
+}
+
+// greader is a Google Reader stream, the format of FreshRSS's starred.json
+// and per-feed exports
+type greader struct {
+	ID    string        `json:"id"`
+	Items []greaderItem `json:"items"`
+}
+
+type greaderItem struct {
+	Canonical  []greaderLink `json:"canonical"`
+	Alternate  []greaderLink `json:"alternate"`
+	Categories []string      `json:"categories"`
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+}
+
+// Parse reads the article states from a Miniflux entries export, as an
+// object with "entries" or a bare array, or a Google Reader stream such as
+// FreshRSS exports. Articles without a link are left out.
+func Parse(r io.Reader) ([]Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		var entries []minifluxEntry
+		if json.Unmarshal(data, &entries) != nil {
+			return nil, err
+		}
+		return fromMiniflux(entries), nil
+	}
+
+	switch {
+	case probe["entries"] != nil:
+		var doc miniflux
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return fromMiniflux(doc.Entries), nil
+	case probe["items"] != nil:
+		var doc greader
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return fromGReader(doc), nil
+	default:
+		return nil, errors.New(`not a Miniflux or Google Reader export, expected "entries" or "items"`)
+	}
+}
+
+func fromMiniflux(entries []minifluxEntry) []Item {
+	var items []Item
+	for _, e := range entries {
+		if url := strings.TrimSpace(e.URL); url != "" {
+			items = append(items, Item{URL: url, Read: e.Status == "read", Starred: e.Starred})
+		}
+	}
+	return items
+}
+
+func fromGReader(doc greader) []Item {
+	// Everything in the starred stream is starred, whatever its categories
+	allStarred := strings.HasSuffix(doc.ID, "/state/com.google/starred")
+
+	var items []Item
+	for _, it := range doc.Items {
+		url := ""
+		for _, links := range [][]greaderLink{it.Canonical, it.Alternate} {
+			if len(links) > 0 && url == "" {
+				url = strings.TrimSpace(links[0].Href)
+			}
+		}
+		if url == "" {
+			continue
+		}
+
+		item := Item{URL: url, Starred: allStarred}
+		for _, c := range it.Categories {
+			switch {
+			case strings.HasSuffix(c, "/state/com.google/read"):
+				item.Read = true
+			case strings.HasSuffix(c, "/state/com.google/starred"):
+				item.Starred = true
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Merge combines the states of links that appear more than once: read or
+// starred anywhere counts. Items keep the order of first appearance.
+func Merge(items []Item) []Item {
+	index := map[string]int{}
+	var merged []Item
+	for _, item := range items {
+		i, ok := index[item.URL]
+		if !ok {
+			index[item.URL] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+		merged[i].Read = merged[i].Read || item.Read
+		merged[i].Starred = merged[i].Starred || item.Starred
+	}
+	return merged
+}
@@ -0,0 +1,110 @@
+package rss
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ClientOptions controls how the HTTP client used for fetching feeds is built
+type ClientOptions struct {
+	// CABundle is a path to a PEM file with extra certificate authorities
+	// trusted in addition to the system pool
+	CABundle string
+	// InsecureSkipVerify disables TLS certificate verification entirely
+	InsecureSkipVerify bool
+	// PreferIPv4 tries IPv4 addresses before falling back to IPv6
+	PreferIPv4 bool
+	// DNSResolver is a host:port of a DNS server used instead of the system resolver
+	DNSResolver string
+	// HostOverrides maps host names to addresses, like /etc/hosts
+	HostOverrides map[string]string
+	// BlockPrivateNetworks refuses connections to loopback, private,
+	// link-local and other non-public addresses
+	BlockPrivateNetworks bool
+	// AllowedNetworks are CIDR ranges still reachable when
+	// BlockPrivateNetworks is set
+	AllowedNetworks []string
+}
+
+// NewClient returns an HTTP client configured according to opts
+func NewClient(opts ClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA bundle contains no valid certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	var guard *addressGuard
+	if opts.BlockPrivateNetworks {
+		g, err := newAddressGuard(opts.AllowedNetworks)
+		if err != nil {
+			return nil, err
+		}
+		guard = g
+	}
+	transport.DialContext = newDialFunc(opts, guard)
+
+	return &http.Client{Transport: transport}, nil
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func newDialFunc(opts ClientOptions, guard *addressGuard) dialFunc {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if guard != nil {
+		dialer.Control = guard.control
+	}
+
+	if opts.DNSResolver != "" {
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, opts.DNSResolver)
+			},
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if override, ok := opts.HostOverrides[host]; ok {
+			addr = net.JoinHostPort(override, port)
+		}
+
+		if opts.PreferIPv4 && network == "tcp" {
+			if conn, err := dialer.DialContext(ctx, "tcp4", addr); err == nil {
+				return conn, nil
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
@@ -0,0 +1,118 @@
+package rss
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLPageError is returned by FetchFeed when the URL serves a web page
+// instead of a feed, usually because the site moved its feed
+type HTMLPageError struct {
+	// Feeds are the feeds the page advertises, absolute and in page order
+	Feeds []string
+}
+
+func (e *HTMLPageError) Error() string {
+	if len(e.Feeds) == 0 {
+		return "got a web page instead of a feed, feed moved? The page links to no feed"
+	}
+	return fmt.Sprintf("got a web page instead of a feed, feed moved? The page links to %s", strings.Join(e.Feeds, ", "))
+}
+
+// feedLinkTypes are the link types that advertise a feed
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/json":      true,
+}
+
+// isHTMLPage reports whether a response is a web page rather than a feed.
+// The body decides: plenty of servers send feeds as text/html, so the
+// Content-Type is only trusted for XHTML pages, which start like a feed does.
+func isHTMLPage(contentType string, body []byte) bool {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	body = bytes.TrimLeft(body, " \t\r\n")
+	head := bytes.ToLower(body[:min(len(body), 1024)])
+
+	if bytes.HasPrefix(head, []byte("{")) {
+		return false
+	}
+	// A feed may open with a comment, which looks like HTML to the sniffer
+	hasHTMLRoot := bytes.Contains(head, []byte("<html"))
+	for _, root := range []string{"<rss", "<feed", "<rdf:rdf"} {
+		if bytes.Contains(head, []byte(root)) && !hasHTMLRoot {
+			return false
+		}
+	}
+	if bytes.HasPrefix(head, []byte("<?xml")) {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		return err == nil && hasHTMLRoot && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+	}
+	return strings.HasPrefix(http.DetectContentType(body), "text/html")
+}
+
+// DiscoverFeeds returns the feeds an HTML page advertises with
+// <link rel="alternate">, resolved against pageURL
+func DiscoverFeeds(page []byte, pageURL string) []string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil
+	}
+
+	var feeds []string
+	seen := map[string]bool{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+			if href := attr(n, "href"); href != "" {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Link && isFeedLink(n) {
+			if u, err := base.Parse(strings.TrimSpace(attr(n, "href"))); err == nil && !seen[u.String()] {
+				seen[u.String()] = true
+				feeds = append(feeds, u.String())
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return feeds
+}
+
+func isFeedLink(n *html.Node) bool {
+	if attr(n, "href") == "" || !feedLinkTypes[strings.ToLower(strings.TrimSpace(attr(n, "type")))] {
+		return false
+	}
+	for _, rel := range strings.Fields(strings.ToLower(attr(n, "rel"))) {
+		if rel == "alternate" {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// acceptEncoding is sent with every feed request. Setting it ourselves turns
+// off the transport's transparent gzip handling, so decodeBody handles both.
+const acceptEncoding = "gzip, deflate"
+
+// decodeBody undoes the Content-Encoding of a response body
+func decodeBody(body io.Reader, contentEncoding string) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return io.NopCloser(body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but plenty of servers send raw
+		// deflate data; the zlib header tells them apart
+		buffered := bufio.NewReader(body)
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+	}
+}
+
+// newXMLDecoder returns a decoder that reads body as UTF-8. A charset in
+// the Content-Type header wins over the XML declaration, as RFC 7303 asks;
+// without one the declaration decides, and UTF-8 is assumed if there's none.
+func newXMLDecoder(body []byte, contentType string) (*xml.Decoder, error) {
+	label := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		label = params["charset"]
+	}
+
+	if label == "" || isUTF8(label) {
+		decoder := xml.NewDecoder(bytes.NewReader(body))
+		decoder.CharsetReader = charset.NewReaderLabel
+		return decoder, nil
+	}
+
+	r, err := charset.NewReaderLabel(label, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(r)
+	// The body is UTF-8 now, whatever its declaration still says
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder, nil
+}
+
+func isUTF8(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+	return label == "utf-8" || label == "utf8"
+}
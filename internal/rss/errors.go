@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StatusError is returned by FetchFeed when the server responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is how long a 429 or 503 response asked to wait before
+	// the next request, 0 if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("unexpected status code: %d, retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// parseRetryAfter reads a Retry-After header, either a number of seconds or
+// an HTTP date, as a wait from now. Anything else, or a time in the past,
+// is 0.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// ErrorKind is a coarse classification of why a feed fetch failed
+type ErrorKind string
+
+const (
+	ErrorKindDNS         ErrorKind = "dns"
+	ErrorKindTLSExpired  ErrorKind = "tls_expired"
+	ErrorKindTLS         ErrorKind = "tls"
+	ErrorKindConnRefused ErrorKind = "connection_refused"
+	ErrorKindTimeout     ErrorKind = "timeout"
+	ErrorKindHTTP4xx     ErrorKind = "http_4xx"
+	ErrorKindRateLimited ErrorKind = "rate_limited"
+	ErrorKindHTTP5xx     ErrorKind = "http_5xx"
+	ErrorKindParse       ErrorKind = "parse"
+	ErrorKindHTMLPage    ErrorKind = "html_page"
+	ErrorKindTooLarge    ErrorKind = "too_large"
+	ErrorKindBlocked     ErrorKind = "blocked"
+	ErrorKindOther       ErrorKind = "other"
+)
+
+// ClassifyError maps an error returned by FetchFeed to an ErrorKind
+func ClassifyError(err error) ErrorKind {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 {
+			return ErrorKindHTTP5xx
+		}
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			return ErrorKindRateLimited
+		}
+		return ErrorKindHTTP4xx
+	}
+
+	if errors.Is(err, ErrBlockedAddress) {
+		return ErrorKindBlocked
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorKindDNS
+	}
+
+	var certErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) {
+		if certErr.Reason == x509.Expired {
+			return ErrorKindTLSExpired
+		}
+		return ErrorKindTLS
+	}
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &verifyErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) {
+		return ErrorKindTLS
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorKindConnRefused
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+
+	var pageErr *HTMLPageError
+	if errors.As(err, &pageErr) {
+		return ErrorKindHTMLPage
+	}
+
+	if errors.Is(err, ErrBodyTooLarge) {
+		return ErrorKindTooLarge
+	}
+
+	var syntaxErr *xml.SyntaxError
+	var jsonSyntaxErr *json.SyntaxError
+	var jsonTypeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &jsonSyntaxErr) || errors.As(err, &jsonTypeErr) || errors.Is(err, errNotJSONFeed) {
+		return ErrorKindParse
+	}
+
+	return ErrorKindOther
+}
@@ -0,0 +1,159 @@
+package rss
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strings"
+)
+
+// Namespace URIs of the extensions gator knows by prefix
+const (
+	NamespaceDC      = "http://purl.org/dc/elements/1.1/"
+	NamespaceContent = "http://purl.org/rss/1.0/modules/content/"
+	NamespaceMedia   = "http://search.yahoo.com/mrss/"
+	NamespaceITunes  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	NamespaceAtom    = "http://www.w3.org/2005/Atom"
+	NamespaceYouTube = "http://www.youtube.com/xml/schemas/2015"
+
+	namespaceXML = "http://www.w3.org/XML/1998/namespace"
+)
+
+var namespacePrefixes = map[string]string{
+	NamespaceDC:      "dc",
+	NamespaceContent: "content",
+	NamespaceMedia:   "media",
+	NamespaceITunes:  "itunes",
+	NamespaceAtom:    "atom",
+	NamespaceYouTube: "yt",
+}
+
+// Extension is a namespaced element found in an item, such as dc:creator or
+// media:content. Elements in namespaces gator doesn't know are named
+// "{namespace-uri}local".
+type Extension struct {
+	Name     string
+	Value    string
+	Attrs    map[string]string
+	Children []Extension
+}
+
+// Attr returns the value of the attribute with the given local name
+func (e Extension) Attr(name string) string {
+	return e.Attrs[name]
+}
+
+// Extension returns the first extension element called name, e.g. "dc:creator"
+func (item *RSSItem) Extension(name string) (Extension, bool) {
+	if exts := item.Extensions[name]; len(exts) > 0 {
+		return exts[0], true
+	}
+	return Extension{}, false
+}
+
+// rawElement captures an element and everything below it without knowing
+// its schema
+type rawElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Content  string       `xml:",chardata"`
+	Children []rawElement `xml:",any"`
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	if prefix, ok := namespacePrefixes[name.Space]; ok {
+		return prefix + ":" + name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+func (r rawElement) extension() Extension {
+	ext := Extension{
+		Name:  qualifiedName(r.XMLName),
+		Value: strings.TrimSpace(r.Content),
+	}
+	if len(r.Attrs) > 0 {
+		ext.Attrs = make(map[string]string, len(r.Attrs))
+		for _, attr := range r.Attrs {
+			ext.Attrs[attr.Name.Local] = attr.Value
+		}
+	}
+	for _, child := range r.Children {
+		ext.Children = append(ext.Children, child.extension())
+	}
+	return ext
+}
+
+func (item *RSSItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	// plain has RSSItem's fields without this method, avoiding recursion
+	type plain RSSItem
+	var aux struct {
+		plain
+		Other []rawElement `xml:",any"`
+	}
+	if err := d.DecodeElement(&aux, &start); err != nil {
+		return err
+	}
+
+	*item = RSSItem(aux.plain)
+	for _, raw := range aux.Other {
+		// Plain RSS elements gator doesn't use aren't extensions
+		if raw.XMLName.Space == "" {
+			continue
+		}
+		if item.Extensions == nil {
+			item.Extensions = make(map[string][]Extension)
+		}
+		ext := raw.extension()
+		item.Extensions[ext.Name] = append(item.Extensions[ext.Name], ext)
+	}
+
+	// Fall back to the common extensions for fields the item lacks
+	if item.Description == "" {
+		if ext, ok := item.Extension("content:encoded"); ok {
+			item.Description = ext.Value
+		}
+	}
+	if item.PubDate == "" {
+		if ext, ok := item.Extension("dc:date"); ok {
+			item.PubDate = ext.Value
+		}
+	}
+
+	return nil
+}
+
+// resolveReference resolves ref against base, returning ref unchanged if
+// either can't be parsed
+func resolveReference(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// resolveLinks applies xml:base from the document, channel and item in turn,
+// starting from the URL the feed was fetched from
+func (feed *RSSFeed) resolveLinks(documentURL string) {
+	base := resolveReference(documentURL, feed.Base)
+	base = resolveReference(base, feed.Channel.Base)
+	feed.Channel.Link = resolveReference(base, feed.Channel.Link)
+
+	for i := range feed.Channel.Item {
+		item := &feed.Channel.Item[i]
+		if item.Link == "" {
+			continue
+		}
+		item.Link = resolveReference(resolveReference(base, item.Base), item.Link)
+	}
+}
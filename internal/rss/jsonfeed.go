@@ -0,0 +1,111 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+var errNotJSONFeed = errors.New("not a JSON Feed")
+
+// untitledLength is how much of an untitled JSON Feed item's text becomes
+// its title
+const untitledLength = 80
+
+// jsonFeed is the subset of JSON Feed 1.0/1.1 (https://jsonfeed.org) that
+// maps onto RSS
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	ExternalURL   string `json:"external_url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	ContentText   string `json:"content_text"`
+	Summary       string `json:"summary"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+}
+
+// isJSONFeed reports whether a response is a JSON Feed, going by its
+// Content-Type or, for servers that send a generic one, its first byte
+func isJSONFeed(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if mediaType == "application/feed+json" || mediaType == "application/json" {
+			return true
+		}
+	}
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '{'
+}
+
+// parseJSONFeed converts a JSON Feed into the RSS structure the rest of gator
+// works with
+func parseJSONFeed(body []byte) (*RSSFeed, error) {
+	var jf jsonFeed
+	if err := json.Unmarshal(body, &jf); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(jf.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("%w: version %q", errNotJSONFeed, jf.Version)
+	}
+
+	var feed RSSFeed
+	feed.Channel.Title = jf.Title
+	feed.Channel.Link = jf.HomePageURL
+	feed.Channel.Description = jf.Description
+
+	for _, ji := range jf.Items {
+		item := RSSItem{
+			Title:       ji.Title,
+			Link:        ji.URL,
+			Description: ji.ContentHTML,
+			PubDate:     ji.DatePublished,
+		}
+		if item.Link == "" {
+			item.Link = ji.ExternalURL
+		}
+		if item.Description == "" {
+			item.Description = ji.ContentText
+		}
+		if item.Description == "" {
+			item.Description = ji.Summary
+		}
+		if item.PubDate == "" {
+			item.PubDate = ji.DateModified
+		}
+		// Titles are optional in JSON Feed, microblogs often leave them out
+		if item.Title == "" {
+			item.Title = untitled(ji)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed, nil
+}
+
+// untitled makes a title from the start of an item's text
+func untitled(ji jsonFeedItem) string {
+	text := ji.Summary
+	if text == "" {
+		text = ji.ContentText
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= untitledLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:untitledLength])) + "..."
+}
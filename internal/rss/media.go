@@ -0,0 +1,70 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Video holds the Media RSS metadata of a video item. Zero values mean the
+// feed didn't provide the field.
+type Video struct {
+	Duration time.Duration
+	EmbedURL string
+	Views    int64
+}
+
+// mediaExtensions returns the media: elements of the item, including those
+// wrapped in media:group as YouTube and PeerTube do
+func (item *RSSItem) mediaExtensions(name string) []Extension {
+	found := append([]Extension(nil), item.Extensions[name]...)
+	for _, group := range item.Extensions["media:group"] {
+		for _, child := range group.Children {
+			if child.Name == name {
+				found = append(found, child)
+			}
+		}
+	}
+	return found
+}
+
+// Video reads the media: extensions of the item
+func (item *RSSItem) Video() Video {
+	var v Video
+
+	for _, content := range item.mediaExtensions("media:content") {
+		medium := content.Attr("medium")
+		if medium != "" && medium != "video" && !strings.HasPrefix(content.Attr("type"), "video/") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(content.Attr("duration")); err == nil && seconds > 0 {
+			v.Duration = time.Duration(seconds) * time.Second
+			break
+		}
+	}
+
+	for _, player := range item.mediaExtensions("media:player") {
+		if url := player.Attr("url"); url != "" {
+			v.EmbedURL = url
+			break
+		}
+	}
+	if v.EmbedURL == "" {
+		if ext, ok := item.Extension("yt:videoId"); ok && ext.Value != "" {
+			v.EmbedURL = "https://www.youtube.com/embed/" + ext.Value
+		}
+	}
+
+	for _, community := range item.mediaExtensions("media:community") {
+		for _, child := range community.Children {
+			if child.Name != "media:statistics" {
+				continue
+			}
+			if views, err := strconv.ParseInt(child.Attr("views"), 10, 64); err == nil && views >= 0 {
+				v.Views = views
+			}
+		}
+	}
+
+	return v
+}
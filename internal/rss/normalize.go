@@ -0,0 +1,109 @@
+package rss
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// TitleRule is a normalization applied to post titles at ingest
+type TitleRule string
+
+const (
+	// TitleRuleStripPrefix removes a leading feed name such as "The Verge – "
+	TitleRuleStripPrefix TitleRule = "strip-prefix"
+	// TitleRuleCollapseSpace replaces runs of whitespace with a single space
+	TitleRuleCollapseSpace TitleRule = "collapse-space"
+	// TitleRuleDecodeEntities decodes entities left over from double escaping
+	TitleRuleDecodeEntities TitleRule = "decode-entities"
+	// TitleRuleTitleCase converts ALL-CAPS headlines to title case
+	TitleRuleTitleCase TitleRule = "title-case"
+)
+
+var TitleRules = []TitleRule{
+	TitleRuleStripPrefix,
+	TitleRuleCollapseSpace,
+	TitleRuleDecodeEntities,
+	TitleRuleTitleCase,
+}
+
+func ParseTitleRule(s string) (TitleRule, error) {
+	for _, rule := range TitleRules {
+		if string(rule) == s {
+			return rule, nil
+		}
+	}
+	return "", fmt.Errorf("unknown title rule: %s", s)
+}
+
+// NormalizeTitle applies rules to title in a fixed order. prefixes are the
+// names the feed is known by, used for TitleRuleStripPrefix.
+func NormalizeTitle(title string, rules []TitleRule, prefixes ...string) string {
+	enabled := make(map[TitleRule]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+
+	if enabled[TitleRuleDecodeEntities] {
+		title = html.UnescapeString(title)
+	}
+	if enabled[TitleRuleCollapseSpace] {
+		title = strings.Join(strings.Fields(title), " ")
+	}
+	if enabled[TitleRuleStripPrefix] {
+		title = stripPrefix(title, prefixes)
+	}
+	if enabled[TitleRuleTitleCase] && isAllCaps(title) {
+		title = titleCase(title)
+	}
+
+	return strings.TrimSpace(title)
+}
+
+func stripPrefix(title string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" || len(title) <= len(prefix) {
+			continue
+		}
+		if !strings.EqualFold(title[:len(prefix)], prefix) {
+			continue
+		}
+
+		rest := strings.TrimLeft(title[len(prefix):], " \t")
+		trimmed := strings.TrimLeft(rest, "-–—:|·")
+		// Only strip when the name is followed by a separator, so titles
+		// that merely start with the same word are left alone
+		if trimmed == rest {
+			continue
+		}
+		if trimmed = strings.TrimSpace(trimmed); trimmed != "" {
+			return trimmed
+		}
+	}
+	return title
+}
+
+func isAllCaps(s string) bool {
+	hasLetter := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
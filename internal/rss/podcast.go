@@ -0,0 +1,66 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Podcast holds the iTunes episode metadata of an item. Zero values mean the
+// feed didn't provide the field.
+type Podcast struct {
+	Duration    time.Duration
+	Episode     int
+	Season      int
+	Image       string
+	EpisodeType string
+}
+
+// Podcast reads the itunes: extensions of the item
+func (item *RSSItem) Podcast() Podcast {
+	var p Podcast
+	if ext, ok := item.Extension("itunes:duration"); ok {
+		p.Duration = parseDuration(ext.Value)
+	}
+	if ext, ok := item.Extension("itunes:episode"); ok {
+		if n, err := strconv.Atoi(ext.Value); err == nil && n > 0 {
+			p.Episode = n
+		}
+	}
+	if ext, ok := item.Extension("itunes:season"); ok {
+		if n, err := strconv.Atoi(ext.Value); err == nil && n > 0 {
+			p.Season = n
+		}
+	}
+	if ext, ok := item.Extension("itunes:image"); ok {
+		// The image URL is in the href attribute, though some feeds put it in
+		// the element text
+		p.Image = ext.Attr("href")
+		if p.Image == "" {
+			p.Image = ext.Value
+		}
+	}
+	if ext, ok := item.Extension("itunes:episodeType"); ok {
+		p.EpisodeType = strings.ToLower(ext.Value)
+	}
+	return p
+}
+
+// parseDuration accepts the forms itunes:duration uses in the wild: plain
+// seconds, MM:SS and HH:MM:SS
+func parseDuration(s string) time.Duration {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0
+	}
+
+	seconds := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,85 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrBodyTooLarge is returned when a feed is bigger than the policy allows
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// FetchPolicy bounds how long a fetch may take, how often it is retried and
+// how much of the response is read
+type FetchPolicy struct {
+	// Timeout limits each attempt, 0 means no limit
+	Timeout time.Duration
+	// Retries is how many times a failed attempt is repeated when the error
+	// may be temporary: timeouts, dropped connections and 5xx responses
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further one
+	Backoff time.Duration
+	// MaxBodySize is the largest response body read in bytes, 0 means no limit
+	MaxBodySize int64
+}
+
+var DefaultFetchPolicy = FetchPolicy{
+	Timeout:     30 * time.Second,
+	Retries:     2,
+	Backoff:     time.Second,
+	MaxBodySize: 10 << 20,
+}
+
+// FetchFeedWithPolicy fetches and parses the feed at feedURL, retrying
+// temporary failures with exponential backoff
+func FetchFeedWithPolicy(ctx context.Context, client *http.Client, feedURL string, policy FetchPolicy) (*RSSFeed, error) {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		feed, err := fetchOnce(ctx, client, feedURL, policy)
+		if err == nil || attempt >= policy.Retries || !retryable(err) {
+			return feed, err
+		}
+		// A server that asked for a longer break than the backoff gets it
+		// from the next scheduled fetch
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > backoff {
+			return feed, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether err may go away when the fetch is repeated
+func retryable(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorKindTimeout, ErrorKindHTTP5xx, ErrorKindConnRefused:
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// readBody reads r up to limit bytes, failing with ErrBodyTooLarge rather
+// than truncating a bigger body
+func readBody(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, limit)
+	}
+	return body, nil
+}
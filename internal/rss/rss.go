@@ -2,27 +2,55 @@ package rss
 
 import (
 	"context"
-	"encoding/xml"
 	"html"
-	"io"
 	"net/http"
 	"time"
 )
 
 type RSSFeed struct {
+	Base    string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Channel struct {
+		Base        string    `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 		Title       string    `xml:"title"`
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
 	} `xml:"channel"`
+
+	// FinalURL is the URL the feed was served from after following redirects
+	FinalURL string `xml:"-"`
+
+	// StatusCode and Size describe the response the feed was parsed from
+	StatusCode int `xml:"-"`
+	Size       int `xml:"-"`
+
+	// PermanentRedirect is set when FinalURL was reached only through
+	// permanent (301 or 308) redirects, so it can replace the stored URL
+	PermanentRedirect bool `xml:"-"`
+}
+
+// permanentlyRedirected reports whether resp was reached through one or more
+// redirects that were all permanent
+func permanentlyRedirected(resp *http.Response) bool {
+	redirected := false
+	for r := resp.Request.Response; r != nil; r = r.Request.Response {
+		if r.StatusCode != http.StatusMovedPermanently && r.StatusCode != http.StatusPermanentRedirect {
+			return false
+		}
+		redirected = true
+	}
+	return redirected
 }
 
 type RSSItem struct {
+	Base        string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+
+	// Extensions holds namespaced elements keyed by their prefixed name
+	Extensions map[string][]Extension `xml:"-"`
 }
 
 // ParsePubDate tries to parse the pubDate string into a time.Time
@@ -52,7 +80,18 @@ func (item *RSSItem) ParsePubDate() (time.Time, error) {
 	return time.Time{}, nil
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// FetchFeed fetches and parses the feed at feedURL under DefaultFetchPolicy
+func FetchFeed(ctx context.Context, client *http.Client, feedURL string) (*RSSFeed, error) {
+	return FetchFeedWithPolicy(ctx, client, feedURL, DefaultFetchPolicy)
+}
+
+func fetchOnce(ctx context.Context, client *http.Client, feedURL string, policy FetchPolicy) (*RSSFeed, error) {
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
 	// Create a new HTTP request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
@@ -61,28 +100,74 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	// Set User-Agent header
 	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
 
 	// Make the HTTP request
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, statusErr
+	}
+
 	// Read the response body
-	body, err := io.ReadAll(resp.Body)
+	decoded, err := decodeBody(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	defer decoded.Close()
+	body, err := readBody(decoded, policy.MaxBodySize)
 	if err != nil {
 		return nil, err
 	}
 
+	if isHTMLPage(resp.Header.Get("Content-Type"), body) {
+		pageErr := &HTMLPageError{}
+		for _, link := range DiscoverFeeds(body, resp.Request.URL.String()) {
+			if link != feedURL {
+				pageErr.Feeds = append(pageErr.Feeds, link)
+			}
+		}
+		return nil, pageErr
+	}
+
+	if isJSONFeed(resp.Header.Get("Content-Type"), body) {
+		feed, err := parseJSONFeed(body)
+		if err != nil {
+			return nil, err
+		}
+		feed.FinalURL = resp.Request.URL.String()
+		feed.PermanentRedirect = permanentlyRedirected(resp)
+		feed.StatusCode = resp.StatusCode
+		feed.Size = len(body)
+		feed.resolveLinks(feed.FinalURL)
+		return feed, nil
+	}
+
 	// Parse the XML
+	decoder, err := newXMLDecoder(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
 	var feed RSSFeed
-	err = xml.Unmarshal(body, &feed)
+	err = decoder.Decode(&feed)
 	if err != nil {
 		return nil, err
 	}
 
+	feed.FinalURL = resp.Request.URL.String()
+	feed.PermanentRedirect = permanentlyRedirected(resp)
+	feed.StatusCode = resp.StatusCode
+	feed.Size = len(body)
+	feed.resolveLinks(feed.FinalURL)
+
 	// Unescape HTML entities in channel fields
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
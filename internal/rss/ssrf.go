@@ -0,0 +1,83 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a fetch would connect to an address
+// that BlockPrivateNetworks rules out
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// blockedPrefixes are ranges not covered by the netip predicates used in
+// isPublic
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, can reach private IPv4
+}
+
+// isPublic reports whether addr is a globally routable unicast address.
+// Link-local covers the 169.254.169.254 cloud metadata endpoint.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() ||
+		addr.IsLinkLocalUnicast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// addressGuard refuses connections to non-public addresses, except those in
+// allowed. It checks the address actually dialed, after DNS resolution and
+// on every redirect, so neither a hostile DNS record nor a redirect can
+// reach an internal service.
+type addressGuard struct {
+	allowed []netip.Prefix
+}
+
+func newAddressGuard(allowed []string) (*addressGuard, error) {
+	g := &addressGuard{}
+	for _, cidr := range allowed {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed network %q: %w", cidr, err)
+		}
+		g.allowed = append(g.allowed, prefix.Masked())
+	}
+	return g, nil
+}
+
+// control is a net.Dialer Control function
+func (g *addressGuard) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+
+	if isPublic(addr) {
+		return nil
+	}
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+}
@@ -0,0 +1,49 @@
+package rss
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"8.8.8.8", true},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"192.0.0.8", false},
+		{"198.18.0.1", false},
+		{"240.0.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"2001:4860:4860::8888", true},
+		{"fd00::1", false},
+		{"fc00::1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+		{"64:ff9b::a00:1", false},
+		{"::ffff:8.8.8.8", true},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:100.64.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package rss
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTitleLength is the longest title, in characters, accepted at ingest
+const MaxTitleLength = 500
+
+// Validate reports why item isn't fit to be stored as a post, or nil if it is
+func (item *RSSItem) Validate() error {
+	link := strings.TrimSpace(item.Link)
+	if link == "" {
+		return fmt.Errorf("empty link")
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("invalid link: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "":
+		return fmt.Errorf("relative link %q", link)
+	default:
+		return fmt.Errorf("disallowed link scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("link has no host")
+	}
+
+	if n := utf8.RuneCountInString(item.Title); n > MaxTitleLength {
+		return fmt.Errorf("title is %d characters long (limit %d)", n, MaxTitleLength)
+	}
+
+	return nil
+}
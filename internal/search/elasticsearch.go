@@ -0,0 +1,140 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Elasticsearch indexes posts in an Elasticsearch or OpenSearch index,
+// matching with fuzziness so small typos still find posts
+type Elasticsearch struct {
+	c     client
+	index string
+}
+
+func (e *Elasticsearch) path(suffix string) string {
+	return "/" + url.PathEscape(e.index) + suffix
+}
+
+func (e *Elasticsearch) Setup(ctx context.Context) error {
+	err := e.c.doJSON(ctx, "PUT", e.path(""), map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"feed_id":      map[string]string{"type": "keyword"},
+				"feed_name":    map[string]string{"type": "text"},
+				"title":        map[string]string{"type": "text"},
+				"description":  map[string]string{"type": "text"},
+				"url":          map[string]string{"type": "keyword"},
+				"published_at": map[string]string{"type": "date", "format": "epoch_second"},
+			},
+		},
+	}, nil)
+
+	// Creating an index that exists fails with a 400, which is fine here
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(statusErr.Body, "resource_already_exists_exception") {
+		return nil
+	}
+	return err
+}
+
+func (e *Elasticsearch) Index(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]any{"index": map[string]string{"_index": e.index, "_id": doc.ID}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Error *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.c.do(ctx, "POST", "/_bulk", "application/x-ndjson", body.Bytes(), &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		for _, item := range resp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					return fmt.Errorf("couldn't index post: %s", result.Error.Reason)
+				}
+			}
+		}
+		return errors.New("couldn't index some posts")
+	}
+	return nil
+}
+
+func (e *Elasticsearch) Search(ctx context.Context, query string, filter Filter, limit int) ([]string, error) {
+	if len(filter.FeedIDs) == 0 {
+		return nil, nil
+	}
+
+	filters := []any{
+		map[string]any{"terms": map[string]any{"feed_id": filter.FeedIDs}},
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		published := map[string]any{}
+		if !filter.Since.IsZero() {
+			published["gte"] = filter.Since.Unix()
+		}
+		if !filter.Until.IsZero() {
+			published["lt"] = filter.Until.Unix()
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"published_at": published}})
+	}
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	err := e.c.doJSON(ctx, "POST", e.path("/_search"), map[string]any{
+		"size":    limit,
+		"_source": false,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": map[string]any{
+					"multi_match": map[string]any{
+						"query":     query,
+						"fields":    []string{"title^3", "feed_name^2", "description"},
+						"fuzziness": "AUTO",
+					},
+				},
+				"filter": filters,
+			},
+		},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(resp.Hits.Hits))
+	for i, hit := range resp.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Meilisearch indexes posts in a Meilisearch (https://www.meilisearch.com)
+// index, which is typo tolerant out of the box
+type Meilisearch struct {
+	c     client
+	index string
+}
+
+func (m *Meilisearch) path(suffix string) string {
+	return "/indexes/" + url.PathEscape(m.index) + suffix
+}
+
+func (m *Meilisearch) Setup(ctx context.Context) error {
+	// Updating the settings creates the index if it doesn't exist yet
+	return m.c.doJSON(ctx, "PATCH", m.path("/settings"), map[string]any{
+		"searchableAttributes": []string{"title", "feed_name", "description"},
+		"filterableAttributes": []string{"feed_id", "published_at"},
+		"sortableAttributes":   []string{"published_at"},
+	}, nil)
+}
+
+func (m *Meilisearch) Index(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return m.c.doJSON(ctx, "POST", m.path("/documents?primaryKey=id"), docs, nil)
+}
+
+func (m *Meilisearch) Search(ctx context.Context, query string, filter Filter, limit int) ([]string, error) {
+	if len(filter.FeedIDs) == 0 {
+		return nil, nil
+	}
+
+	quoted := make([]string, len(filter.FeedIDs))
+	for i, id := range filter.FeedIDs {
+		b, _ := json.Marshal(id)
+		quoted[i] = string(b)
+	}
+	conditions := []string{"feed_id IN [" + strings.Join(quoted, ", ") + "]"}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("published_at >= %d", filter.Since.Unix()))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("published_at < %d", filter.Until.Unix()))
+	}
+
+	var resp struct {
+		Hits []struct {
+			ID string `json:"id"`
+		} `json:"hits"`
+	}
+	err := m.c.doJSON(ctx, "POST", m.path("/search"), map[string]any{
+		"q":                    query,
+		"limit":                limit,
+		"filter":               strings.Join(conditions, " AND "),
+		"attributesToRetrieve": []string{"id"},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(resp.Hits))
+	for i, hit := range resp.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
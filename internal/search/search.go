@@ -0,0 +1,127 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Document is a post as it is stored in the search index
+type Document struct {
+	ID          string `json:"id"`
+	FeedID      string `json:"feed_id"`
+	FeedName    string `json:"feed_name"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+	// PublishedAt is a Unix timestamp, 0 if the post has no date
+	PublishedAt int64 `json:"published_at,omitempty"`
+}
+
+// Backend is an external full-text index of posts
+type Backend interface {
+	// Setup creates the index and its settings, it is safe to call again
+	Setup(ctx context.Context) error
+	// Index adds or replaces docs
+	Index(ctx context.Context, docs []Document) error
+	// Search returns the IDs of the best matches for query among the posts
+	// that pass filter, best first
+	Search(ctx context.Context, query string, filter Filter, limit int) ([]string, error)
+}
+
+// Filter narrows a search down to posts from FeedIDs published within
+// [Since, Until). A zero Since or Until leaves that end open, posts without
+// a date only match when both are zero.
+type Filter struct {
+	FeedIDs []string
+	Since   time.Time
+	Until   time.Time
+}
+
+// New returns the backend of the given kind, "meilisearch" or
+// "elasticsearch", talking to the server at baseURL
+func New(kind, baseURL, index, apiKey string) (Backend, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s backend needs a url", kind)
+	}
+	if index == "" {
+		index = "gator-posts"
+	}
+	c := client{
+		http:    &http.Client{Timeout: 10 * time.Second},
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+
+	switch kind {
+	case "meilisearch":
+		if apiKey != "" {
+			c.auth = "Bearer " + apiKey
+		}
+		return &Meilisearch{c: c, index: index}, nil
+	case "elasticsearch":
+		if apiKey != "" {
+			c.auth = "ApiKey " + apiKey
+		}
+		return &Elasticsearch{c: c, index: index}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q, use \"meilisearch\" or \"elasticsearch\"", kind)
+	}
+}
+
+type client struct {
+	http    *http.Client
+	baseURL string
+	auth    string
+}
+
+// StatusError is returned when the search server answers with a non-2xx
+// status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// do sends body to path and decodes the JSON response into out, if given
+func (c client) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "gator")
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, "application/json", body, out)
+}
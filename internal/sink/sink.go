@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is the schema_version of every Post record. Consumers of
+// the stream can rely on the fields they know until it goes up; new fields
+// may appear in any record without it.
+const SchemaVersion = 1
+
+// CommandTimeout is how long a command sink may take over a batch before it
+// is killed, so a hung upload can't hold up the fetch that wrote it
+const CommandTimeout = 2 * time.Minute
+
+// Post is one line of the JSONL stream written for every newly ingested post
+type Post struct {
+	SchemaVersion int        `json:"schema_version"`
+	ID            string     `json:"id"`
+	FeedID        string     `json:"feed_id"`
+	FeedName      string     `json:"feed_name"`
+	FeedURL       string     `json:"feed_url"`
+	Title         string     `json:"title"`
+	URL           string     `json:"url"`
+	Description   string     `json:"description,omitempty"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	IngestedAt    time.Time  `json:"ingested_at"`
+}
+
+// Sink receives new posts in batches, one batch per feed fetch
+type Sink interface {
+	Write(posts []Post) error
+}
+
+func encode(posts []Post) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, post := range posts {
+		post.SchemaVersion = SchemaVersion
+		if err := enc.Encode(post); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// File appends posts to a local JSONL file
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Write(posts []Post) error {
+	data, err := encode(posts)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Command runs a program once per batch with the posts as JSONL on its
+// standard input. {batch} in its arguments is replaced by a unique batch ID,
+// for targets such as object storage that need a new name per upload.
+type Command struct {
+	args []string
+}
+
+func NewCommand(args []string) (*Command, error) {
+	if len(args) == 0 {
+		return nil, errors.New("command sink needs a command")
+	}
+	return &Command{args: args}, nil
+}
+
+func (c *Command) Write(posts []Post) error {
+	data, err := encode(posts)
+	if err != nil {
+		return err
+	}
+
+	batch := time.Now().UTC().Format("20060102T150405Z") + "-" + uuid.NewString()
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = strings.ReplaceAll(arg, "{batch}", batch)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	// Children the command started may keep its output open after it's killed
+	cmd.WaitDelay = 5 * time.Second
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s took longer than %s, killed it\n%s", args[0], CommandTimeout, output)
+		}
+		return fmt.Errorf("%s failed: %w\n%s", args[0], err, output)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is bumped whenever a payload field is removed or changes
+// meaning. Adding fields doesn't change it.
+const SchemaVersion = 1
+
+type Event string
+
+const (
+	EventFeedAdded     Event = "feed.added"
+	EventFeedBroken    Event = "feed.broken"
+	EventFeedRecovered Event = "feed.recovered"
+	EventDailySummary  Event = "daily.summary"
+
+	EventFeedRequested      Event = "feed.requested"
+	EventFeedRequestDecided Event = "feed.request_decided"
+)
+
+type Payload struct {
+	SchemaVersion int       `json:"schema_version"`
+	Event         Event     `json:"event"`
+	Timestamp     time.Time `json:"timestamp"`
+	Data          any       `json:"data"`
+}
+
+type FeedData struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	URL                 string `json:"url"`
+	AddedBy             string `json:"added_by,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	LastError           string `json:"last_error,omitempty"`
+}
+
+// FeedRequestData is a user's request for a feed, Status is "pending",
+// "approved" or "denied"
+type FeedRequestData struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason,omitempty"`
+	RequestedBy string `json:"requested_by"`
+	Status      string `json:"status"`
+	DecidedBy   string `json:"decided_by,omitempty"`
+	Note        string `json:"note,omitempty"`
+}
+
+type SummaryData struct {
+	Since          time.Time `json:"since"`
+	Cycles         int64     `json:"cycles"`
+	FeedsAttempted int64     `json:"feeds_attempted"`
+	FeedsSucceeded int64     `json:"feeds_succeeded"`
+	FeedsFailed    int64     `json:"feeds_failed"`
+	NewPosts       int64     `json:"new_posts"`
+}
+
+// Hook is a URL that receives a POST for each of the listed events. An empty
+// Events list subscribes to everything.
+type Hook struct {
+	URL    string
+	Events []Event
+}
+
+func (h Hook) wants(event Event) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// queueSize bounds how many deliveries may wait for the sender, later
+	// ones are dropped rather than holding up the caller
+	queueSize = 256
+
+	// deliveryAttempts is how often a delivery is tried, waiting
+	// retryBackoff before the first retry and twice as long each time after
+	deliveryAttempts = 3
+	retryBackoff     = 2 * time.Second
+)
+
+type delivery struct {
+	url   string
+	event Event
+	body  []byte
+}
+
+// Dispatcher delivers events in the background, so neither a fetch nor a
+// command waits on a slow or dead endpoint
+type Dispatcher struct {
+	hooks   []Hook
+	client  *http.Client
+	onError func(error)
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan delivery
+	done   chan struct{}
+}
+
+// New starts the sender of hooks. onError is told about deliveries that
+// failed every attempt or didn't fit in the queue.
+func New(hooks []Hook, onError func(error)) *Dispatcher {
+	d := &Dispatcher{
+		hooks:   hooks,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		onError: onError,
+		queue:   make(chan delivery, queueSize),
+		done:    make(chan struct{}),
+	}
+	go d.deliver()
+	return d
+}
+
+// Send queues event for every hook subscribed to it
+func (d *Dispatcher) Send(event Event, data any) {
+	body, err := json.Marshal(Payload{
+		SchemaVersion: SchemaVersion,
+		Event:         event,
+		Timestamp:     time.Now().UTC(),
+		Data:          data,
+	})
+	if err != nil {
+		d.onError(fmt.Errorf("%s: %w", event, err))
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, hook := range d.hooks {
+		if !hook.wants(event) {
+			continue
+		}
+		if d.closed {
+			d.onError(fmt.Errorf("%s to %s: dispatcher is closed", event, hook.URL))
+			continue
+		}
+		select {
+		case d.queue <- delivery{url: hook.URL, event: event, body: body}:
+		default:
+			d.onError(fmt.Errorf("%s to %s: queue is full, dropped", event, hook.URL))
+		}
+	}
+}
+
+// Close stops taking events and waits up to timeout for the queued ones to
+// be delivered, so a command's last events aren't lost when it exits
+func (d *Dispatcher) Close(timeout time.Duration) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-time.After(timeout):
+		d.onError(fmt.Errorf("gave up on %d queued deliveries after %s", len(d.queue), timeout))
+	}
+}
+
+// deliver posts queued deliveries one at a time until the queue is closed
+func (d *Dispatcher) deliver() {
+	defer close(d.done)
+	for del := range d.queue {
+		backoff := retryBackoff
+		var err error
+		for attempt := 1; attempt <= deliveryAttempts; attempt++ {
+			if err = d.post(context.Background(), del.url, del.body); err == nil {
+				break
+			}
+			if attempt < deliveryAttempts {
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+		}
+		if err != nil {
+			d.onError(fmt.Errorf("%s to %s: %w", del.event, del.url, err))
+		}
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
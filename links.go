@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+)
+
+const (
+	defaultLinksWindow = 7 * 24 * time.Hour
+	defaultLinksLimit  = 20
+)
+
+// saveLinks stores the outbound links of a post that was just created.
+// description is the one from the feed, so links are kept in headlines-only
+// mode too.
+func saveLinks(s *state, post database.Post, description string) {
+	for i, link := range article.Links(description, post.Url) {
+		err := s.db.CreatePostLink(context.Background(), database.CreatePostLinkParams{
+			PostID:   post.ID,
+			Position: int32(i),
+			Url:      link.URL,
+			Text:     link.Text,
+		})
+		if err != nil {
+			slog.Error("couldn't save link", "url", link.URL, "title", post.Title, "err", err)
+			return
+		}
+	}
+}
+
+// handlerLinks lists the links of one post, or without one the URLs most
+// linked to across the followed feeds
+func handlerLinks(s *state, cmd command, user database.User) error {
+	limit := defaultLinksLimit
+	now := time.Now().UTC()
+	since := now.Add(-defaultLinksWindow)
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--since=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--since="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			since = t
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = l
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	switch len(positional) {
+	case 0:
+		return printMostLinked(s, user, since, limit)
+	case 1:
+	default:
+		return errors.New("usage: gator links [post_url|number] [--since=7d] [--limit=N]")
+	}
+
+	post, err := resolvePost(s, user, positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+	links, err := s.db.GetLinksForPost(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get links: %w", err)
+	}
+	if len(links) == 0 {
+		fmt.Printf("No links found in %s\n", post.Title)
+		return nil
+	}
+
+	fmt.Printf("%d link(s) in %s:\n", len(links), post.Title)
+	for i, link := range links {
+		if link.Text != "" {
+			fmt.Printf("%2d. %s\n    %s\n", i+1, link.Text, link.Url)
+		} else {
+			fmt.Printf("%2d. %s\n", i+1, link.Url)
+		}
+	}
+	return nil
+}
+
+func printMostLinked(s *state, user database.User, since time.Time, limit int) error {
+	links, err := s.db.GetMostLinkedURLs(context.Background(), database.GetMostLinkedURLsParams{
+		UserID:      user.ID,
+		PublishedAt: sql.NullTime{Time: since, Valid: true},
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get links: %w", err)
+	}
+	if len(links) == 0 {
+		fmt.Printf("No links in posts since %s\n", since.Local().Format("Mon, 02 Jan 2006"))
+		return nil
+	}
+
+	fmt.Printf("Most linked since %s:\n", since.Local().Format("Mon, 02 Jan 2006"))
+	for i, link := range links {
+		title := link.Text
+		if title == "" {
+			title = link.Url
+		}
+		fmt.Printf("%2d. %s\n    %s (%d post(s), %d feed(s))\n", i+1, title, link.Url, link.Posts, link.Feeds)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// rememberListing saves the numbering of a post listing so later commands
+// can take a number instead of a URL. first is the number of the first post.
+func rememberListing(s *state, user database.User, first int, postIDs []uuid.UUID) {
+	if s.readOnly {
+		return
+	}
+	ctx := context.Background()
+	if err := s.db.ClearListedPosts(ctx, user.ID); err != nil {
+		fmt.Printf("Couldn't save listing numbers: %v\n", err)
+		return
+	}
+	for i, id := range postIDs {
+		err := s.db.AddListedPost(ctx, database.AddListedPostParams{
+			UserID:   user.ID,
+			Position: int32(first + i),
+			PostID:   id,
+		})
+		if err != nil {
+			fmt.Printf("Couldn't save listing numbers: %v\n", err)
+			return
+		}
+	}
+}
+
+// resolvePost finds a post by its number in the user's last listing or by
+// its URL
+func resolvePost(s *state, user database.User, ref string) (database.Post, error) {
+	ctx := context.Background()
+	if n, err := strconv.Atoi(ref); err == nil {
+		post, err := s.db.GetListedPost(ctx, database.GetListedPostParams{
+			UserID:   user.ID,
+			Position: int32(n),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Post{}, fmt.Errorf("no post #%d in your last listing, run browse, search or bookmarks first", n)
+		}
+		return post, err
+	}
+
+	return s.db.GetPostByURL(ctx, ref)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logOptions are the agg flags that shape its log output
+type logOptions struct {
+	level  string
+	format string
+	file   string
+}
+
+// parseLogFlag picks up --log-level, --log-format and --log-file, and
+// reports whether arg was one of them
+func (o *logOptions) parseLogFlag(arg string) bool {
+	switch {
+	case strings.HasPrefix(arg, "--log-level="):
+		o.level = strings.TrimPrefix(arg, "--log-level=")
+	case strings.HasPrefix(arg, "--log-format="):
+		o.format = strings.TrimPrefix(arg, "--log-format=")
+	case strings.HasPrefix(arg, "--log-file="):
+		o.file = strings.TrimPrefix(arg, "--log-file=")
+	default:
+		return false
+	}
+	return true
+}
+
+// newLogger builds the logger agg reports through: text or JSON lines at
+// the given level, to stdout or appended to a file. The returned closer
+// closes the file, if any.
+func newLogger(opts logOptions) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if opts.level != "" {
+		if err := level.UnmarshalText([]byte(opts.level)); err != nil {
+			return nil, nil, fmt.Errorf("invalid --log-level %q, use debug, info, warn or error", opts.level)
+		}
+	}
+
+	var out io.WriteCloser = nopCloser{os.Stdout}
+	if opts.file != "" {
+		f, err := os.OpenFile(opts.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't open log file: %w", err)
+		}
+		out = f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch opts.format {
+	case "", "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		out.Close()
+		return nil, nil, fmt.Errorf("invalid --log-format %q, use text or json", opts.format)
+	}
+	return slog.New(handler), out, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -6,9 +6,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,14 +22,36 @@ import (
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/breaker"
 	"github.com/olereon/Gator/internal/config"
 	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/dbtrace"
+	"github.com/olereon/Gator/internal/layout"
 	"github.com/olereon/Gator/internal/rss"
+	"github.com/olereon/Gator/internal/search"
+	"github.com/olereon/Gator/internal/sink"
+	"github.com/olereon/Gator/internal/webhook"
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db    *database.Queries
+	cfg   *config.Config
+	hooks *webhook.Dispatcher
+
+	// conn is the connection pool under db
+	conn *sql.DB
+
+	// readOnly refuses commands that change the database and skips
+	// bookkeeping writes such as listing numbers and read marks
+	readOnly bool
+
+	// sqlTrace logs the statements run on db while it is enabled
+	sqlTrace *dbtrace.Tracer
+
+	// userOverride is set when --user or GATOR_USER picked the user, who
+	// must then never be written back to the config
+	userOverride bool
 }
 
 type command struct {
@@ -47,16 +75,56 @@ func (c *commands) run(s *state, cmd command) error {
 	return handler(s, cmd)
 }
 
+// webhookFlushTimeout is how long a command waits on exit for the webhooks
+// it triggered to be delivered
+const webhookFlushTimeout = 30 * time.Second
+
+// notify queues event for the configured webhooks. They are sent in the
+// background, delivery failures are logged.
+func notify(s *state, event webhook.Event, data any) {
+	s.hooks.Send(event, data)
+}
+
+func newDispatcher(cfg *config.Config) *webhook.Dispatcher {
+	hooks := make([]webhook.Hook, 0, len(cfg.Webhooks))
+	for _, wh := range cfg.Webhooks {
+		hook := webhook.Hook{URL: wh.URL}
+		for _, event := range wh.Events {
+			hook.Events = append(hook.Events, webhook.Event(event))
+		}
+		hooks = append(hooks, hook)
+	}
+	return webhook.New(hooks, func(err error) {
+		slog.Error("couldn't send webhook", "err", err)
+	})
+}
+
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
-		user, err := s.db.GetUserByName(context.Background(), s.cfg.CurrentUserName)
+		user, err := loggedInUser(s)
 		if err != nil {
-			return fmt.Errorf("couldn't get user: %w", err)
+			return err
 		}
 		return handler(s, cmd, user)
 	}
 }
 
+// loggedInUser is the current user, for commands that only need one for
+// some of their options
+func loggedInUser(s *state) (database.User, error) {
+	user, err := s.db.GetUserByName(context.Background(), s.cfg.CurrentUserName)
+	if err != nil {
+		return database.User{}, fmt.Errorf("couldn't get user: %w", err)
+	}
+	if user.IsSystem {
+		return database.User{}, errors.New("the system user can't run commands, log in as a regular user")
+	}
+	if err := authenticate(s, user); err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
 func handlerLogin(s *state, cmd command) error {
 	if len(cmd.args) == 0 {
 		return errors.New("username is required")
@@ -65,10 +133,31 @@ func handlerLogin(s *state, cmd command) error {
 	username := cmd.args[0]
 	
 	// Check if user exists in database
-	_, err := s.db.GetUserByName(context.Background(), username)
+	user, err := s.db.GetUserByName(context.Background(), username)
 	if err != nil {
 		return fmt.Errorf("user %s doesn't exist", username)
 	}
+	if user.IsSystem {
+		return fmt.Errorf("%s is the system user and can't log in", username)
+	}
+
+	hash, err := passwordHash(s, user)
+	if err != nil {
+		return err
+	}
+	if hash != "" {
+		if s.readOnly {
+			return fmt.Errorf("login: %w", errReadOnly)
+		}
+		if err := checkPassword(user, hash); err != nil {
+			return err
+		}
+		if err := startSession(s, user); err != nil {
+			return err
+		}
+		fmt.Printf("User has been set to: %s\n", username)
+		return nil
+	}
 
 	// Set current user in config
 	err = s.cfg.SetUser(username)
@@ -81,11 +170,35 @@ func handlerLogin(s *state, cmd command) error {
 }
 
 func handlerRegister(s *state, cmd command) error {
-	if len(cmd.args) == 0 {
+	withPassword := false
+	var positional []string
+	for _, arg := range cmd.args {
+		if arg == "--password" {
+			withPassword = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 {
 		return errors.New("username is required")
 	}
 
-	username := cmd.args[0]
+	username := positional[0]
+	// Names starting with @ are reserved for the system user
+	if strings.HasPrefix(username, "@") {
+		return errors.New("user names can't start with @")
+	}
+
+	// Ask before creating the user, so a mistyped password leaves nothing
+	// behind
+	password := ""
+	if withPassword {
+		var err error
+		password, err = readNewPassword()
+		if err != nil {
+			return err
+		}
+	}
 
 	// Create new user in database
 	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
@@ -102,8 +215,19 @@ func handlerRegister(s *state, cmd command) error {
 		return fmt.Errorf("couldn't create user: %w", err)
 	}
 
-	// Set current user in config
-	err = s.cfg.SetUser(username)
+	if err := followAnnouncements(s, user); err != nil {
+		return err
+	}
+
+	if withPassword {
+		if err := setPassword(s, user, password); err != nil {
+			return err
+		}
+		err = startSession(s, user)
+	} else {
+		// Set current user in config
+		err = s.cfg.SetUser(username)
+	}
 	if err != nil {
 		return fmt.Errorf("couldn't set current user: %w", err)
 	}
@@ -116,8 +240,17 @@ func handlerRegister(s *state, cmd command) error {
 }
 
 func handlerReset(s *state, cmd command) error {
-	// Delete all users from the database
-	err := s.db.DeleteAllUsers(context.Background())
+	// Delete all feeds, including those owned by the system user, and all
+	// users except the system user. The announcements feed is only emptied.
+	err := s.db.DeleteAllFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't reset database: %w", err)
+	}
+	err = s.db.DeleteAnnouncements(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't reset database: %w", err)
+	}
+	err = s.db.DeleteAllUsers(context.Background())
 	if err != nil {
 		return fmt.Errorf("couldn't reset database: %w", err)
 	}
@@ -126,6 +259,27 @@ func handlerReset(s *state, cmd command) error {
 	return nil
 }
 
+// extractValueFlag removes a global --<name>=<value> or --<name> <value>
+// flag from args, wherever it appears, and returns the value
+func extractValueFlag(args []string, flag string) ([]string, string) {
+	value := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, flag+"=") {
+			value = strings.TrimPrefix(arg, flag+"=")
+			continue
+		}
+		if arg == flag && i+1 < len(args) {
+			value = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, value
+}
+
 func handlerUsers(s *state, cmd command) error {
 	// Get all users from the database
 	users, err := s.db.GetUsers(context.Background())
@@ -148,123 +302,473 @@ func handlerUsers(s *state, cmd command) error {
 	return nil
 }
 
-func scrapeFeed(s *state, feed database.Feed, wg *sync.WaitGroup) {
-	defer wg.Done()
+const (
+	hostFailureThreshold = 3
+	hostCooldown         = 10 * time.Minute
+)
 
-	// Mark it as fetched
-	err := s.db.MarkFeedFetched(context.Background(), feed.ID)
+func feedHost(feedURL string) string {
+	u, err := url.Parse(feedURL)
 	if err != nil {
-		fmt.Printf("Error marking feed %s as fetched: %v\n", feed.Name, err)
-		return
+		return feedURL
 	}
+	return u.Hostname()
+}
 
-	// Fetch the feed
-	rssFeed, err := rss.FetchFeed(context.Background(), feed.Url)
+const defaultFeedBrokenThreshold = 5
+
+// feedBrokenThreshold returns how many consecutive failures mark a feed as broken
+func feedBrokenThreshold(cfg *config.Config) int {
+	if cfg.FeedBrokenThreshold > 0 {
+		return cfg.FeedBrokenThreshold
+	}
+	return defaultFeedBrokenThreshold
+}
+
+// isHostFailure reports whether kind indicates the host itself is unhealthy
+// (timeouts or 5xx responses) rather than a problem with a single feed
+func isHostFailure(kind rss.ErrorKind) bool {
+	return kind == rss.ErrorKindTimeout || kind == rss.ErrorKindHTTP5xx
+}
+
+type scraper struct {
+	breaker        *breaker.Breaker
+	client         *http.Client
+	insecureClient *http.Client
+	minInterval    time.Duration
+	maxInterval    time.Duration
+	policy         rss.FetchPolicy
+	sinks          []sink.Sink
+	search         search.Backend
+
+	// fullContent downloads the article of every new post, see agg --full
+	fullContent bool
+	// pages downloads the article pages of new posts
+	pages *pageQueue
+}
+
+func clientOptions(cfg *config.Config) rss.ClientOptions {
+	return rss.ClientOptions{
+		CABundle:      cfg.CABundle,
+		PreferIPv4:    cfg.PreferIPv4,
+		DNSResolver:   cfg.DNSResolver,
+		HostOverrides: cfg.HostOverrides,
+
+		BlockPrivateNetworks: cfg.BlockPrivateNetworks,
+		AllowedNetworks:      cfg.AllowedNetworks,
+	}
+}
+
+func newScraper(cfg *config.Config) (*scraper, error) {
+	opts := clientOptions(cfg)
+	client, err := rss.NewClient(opts)
 	if err != nil {
-		fmt.Printf("Error fetching feed %s: %v\n", feed.Name, err)
-		return
+		return nil, err
+	}
+
+	opts.InsecureSkipVerify = true
+	insecureClient, err := rss.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	minInterval, maxInterval, err := fetchBounds(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := fetchPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks, err := newSinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := newSearchBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scraper{
+		breaker:        breaker.New(hostFailureThreshold, hostCooldown),
+		client:         client,
+		insecureClient: insecureClient,
+		minInterval:    minInterval,
+		maxInterval:    maxInterval,
+		policy:         policy,
+		sinks:          sinks,
+		search:         backend,
+	}, nil
+}
+
+func (sc *scraper) clientFor(feed database.Feed) *http.Client {
+	if feed.InsecureSkipVerify {
+		return sc.insecureClient
+	}
+	return sc.client
+}
+
+// scrapeFeed fetches a single feed and stores its posts, returning how many
+// posts were new
+func scrapeFeed(s *state, sc *scraper, feed database.Feed) (newPosts int, err error) {
+	// Mark it as fetched
+	err = s.db.MarkFeedFetched(context.Background(), feed.ID)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't mark feed as fetched: %w", err)
+	}
+	started := time.Now().UTC()
+	var rssFeed *rss.RSSFeed
+	var fetchErr error
+	defer func() {
+		switch rss.ClassifyError(fetchErr) {
+		case rss.ErrorKindHTMLPage:
+			// A web page won't turn back into a feed soon, check it rarely
+			setNextFetch(s, feed, sc.maxInterval)
+		case rss.ErrorKindRateLimited:
+			setNextFetch(s, feed, rateLimitDelay(sc, fetchErr))
+		default:
+			scheduleNextFetch(s, sc, feed, unavailableDelay(fetchErr))
+		}
+	}()
+	defer func() {
+		logFetch(s, feed, started, rssFeed, newPosts, fetchErr)
+	}()
+
+	// Fetch the feed
+	if feed.InsecureSkipVerify {
+		slog.Warn("fetching without TLS certificate verification", "feed", feed.Name)
+	}
+	host := feedHost(feed.Url)
+	cb := sc.breaker
+	rssFeed, fetchErr = rss.FetchFeedWithPolicy(context.Background(), sc.clientFor(feed), feed.Url, sc.policyFor(feed))
+	if fetchErr != nil {
+		kind := rss.ClassifyError(fetchErr)
+		if kind == rss.ErrorKindRateLimited {
+			// Asking too often isn't the feed failing, so it doesn't count
+			// towards disabling it. The fetch log keeps the event.
+			slog.Warn("feed rate limited, backing off", "feed", feed.Name, "until", time.Now().Add(rateLimitDelay(sc, fetchErr)).Format(time.RFC3339))
+			return 0, fmt.Errorf("couldn't fetch feed: %w", fetchErr)
+		}
+		status := sql.NullInt32{}
+		var statusErr *rss.StatusError
+		if errors.As(fetchErr, &statusErr) {
+			status = sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+		}
+		failures, err := s.db.SetFeedError(context.Background(), database.SetFeedErrorParams{
+			ID:             feed.ID,
+			LastErrorKind:  sql.NullString{String: string(kind), Valid: true},
+			LastError:      sql.NullString{String: fetchErr.Error(), Valid: true},
+			LastHttpStatus: status,
+		})
+		var pageErr *rss.HTMLPageError
+		if err == nil && failures == 1 && errors.As(fetchErr, &pageErr) {
+			warnFeedMoved(s, feed, pageErr, started)
+		}
+		if err != nil {
+			slog.Error("couldn't record feed failure", "feed", feed.Name, "err", err)
+		} else if int(failures) >= feedBrokenThreshold(s.cfg) {
+			// Broken feeds are no longer fetched until someone runs feed enable
+			if err := s.db.DisableFeed(context.Background(), feed.ID); err != nil {
+				slog.Error("couldn't disable feed", "feed", feed.Name, "err", err)
+			} else if err := s.db.ReleaseFeedAliases(context.Background(), uuid.NullUUID{UUID: feed.ID, Valid: true}); err != nil {
+				slog.Error("couldn't release aliases of disabled feed", "feed", feed.Name, "err", err)
+			}
+			if int(failures) == feedBrokenThreshold(s.cfg) {
+				slog.Warn("disabled feed after failed fetches", "feed", feed.Name, "failures", failures)
+				notify(s, webhook.EventFeedBroken, webhook.FeedData{
+					ID:                  feed.ID.String(),
+					Name:                feed.Name,
+					URL:                 feed.Url,
+					ConsecutiveFailures: int(failures),
+					LastError:           fetchErr.Error(),
+				})
+				announce(s, fmt.Sprintf("feed-disabled/%s/%d", feed.ID, started.Unix()),
+					fmt.Sprintf("Feed %s was disabled", feed.Name),
+					fmt.Sprintf("%s failed %d times in a row and won't be fetched until it is enabled again with: gator feed enable %s\nThe last error was: %v",
+						feed.Url, failures, feed.Url, fetchErr))
+			}
+		}
+		if isHostFailure(kind) && cb.RecordFailure(host) {
+			slog.Warn("circuit opened, skipping the host's feeds", "host", host, "until", cb.OpenUntil(host))
+		}
+		return 0, fmt.Errorf("couldn't fetch feed: %w", fetchErr)
+	}
+	cb.RecordSuccess(host)
+	if feed.LastErrorKind.Valid {
+		if err := s.db.ClearFeedError(context.Background(), feed.ID); err != nil {
+			slog.Error("couldn't clear feed failure", "feed", feed.Name, "err", err)
+		} else if int(feed.ConsecutiveFailures) >= feedBrokenThreshold(s.cfg) {
+			notify(s, webhook.EventFeedRecovered, webhook.FeedData{
+				ID:   feed.ID.String(),
+				Name: feed.Name,
+				URL:  feed.Url,
+			})
+		}
+	}
+
+	if rssFeed.PermanentRedirect {
+		feed = followPermanentRedirect(s, feed, rssFeed.FinalURL)
+	}
+	if rssFeed.FinalURL != "" {
+		detectAlias(s, feed, rssFeed.FinalURL)
 	}
 
 	// Save posts to database
-	fmt.Printf("Found %d posts in %s\n", len(rssFeed.Channel.Item), feed.Name)
+	titleRules := feedTitleRules(feed)
+	rules := loadRules(s, feed)
+	headlines := headlinesOnly(s.cfg, feed)
+	// New items are saved in one statement, the descriptions are kept for
+	// the rules and links that look at them after
+	params := database.CreatePostsParams{
+		CreatedAt: time.Now().UTC(),
+		FeedID:    feed.ID,
+	}
+	descriptions := map[string]string{}
 	for _, item := range rssFeed.Channel.Item {
 		// Parse publication date
 		pubDate, _ := item.ParsePubDate()
-		
-		// Create post in database
-		_, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   time.Now().UTC(),
-			UpdatedAt:   time.Now().UTC(),
-			Title:       item.Title,
-			Url:         item.Link,
-			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
-			PublishedAt: sql.NullTime{Time: pubDate, Valid: !pubDate.IsZero()},
-			FeedID:      feed.ID,
+
+		if len(titleRules) > 0 {
+			item.Title = rss.NormalizeTitle(item.Title, titleRules, feed.Name, rssFeed.Channel.Title)
+		}
+
+		if err := item.Validate(); err != nil {
+			quarantineItem(s, feed, item, err)
+			continue
+		}
+		if _, ok := descriptions[item.Link]; ok {
+			// The feed lists the same link twice, the first one wins
+			continue
+		}
+		if knownCanonically(s, feed, item.Link) {
+			// An AMP or mirror link to a story that is already stored
+			continue
+		}
+
+		// Rules still see the description that headlines-only mode drops
+		descriptions[item.Link] = item.Description
+		if headlines {
+			item.Description = ""
+		}
+
+		podcast := item.Podcast()
+		video := item.Video()
+		duration := podcast.Duration
+		if duration == 0 {
+			duration = video.Duration
+		}
+
+		params.Ids = append(params.Ids, uuid.New())
+		params.Titles = append(params.Titles, item.Title)
+		params.Urls = append(params.Urls, item.Link)
+		params.Descriptions = append(params.Descriptions, item.Description)
+		params.PublishedAts = append(params.PublishedAts, pubDate)
+		params.DurationsSeconds = append(params.DurationsSeconds, max(int32(duration.Seconds()), 0))
+		params.Episodes = append(params.Episodes, int32(max(podcast.Episode, 0)))
+		params.Seasons = append(params.Seasons, int32(max(podcast.Season, 0)))
+		params.ImageUrls = append(params.ImageUrls, podcast.Image)
+		params.EpisodeTypes = append(params.EpisodeTypes, podcast.EpisodeType)
+		params.EmbedUrls = append(params.EmbedUrls, video.EmbedURL)
+		params.ViewCounts = append(params.ViewCounts, max(video.Views, 0))
+	}
+
+	// Items already stored are skipped by the database
+	var created []database.Post
+	if len(params.Urls) > 0 {
+		created, err = s.db.CreatePosts(context.Background(), params)
+		if err != nil {
+			// One bad item fails the whole statement, so it mustn't cost
+			// the others
+			slog.Warn("couldn't create posts at once, saving them one by one", "feed", feed.Name, "posts", len(params.Urls), "err", err)
+			created = createPostsOneByOne(s, feed, params)
+		}
+	}
+	// Keep the feed's order, which RETURNING doesn't promise
+	order := map[string]int{}
+	for i, url := range params.Urls {
+		order[url] = i
+	}
+	slices.SortFunc(created, func(a, b database.Post) int {
+		return order[a.Url] - order[b.Url]
+	})
+	for _, post := range created {
+		applyRules(s, rules, post, descriptions[post.Url])
+		saveLinks(s, post, descriptions[post.Url])
+		newPosts++
+	}
+	exportPosts(sc, feed, created)
+	indexPosts(sc, feed, created)
+	if !headlines {
+		fetchContents(sc, feed, created)
+		enrichPosts(sc, feed, created)
+	}
+
+	return newPosts, nil
+}
+
+// createPostsOneByOne saves what CreatePosts failed to, a post at a time,
+// so only the items that fail themselves are lost
+func createPostsOneByOne(s *state, feed database.Feed, params database.CreatePostsParams) []database.Post {
+	var created []database.Post
+	for i := range params.Urls {
+		post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+			ID:              params.Ids[i],
+			CreatedAt:       params.CreatedAt,
+			UpdatedAt:       params.CreatedAt,
+			Title:           params.Titles[i],
+			Url:             params.Urls[i],
+			Description:     sql.NullString{String: params.Descriptions[i], Valid: params.Descriptions[i] != ""},
+			PublishedAt:     sql.NullTime{Time: params.PublishedAts[i], Valid: !params.PublishedAts[i].IsZero()},
+			FeedID:          feed.ID,
+			DurationSeconds: sql.NullInt32{Int32: params.DurationsSeconds[i], Valid: params.DurationsSeconds[i] > 0},
+			Episode:         sql.NullInt32{Int32: params.Episodes[i], Valid: params.Episodes[i] > 0},
+			Season:          sql.NullInt32{Int32: params.Seasons[i], Valid: params.Seasons[i] > 0},
+			ImageUrl:        sql.NullString{String: params.ImageUrls[i], Valid: params.ImageUrls[i] != ""},
+			EpisodeType:     sql.NullString{String: params.EpisodeTypes[i], Valid: params.EpisodeTypes[i] != ""},
+			EmbedUrl:        sql.NullString{String: params.EmbedUrls[i], Valid: params.EmbedUrls[i] != ""},
+			ViewCount:       sql.NullInt64{Int64: params.ViewCounts[i], Valid: params.ViewCounts[i] > 0},
 		})
 		if err != nil {
 			// Ignore duplicate URL errors
 			if err.Error() != `pq: duplicate key value violates unique constraint "posts_url_key"` {
-				fmt.Printf("Error creating post %s: %v\n", item.Title, err)
+				slog.Error("couldn't create post", "feed", feed.Name, "title", params.Titles[i], "err", err)
 			}
+			continue
 		}
+		created = append(created, post)
 	}
+	return created
+}
+
+type cycleSummary struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	attempted int
+	succeeded int
+	failed    int
+	skipped   int
+	newPosts  int
 }
 
-func scrapeFeeds(s *state, concurrency int) {
-	// Get multiple feeds to fetch
-	feeds, err := s.db.GetNextFeedsToFetch(context.Background(), int32(concurrency))
+func (c *cycleSummary) record(newPosts int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempted++
 	if err != nil {
-		fmt.Printf("Error getting feeds: %v\n", err)
+		c.failed++
 		return
 	}
+	c.succeeded++
+	c.newPosts += newPosts
+}
 
-	if len(feeds) == 0 {
-		fmt.Println("No feeds to fetch")
-		return
-	}
+func (c *cycleSummary) skip() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	fmt.Printf("Fetching %d feeds concurrently\n", len(feeds))
+	c.skipped++
+}
 
-	var wg sync.WaitGroup
-	for _, feed := range feeds {
-		wg.Add(1)
-		go scrapeFeed(s, feed, &wg)
+func sendDailySummary(s *state, since time.Time) {
+	totals, err := s.db.GetAggCycleTotals(context.Background(), since)
+	if err != nil {
+		slog.Error("couldn't get cycle totals", "err", err)
+		return
 	}
-	wg.Wait()
+
+	notify(s, webhook.EventDailySummary, webhook.SummaryData{
+		Since:          since,
+		Cycles:         totals.Cycles,
+		FeedsAttempted: totals.FeedsAttempted,
+		FeedsSucceeded: totals.FeedsSucceeded,
+		FeedsFailed:    totals.FeedsFailed,
+		NewPosts:       totals.NewPosts,
+	})
 }
 
-func handlerAgg(s *state, cmd command) error {
-	if len(cmd.args) == 0 {
-		return errors.New("time_between_reqs is required")
+func handlerAddFeed(s *state, cmd command, user database.User) error {
+	insecureSkipVerify := false
+	skipConfirm := false
+	systemOwned := false
+	var positional []string
+	for _, arg := range cmd.args {
+		switch arg {
+		case "--insecure-skip-verify":
+			insecureSkipVerify = true
+		case "--yes", "-y":
+			skipConfirm = true
+		case "--system":
+			systemOwned = true
+		default:
+			positional = append(positional, arg)
+		}
 	}
 
-	timeBetweenRequests, err := time.ParseDuration(cmd.args[0])
-	if err != nil {
-		return fmt.Errorf("invalid duration: %w", err)
+	if len(positional) < 2 {
+		return errors.New("name and url are required")
 	}
 
-	// Default concurrency
-	concurrency := 5
+	name := positional[0]
+	url := positional[1]
 
-	// Parse optional concurrency argument
-	if len(cmd.args) > 1 {
-		if c, err := strconv.Atoi(cmd.args[1]); err == nil && c > 0 {
-			concurrency = c
-		} else {
-			return fmt.Errorf("invalid concurrency value: %s", cmd.args[1])
-		}
+	// No user can remove a system feed, so only admins may add one
+	if systemOwned && !isAdmin(s, user) {
+		return errors.New("only admins can add feeds with --system")
 	}
 
-	fmt.Printf("Collecting feeds every %s with concurrency %d\n", timeBetweenRequests, concurrency)
-
-	ticker := time.NewTicker(timeBetweenRequests)
-	for ; ; <-ticker.C {
-		scrapeFeeds(s, concurrency)
+	if err := checkFeedPolicy(s.cfg, url); err != nil {
+		return err
 	}
-}
 
-func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.args) < 2 {
-		return errors.New("name and url are required")
+	if !skipConfirm {
+		previewFeed(s.cfg, url, insecureSkipVerify)
+
+		ok, err := confirm("Add and follow this feed?")
+		if errors.Is(err, errNoAnswer) {
+			return fmt.Errorf("%w, pass --yes to add the feed without asking", err)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Feed not added.")
+			return nil
+		}
 	}
 
-	name := cmd.args[0]
-	url := cmd.args[1]
+	owner := user
+	if systemOwned {
+		systemUser, err := s.db.GetSystemUser(context.Background())
+		if err != nil {
+			return fmt.Errorf("couldn't get system user: %w", err)
+		}
+		owner = systemUser
+	}
 
 	// Create the feed
 	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      name,
-		Url:       url,
-		UserID:    user.ID,
+		ID:                 uuid.New(),
+		CreatedAt:          time.Now().UTC(),
+		UpdatedAt:          time.Now().UTC(),
+		Name:               name,
+		Url:                url,
+		UserID:             owner.ID,
+		InsecureSkipVerify: insecureSkipVerify,
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't create feed: %w", err)
 	}
 
+	if insecureSkipVerify {
+		fmt.Println("WARNING: TLS certificate verification is DISABLED for this feed.")
+		fmt.Println("WARNING: Anyone on the network path can impersonate the server and inject content.")
+		fmt.Println("WARNING: Prefer adding your internal CA to ca_bundle in ~/.gatorconfig.json instead.")
+	}
+
 	// Automatically follow the feed
 	feedFollow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID:        uuid.New(),
@@ -280,21 +784,234 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 	fmt.Printf("Feed %s created successfully!\n", feed.Name)
 	fmt.Printf("%s is now following %s\n", feedFollow.UserName, feedFollow.FeedName)
 
+	notify(s, webhook.EventFeedAdded, webhook.FeedData{
+		ID:      feed.ID.String(),
+		Name:    feed.Name,
+		URL:     feed.Url,
+		AddedBy: user.Name,
+	})
+
 	return nil
 }
 
+// previewFeed fetches the feed at feedURL and prints its newest item titles
+// so the user can check it's the feed they meant to add
+func previewFeed(cfg *config.Config, feedURL string, insecureSkipVerify bool) {
+	opts := clientOptions(cfg)
+	opts.InsecureSkipVerify = insecureSkipVerify
+	client, err := rss.NewClient(opts)
+	if err != nil {
+		fmt.Printf("Couldn't preview feed: %v\n", err)
+		return
+	}
+
+	policy, err := fetchPolicy(cfg)
+	if err != nil {
+		fmt.Printf("Couldn't preview feed: %v\n", err)
+		return
+	}
+
+	rssFeed, err := rss.FetchFeedWithPolicy(context.Background(), client, feedURL, policy)
+	if err != nil {
+		fmt.Printf("Couldn't preview feed: %v\n", err)
+		return
+	}
+
+	items := rssFeed.Channel.Item
+	sort.SliceStable(items, func(i, j int) bool {
+		a, _ := items[i].ParsePubDate()
+		b, _ := items[j].ParsePubDate()
+		return a.After(b)
+	})
+
+	fmt.Printf("%s\n", rssFeed.Channel.Title)
+	if len(items) == 0 {
+		fmt.Println("  (no items)")
+		return
+	}
+	for i, item := range items {
+		if i == 3 {
+			break
+		}
+		fmt.Printf("  - %s\n", item.Title)
+	}
+}
+
+// errNoAnswer is returned by confirm when stdin ends before an answer, as
+// it does in scripts and cron jobs
+var errNoAnswer = errors.New("no answer, stdin is closed")
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if errors.Is(err, io.EOF) && strings.TrimSpace(answer) == "" {
+		fmt.Println()
+		return false, errNoAnswer
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("error reading input: %w", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
 func handlerFeeds(s *state, cmd command) error {
+	brokenOnly := false
+	category := ""
+	for _, arg := range cmd.args {
+		if arg == "--broken" {
+			brokenOnly = true
+		} else if strings.HasPrefix(arg, "--category=") {
+			category = strings.TrimPrefix(arg, "--category=")
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
 	// Get all feeds with user information
 	feeds, err := s.db.GetFeedsWithUsers(context.Background())
 	if err != nil {
 		return fmt.Errorf("couldn't get feeds: %w", err)
 	}
 
+	// Categories are the current user's own
+	inCategory := map[uuid.UUID]bool{}
+	if category != "" {
+		user, err := loggedInUser(s)
+		if err != nil {
+			return err
+		}
+		follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't get feed follows: %w", err)
+		}
+		for _, ff := range follows {
+			if strings.EqualFold(ff.Category.String, category) {
+				inCategory[ff.FeedID] = true
+			}
+		}
+	}
+
 	// Print all feeds
+	shown := 0
 	for _, feed := range feeds {
+		if brokenOnly && !feed.DisabledAt.Valid {
+			continue
+		}
+		if category != "" && !inCategory[feed.ID] {
+			continue
+		}
+		shown++
+
 		fmt.Printf("* %s\n", feed.FeedName)
 		fmt.Printf("  URL: %s\n", feed.FeedUrl)
 		fmt.Printf("  Created by: %s\n", feed.UserName)
+		if feed.HeadlinesOnly.Valid {
+			if feed.HeadlinesOnly.Bool {
+				fmt.Println("  Headlines only: on")
+			} else {
+				fmt.Println("  Headlines only: off")
+			}
+		}
+		if feed.DisabledAt.Valid {
+			fmt.Printf("  BROKEN since %s after %d failed fetches, not fetched until enabled\n",
+				feed.DisabledAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST"), feed.ConsecutiveFailures)
+			if feed.LastHttpStatus.Valid {
+				fmt.Printf("  Last HTTP status: %d\n", feed.LastHttpStatus.Int32)
+			}
+			if feed.LastError.Valid {
+				fmt.Printf("  Last error: %s\n", feed.LastError.String)
+			}
+		}
+		if feed.CanonicalFeedName != "" {
+			fmt.Printf("  Alias of: %s (not fetched separately)\n", feed.CanonicalFeedName)
+		}
+		if len(feed.TitleRules) > 0 {
+			fmt.Printf("  Title rules: %s\n", strings.Join(feed.TitleRules, ", "))
+		}
+		if feed.FetchIntervalSeconds.Valid {
+			fmt.Printf("  Fetch interval: %s\n", time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second)
+		}
+		if feed.FetchTimeoutSeconds.Valid || feed.FetchRetries.Valid || feed.MaxBodyBytes.Valid {
+			fmt.Printf("  Fetch policy: %s\n", describeFetchPolicy(feed.FetchTimeoutSeconds, feed.FetchRetries, feed.MaxBodyBytes))
+		}
+		if feed.InsecureSkipVerify {
+			fmt.Println("  WARNING: TLS certificate verification disabled")
+		}
+		fmt.Println()
+	}
+
+	if brokenOnly && shown == 0 {
+		fmt.Println("No feeds are broken.")
+	} else if category != "" && shown == 0 {
+		fmt.Printf("You have no feeds in category %s.\n", category)
+	}
+	return nil
+}
+
+// feedErrorAdvice returns a suggestion for fixing a feed that fails with kind
+func feedErrorAdvice(kind rss.ErrorKind) string {
+	switch kind {
+	case rss.ErrorKindDNS:
+		return "The host name doesn't resolve. Check the URL for typos or whether the site has moved."
+	case rss.ErrorKindTLSExpired:
+		return "The site's TLS certificate has expired. Wait for the owner to renew it or contact them."
+	case rss.ErrorKindTLS:
+		return "The TLS certificate couldn't be verified. The site may use a self-signed or internal CA."
+	case rss.ErrorKindConnRefused:
+		return "The server refused the connection. The site may be down or the port may be wrong."
+	case rss.ErrorKindTimeout:
+		return "The server didn't respond in time. It may be overloaded or unreachable from this network."
+	case rss.ErrorKindHTTP4xx:
+		return "The server rejected the request. The feed may have moved or been removed; check the URL."
+	case rss.ErrorKindRateLimited:
+		return "The server asked gator to slow down (429). agg waits as long as its Retry-After says, or an hour, before fetching the feed again."
+	case rss.ErrorKindHTTP5xx:
+		return "The server is failing. This is usually temporary; if it persists contact the site owner."
+	case rss.ErrorKindParse:
+		return "The response isn't a valid feed. The URL may point to a web page instead of the feed."
+	case rss.ErrorKindHTMLPage:
+		return "The URL serves a web page, so the feed has probably moved. Add the feed the page links to with gator addfeed, then gator feed remove this one."
+	case rss.ErrorKindBlocked:
+		return "The feed's host resolves to a private or internal address, which block_private_networks refuses. Add the range to allowed_networks if the feed is trusted."
+	case rss.ErrorKindTooLarge:
+		return "The feed is bigger than max_feed_size. Raise the limit for it with gator feed set-fetch-policy --max-size."
+	default:
+		return "Check the error message above and try fetching the URL manually."
+	}
+}
+
+func handlerFeedErrors(s *state, cmd command) error {
+	feeds, err := s.db.GetFeedsWithErrors(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds: %w", err)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println("No feeds are failing.")
+		return nil
+	}
+
+	for _, feed := range feeds {
+		kind := rss.ErrorKind(feed.LastErrorKind.String)
+		fmt.Printf("* %s\n", feed.Name)
+		fmt.Printf("  URL: %s\n", feed.Url)
+		fmt.Printf("  Error (%s): %s\n", kind, feed.LastError.String)
+		if feed.LastHttpStatus.Valid {
+			fmt.Printf("  HTTP status: %d\n", feed.LastHttpStatus.Int32)
+		}
+		fmt.Printf("  Consecutive failures: %d\n", feed.ConsecutiveFailures)
+		if feed.DisabledAt.Valid {
+			fmt.Printf("  Disabled: %s (run gator feed enable %s once it's fixed)\n",
+				feed.DisabledAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST"), feed.Url)
+		}
+		if feed.LastErrorAt.Valid {
+			fmt.Printf("  Last failed: %s\n", feed.LastErrorAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST"))
+		}
+		fmt.Printf("  Advice: %s\n", feedErrorAdvice(kind))
 		fmt.Println()
 	}
 
@@ -313,6 +1030,11 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 	if err != nil {
 		return fmt.Errorf("couldn't find feed: %w", err)
 	}
+	if feed.ID != announcementsFeedID {
+		if err := checkFeedPolicy(s.cfg, feed.Url); err != nil {
+			return err
+		}
+	}
 
 	// Create feed follow
 	feedFollow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
@@ -344,7 +1066,11 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 	// Print followed feeds
 	fmt.Printf("Feeds followed by %s:\n", user.Name)
 	for _, ff := range feedFollows {
-		fmt.Printf("* %s\n", ff.FeedName)
+		if ff.Category.Valid {
+			fmt.Printf("* %s (%s)\n", ff.FeedName, ff.Category.String)
+		} else {
+			fmt.Printf("* %s\n", ff.FeedName)
+		}
 	}
 
 	return nil
@@ -359,14 +1085,15 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 
 	// Delete feed follow
 	err := s.db.DeleteFeedFollow(context.Background(), database.DeleteFeedFollowParams{
-		UserID: user.ID,
-		Url:    url,
+		UserID:    user.ID,
+		Url:       url,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't unfollow feed: %w", err)
 	}
 
-	fmt.Printf("%s unfollowed %s\n", user.Name, url)
+	fmt.Printf("%s unfollowed %s (use 'gator undo' to restore it)\n", user.Name, url)
 
 	return nil
 }
@@ -377,6 +1104,14 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	offset := int32(0)
 	sortBy := "published_desc"
 	feedFilter := ""
+	excludeFilter := ""
+	exactFeeds := false
+	unreadOnly := false
+	tag := ""
+	category := ""
+	markShown := false
+	fullText := false
+	var minDuration, maxDuration time.Duration
 
 	// Parse arguments
 	for i, arg := range cmd.args {
@@ -392,13 +1127,48 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 			sortBy = strings.TrimPrefix(arg, "--sort=")
 		} else if strings.HasPrefix(arg, "--feed=") {
 			feedFilter = strings.TrimPrefix(arg, "--feed=")
+		} else if strings.HasPrefix(arg, "--exclude-feed=") {
+			excludeFilter = strings.TrimPrefix(arg, "--exclude-feed=")
+		} else if arg == "--exact" {
+			exactFeeds = true
+		} else if arg == "--unread" {
+			unreadOnly = true
+		} else if arg == "--mark-read" {
+			markShown = true
+		} else if arg == "--full" {
+			fullText = true
+		} else if strings.HasPrefix(arg, "--category=") {
+			category = strings.TrimSpace(strings.TrimPrefix(arg, "--category="))
+		} else if strings.HasPrefix(arg, "--tag=") {
+			tag = normalizeTag(strings.TrimPrefix(arg, "--tag="))
+		} else if strings.HasPrefix(arg, "--min-duration=") {
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--min-duration="))
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid --min-duration: %s", arg)
+			}
+			minDuration = d
+		} else if strings.HasPrefix(arg, "--max-duration=") {
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--max-duration="))
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid --max-duration: %s", arg)
+			}
+			maxDuration = d
 		} else if arg == "--help" {
 			fmt.Println("Usage: gator browse [options]")
 			fmt.Println("Options:")
 			fmt.Println("  --limit=N        Number of posts to show (default: 10)")
 			fmt.Println("  --offset=N       Number of posts to skip (default: 0)")
-			fmt.Println("  --sort=OPTION    Sort by: published_desc, published, title, title_desc, feed, feed_desc (default: published_desc)")
-			fmt.Println("  --feed=NAME      Filter by feed name (partial match)")
+			fmt.Println("  --sort=OPTION    Sort by: published_desc, published, title, title_desc, feed, feed_desc, episode, episode_desc, duration, duration_desc (default: published_desc)")
+			fmt.Println("  --feed=NAMES     Only show these feeds, comma-separated (partial match)")
+			fmt.Println("  --exclude-feed=NAMES  Hide these feeds, comma-separated (partial match)")
+			fmt.Println("  --exact          Match --feed and --exclude-feed names exactly (ignoring case)")
+			fmt.Println("  --unread         Only show posts you haven't read")
+			fmt.Println("  --category=NAME  Only show feeds in this category")
+			fmt.Println("  --tag=TAG        Only show posts you tagged with TAG")
+			fmt.Println("  --mark-read      Mark the posts shown as read")
+			fmt.Println("  --full           Show the whole stored article (see fetch-content) instead of a summary")
+			fmt.Println("  --min-duration=D Only show videos and episodes at least this long (e.g. 10m)")
+			fmt.Println("  --max-duration=D Only show videos and episodes at most this long (e.g. 1h)")
 			fmt.Println("  --help           Show this help")
 			return nil
 		} else if i == 0 {
@@ -409,22 +1179,33 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		}
 	}
 
+	if markShown && s.readOnly {
+		return fmt.Errorf("browse --mark-read: %w", errReadOnly)
+	}
+
 	// Validate sort option
 	validSorts := map[string]bool{
 		"published_desc": true, "published": true, "title": true,
 		"title_desc": true, "feed": true, "feed_desc": true,
+		"episode": true, "episode_desc": true, "duration": true, "duration_desc": true,
 	}
 	if !validSorts[sortBy] {
-		return fmt.Errorf("invalid sort option: %s. Valid options: published_desc, published, title, title_desc, feed, feed_desc", sortBy)
+		return fmt.Errorf("invalid sort option: %s. Valid options: published_desc, published, title, title_desc, feed, feed_desc, episode, episode_desc, duration, duration_desc", sortBy)
 	}
 
 	// Get posts for user with pagination
 	posts, err := s.db.GetPostsForUserWithPagination(context.Background(), database.GetPostsForUserWithPaginationParams{
-		UserID:  user.ID,
-		Column2: feedFilter,
-		Column3: sortBy,
-		Limit:   limit,
-		Offset:  offset,
+		UserID:   user.ID,
+		Column2:  feedPatterns(feedFilter, exactFeeds),
+		Column3:  sortBy,
+		Limit:    limit,
+		Offset:   offset,
+		Column6:  int32(minDuration.Seconds()),
+		Column7:  int32(maxDuration.Seconds()),
+		Column8:  feedPatterns(excludeFilter, exactFeeds),
+		Column9:  unreadOnly,
+		Column10: tag,
+		Column11: category,
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't get posts: %w", err)
@@ -440,50 +1221,228 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	if feedFilter != "" {
 		fmt.Printf(", filtered by feed: %s", feedFilter)
 	}
+	if excludeFilter != "" {
+		fmt.Printf(", excluding feed: %s", excludeFilter)
+	}
+	if unreadOnly {
+		fmt.Print(", unread only")
+	}
+	if category != "" {
+		fmt.Printf(", in category %s", category)
+	}
+	if tag != "" {
+		fmt.Printf(", tagged %s", tag)
+	}
+	if minDuration > 0 {
+		fmt.Printf(", at least %s", minDuration)
+	}
+	if maxDuration > 0 {
+		fmt.Printf(", at most %s", maxDuration)
+	}
 	fmt.Println(")")
 	fmt.Println()
 
+	postIDs := make([]uuid.UUID, len(posts))
 	for i, post := range posts {
-		fmt.Printf("%d. %s\n", int(offset)+i+1, post.Title)
-		if post.Description.Valid && post.Description.String != "" {
-			description := post.Description.String
-			if len(description) > 150 {
-				description = description[:147] + "..."
+		postIDs[i] = post.ID
+	}
+	rememberListing(s, user, int(offset)+1, postIDs)
+
+	for i, post := range posts {
+		title, description, image := previewed(s, post.ID, post.Title, post.Description)
+		fmt.Printf("%d. %s%s\n", int(offset)+i+1, unreadMarker(post.IsRead), title)
+		if fullText {
+			if text, _ := postText(s, post.ID, post.Url, description); text != "" {
+				fmt.Printf("\n%s\n\n", text)
 			}
-			fmt.Printf("   %s\n", description)
+		} else if description.Valid && description.String != "" {
+			printSummary(description.String, post.Url, 150, "   ")
 		}
 		fmt.Printf("   Link: %s\n", post.Url)
+		if image != "" {
+			fmt.Printf("   Image: %s\n", image)
+		}
 		fmt.Printf("   Feed: %s\n", post.FeedName)
+		printMediaInfo(post.Season, post.Episode, post.EpisodeType, post.DurationSeconds, post.ViewCount)
 		if post.PublishedAt.Valid {
 			fmt.Printf("   Published: %s\n", post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST"))
 		}
+		if len(post.Tags) > 0 {
+			fmt.Printf("   Tags: %s\n", strings.Join(post.Tags, ", "))
+		}
 		fmt.Println()
 	}
 
-	// Show pagination info
+	if markShown {
+		// One statement, so the page is either cleared as a whole or not at all
+		marked, err := s.db.MarkPostIDsRead(context.Background(), database.MarkPostIDsReadParams{
+			UserID:  user.ID,
+			ReadAt:  time.Now().UTC(),
+			Column3: postIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts as read: %w", err)
+		}
+		fmt.Printf("Marked %d post(s) as read\n", marked)
+	}
+
+	// Show pagination info. Marked posts drop out of an --unread listing, so
+	// the next page starts at the same offset.
 	if len(posts) == int(limit) {
-		fmt.Printf("To see more posts, use: gator browse --offset=%d\n", offset+limit)
+		next := offset + limit
+		if markShown && unreadOnly {
+			next = offset
+		}
+		fmt.Printf("To see more posts, use: gator browse --offset=%d\n", next)
 	}
 
 	return nil
 }
 
+// printSummary prints an HTML description as one line of text of at most
+// width columns, followed by the links that are left in it
+func printSummary(description, postURL string, width int, indent string) {
+	rendered := article.Render(description, postURL)
+	summary := layout.Truncate(strings.Join(strings.Split(rendered.Text, "\n"), " "), width)
+	if summary == "" {
+		return
+	}
+	fmt.Printf("%s%s\n", indent, summary)
+	for _, note := range rendered.Footnotes(summary) {
+		fmt.Printf("%s%s\n", indent, note)
+	}
+}
+
+// unreadMarker flags unread posts in listings
+func unreadMarker(isRead bool) string {
+	if isRead {
+		return ""
+	}
+	return "* "
+}
+
+// likeEscaper makes text match itself literally in an ILIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// feedPatterns turns a comma-separated list of feed names into ILIKE
+// patterns. The result is never nil, as a NULL array matches nothing.
+func feedPatterns(names string, exact bool) []string {
+	patterns := []string{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		pattern := likeEscaper.Replace(name)
+		if !exact {
+			pattern = "%" + pattern + "%"
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// postFilter narrows a search down by feed, publication date and read
+// state. Feeds holds ILIKE patterns from feedPatterns, empty for all feeds.
+type postFilter struct {
+	Feeds  []string
+	Since  sql.NullTime
+	Until  sql.NullTime
+	Unread bool
+}
+
+// parsePostTime reads the value of --since or --until: a date such as
+// 2024-06-01, or an age such as 7d, 2w or 36h counted back from now
+func parsePostTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.UTC(), nil
+	}
+
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n <= 0 {
+			return time.Time{}, fmt.Errorf("invalid age %q", value)
+		}
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("%q is neither a date (YYYY-MM-DD) nor an age like 7d", value)
+	}
+	return now.Add(-d), nil
+}
+
 func handlerSearch(s *state, cmd command, user database.User) error {
-	if len(cmd.args) == 0 {
+	filter := postFilter{Feeds: []string{}}
+	feedNames := ""
+	exact := false
+	now := time.Now().UTC()
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--feed=") {
+			feedNames = strings.TrimPrefix(arg, "--feed=")
+		} else if arg == "--exact" {
+			exact = true
+		} else if strings.HasPrefix(arg, "--since=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--since="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			filter.Since = sql.NullTime{Time: t, Valid: true}
+		} else if strings.HasPrefix(arg, "--until=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--until="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			filter.Until = sql.NullTime{Time: t, Valid: true}
+		} else if arg == "--unread" {
+			filter.Unread = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 {
 		return errors.New("search query is required")
 	}
+	filter.Feeds = feedPatterns(feedNames, exact)
 
-	query := strings.Join(cmd.args, " ")
+	query := strings.Join(positional, " ")
 	limit := int32(20)
 
-	// Search for posts
-	posts, err := s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
-		UserID:  user.ID,
-		Column2: sql.NullString{String: query, Valid: true},
-		Limit:   limit,
-	})
+	backend, err := newSearchBackend(s.cfg)
 	if err != nil {
-		return fmt.Errorf("couldn't search posts: %w", err)
+		return err
+	}
+
+	// Search for posts, on the database if there's no backend or it fails
+	var posts []database.SearchPostsForUserRow
+	if backend != nil {
+		posts, err = searchExternal(s, backend, user, query, filter, int(limit))
+		if err != nil {
+			fmt.Printf("Search backend failed, searching the database instead: %v\n", err)
+		}
+	}
+	if backend == nil || err != nil {
+		posts, err = s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
+			UserID:        user.ID,
+			Column2:       sql.NullString{String: query, Valid: true},
+			Limit:         limit,
+			Column4:       filter.Feeds,
+			PublishedAt:   filter.Since,
+			PublishedAt_2: filter.Until,
+			Column7:       filter.Unread,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't search posts: %w", err)
+		}
 	}
 
 	if len(posts) == 0 {
@@ -493,17 +1452,20 @@ func handlerSearch(s *state, cmd command, user database.User) error {
 
 	fmt.Printf("Found %d posts matching \"%s\":\n\n", len(posts), query)
 
+	postIDs := make([]uuid.UUID, len(posts))
 	for i, post := range posts {
-		fmt.Printf("%d. %s\n", i+1, post.Title)
+		postIDs[i] = post.ID
+	}
+	rememberListing(s, user, 1, postIDs)
+
+	for i, post := range posts {
+		fmt.Printf("%d. %s%s\n", i+1, unreadMarker(post.IsRead), post.Title)
 		if post.Description.Valid && post.Description.String != "" {
-			description := post.Description.String
-			if len(description) > 150 {
-				description = description[:147] + "..."
-			}
-			fmt.Printf("   %s\n", description)
+			printSummary(post.Description.String, post.Url, 150, "   ")
 		}
 		fmt.Printf("   Link: %s\n", post.Url)
 		fmt.Printf("   Feed: %s\n", post.FeedName)
+		printMediaInfo(post.Season, post.Episode, post.EpisodeType, post.DurationSeconds, post.ViewCount)
 		if post.PublishedAt.Valid {
 			fmt.Printf("   Published: %s\n", post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST"))
 		}
@@ -515,13 +1477,11 @@ func handlerSearch(s *state, cmd command, user database.User) error {
 
 func handlerBookmark(s *state, cmd command, user database.User) error {
 	if len(cmd.args) == 0 {
-		return errors.New("post URL is required")
+		return errors.New("post URL or number is required")
 	}
 
-	postURL := cmd.args[0]
-
-	// Find the post by URL
-	post, err := s.db.GetPostByURL(context.Background(), postURL)
+	// Find the post by URL or listing number
+	post, err := resolvePost(s, user, cmd.args[0])
 	if err != nil {
 		return fmt.Errorf("couldn't find post: %w", err)
 	}
@@ -558,44 +1518,64 @@ func handlerBookmark(s *state, cmd command, user database.User) error {
 
 func handlerUnbookmark(s *state, cmd command, user database.User) error {
 	if len(cmd.args) == 0 {
-		return errors.New("post URL is required")
+		return errors.New("post URL or number is required")
 	}
 
-	postURL := cmd.args[0]
-
-	// Find the post by URL
-	post, err := s.db.GetPostByURL(context.Background(), postURL)
+	// Find the post by URL or listing number
+	post, err := resolvePost(s, user, cmd.args[0])
 	if err != nil {
 		return fmt.Errorf("couldn't find post: %w", err)
 	}
 
 	// Delete bookmark
 	err = s.db.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{
-		UserID: user.ID,
-		PostID: post.ID,
+		UserID:    user.ID,
+		PostID:    post.ID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't remove bookmark: %w", err)
 	}
 
-	fmt.Printf("Removed bookmark: %s\n", post.Title)
+	fmt.Printf("Removed bookmark: %s (use 'gator undo' to restore it)\n", post.Title)
 	return nil
 }
 
 func handlerBookmarks(s *state, cmd command, user database.User) error {
-	limit := int32(20)
+	if len(cmd.args) > 0 && cmd.args[0] == "move" {
+		return handlerBookmarksMove(s, command{name: "move", args: cmd.args[1:]}, user)
+	}
 
-	// Parse optional limit argument
-	if len(cmd.args) > 0 {
-		if l, err := strconv.Atoi(cmd.args[0]); err == nil && l > 0 {
-			limit = int32(l)
+	limit := int32(20)
+	sortBy := bookmarkSorts[0]
+	tag := ""
+	for _, arg := range cmd.args {
+		switch {
+		case strings.HasPrefix(arg, "--sort="):
+			sortBy = strings.TrimPrefix(arg, "--sort=")
+			if !slices.Contains(bookmarkSorts, sortBy) {
+				return fmt.Errorf("invalid sort %q, use one of: %s", sortBy, strings.Join(bookmarkSorts, ", "))
+			}
+		case strings.HasPrefix(arg, "--tag="):
+			tags, err := parseTags([]string{strings.TrimPrefix(arg, "--tag=")})
+			if err != nil {
+				return err
+			}
+			tag = tags[0]
+		default:
+			// Parse optional limit argument
+			if l, err := strconv.Atoi(arg); err == nil && l > 0 {
+				limit = int32(l)
+			}
 		}
 	}
 
 	// Get bookmarks for user
 	bookmarks, err := s.db.GetBookmarksForUser(context.Background(), database.GetBookmarksForUserParams{
-		UserID: user.ID,
-		Limit:  limit,
+		UserID:  user.ID,
+		Limit:   limit,
+		Column3: tag,
+		Column4: sortBy,
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't get bookmarks: %w", err)
@@ -608,14 +1588,16 @@ func handlerBookmarks(s *state, cmd command, user database.User) error {
 
 	fmt.Printf("Your %d bookmark(s):\n\n", len(bookmarks))
 
+	postIDs := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		postIDs[i] = bookmark.ID
+	}
+	rememberListing(s, user, 1, postIDs)
+
 	for i, bookmark := range bookmarks {
 		fmt.Printf("%d. %s\n", i+1, bookmark.Title)
 		if bookmark.Description.Valid && bookmark.Description.String != "" {
-			description := bookmark.Description.String
-			if len(description) > 150 {
-				description = description[:147] + "..."
-			}
-			fmt.Printf("   %s\n", description)
+			printSummary(bookmark.Description.String, bookmark.Url, 150, "   ")
 		}
 		fmt.Printf("   Link: %s\n", bookmark.Url)
 		fmt.Printf("   Feed: %s\n", bookmark.FeedName)
@@ -646,197 +1628,87 @@ func openURL(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
-func handlerTUI(s *state, cmd command, user database.User) error {
-	limit := int32(10)
-
-	// Get recent posts
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  limit,
-	})
+func main() {
+	// Read the config file, with the profile picked by --profile or
+	// GATOR_PROFILE (the flag wins)
+	args, profile := extractValueFlag(os.Args, "--profile")
+	if profile == "" {
+		profile = os.Getenv("GATOR_PROFILE")
+	}
+	cfg, err := config.Read(profile)
 	if err != nil {
-		return fmt.Errorf("couldn't get posts: %w", err)
+		fmt.Printf("Error reading config: %v\n", err)
+		os.Exit(1)
 	}
-
-	if len(posts) == 0 {
-		fmt.Println("No posts found.")
-		return nil
+	if cfg.ConfigVersion > config.SchemaVersion {
+		configPath, _ := config.Path()
+		fmt.Printf("Warning: %s was written by a newer gator (config version %d), settings this version doesn't know are ignored\n", configPath, cfg.ConfigVersion)
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	args, readOnly := extractReadOnlyFlag(args)
+	readOnly = readOnly || cfg.ReadOnly
+	args, debug := extractFlag(args, "--debug")
+	args, sqlTrace := extractFlag(args, "--sql-trace")
 
-	for {
-		// Clear screen (works on most terminals)
-		fmt.Print("\033[2J\033[H")
-
-		fmt.Println("=== Gator TUI - Latest Posts ===")
-		fmt.Println()
-
-		// Display posts
-		for i, post := range posts {
-			fmt.Printf("%d. %s\n", i+1, post.Title)
-			if post.Description.Valid && post.Description.String != "" {
-				description := post.Description.String
-				if len(description) > 100 {
-					description = description[:97] + "..."
-				}
-				fmt.Printf("   %s\n", description)
-			}
-			fmt.Printf("   Feed: %s", post.FeedName)
-			if post.PublishedAt.Valid {
-				fmt.Printf(" | %s", post.PublishedAt.Time.Format("Jan 02"))
-			}
-			fmt.Println()
-		}
-
-		fmt.Println()
-		fmt.Println("Commands:")
-		fmt.Println("  1-10    Open post in browser")
-		fmt.Println("  r       Refresh posts")
-		fmt.Println("  s       Search posts")
-		fmt.Println("  b       View bookmarks")
-		fmt.Println("  q       Quit")
-		fmt.Print("\nEnter command: ")
-
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("error reading input: %w", err)
-		}
-
-		input = strings.TrimSpace(input)
-
-		switch input {
-		case "q":
-			fmt.Println("Goodbye!")
-			return nil
-
-		case "r":
-			// Refresh posts
-			posts, err = s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-				UserID: user.ID,
-				Limit:  limit,
-			})
-			if err != nil {
-				fmt.Printf("Error refreshing posts: %v\n", err)
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-			}
-
-		case "s":
-			fmt.Print("Enter search query: ")
-			query, err := reader.ReadString('\n')
-			if err != nil {
-				fmt.Printf("Error reading query: %v\n", err)
-				continue
-			}
-			query = strings.TrimSpace(query)
-			if query == "" {
-				continue
-			}
-
-			searchResults, err := s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
-				UserID:  user.ID,
-				Column2: sql.NullString{String: query, Valid: true},
-				Limit:   limit,
-			})
-			if err != nil {
-				fmt.Printf("Error searching posts: %v\n", err)
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-
-			// Convert search results to regular posts format
-			posts = make([]database.GetPostsForUserRow, len(searchResults))
-			for i, result := range searchResults {
-				posts[i] = database.GetPostsForUserRow{
-					ID:          result.ID,
-					CreatedAt:   result.CreatedAt,
-					UpdatedAt:   result.UpdatedAt,
-					Title:       result.Title,
-					Url:         result.Url,
-					Description: result.Description,
-					PublishedAt: result.PublishedAt,
-					FeedID:      result.FeedID,
-					FeedName:    result.FeedName,
-				}
-			}
-
-		case "b":
-			bookmarks, err := s.db.GetBookmarksForUser(context.Background(), database.GetBookmarksForUserParams{
-				UserID: user.ID,
-				Limit:  limit,
-			})
-			if err != nil {
-				fmt.Printf("Error getting bookmarks: %v\n", err)
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-				continue
-			}
-
-			// Convert bookmarks to regular posts format
-			posts = make([]database.GetPostsForUserRow, len(bookmarks))
-			for i, bookmark := range bookmarks {
-				posts[i] = database.GetPostsForUserRow{
-					ID:          bookmark.ID,
-					CreatedAt:   bookmark.CreatedAt,
-					UpdatedAt:   bookmark.UpdatedAt,
-					Title:       bookmark.Title,
-					Url:         bookmark.Url,
-					Description: bookmark.Description,
-					PublishedAt: bookmark.PublishedAt,
-					FeedID:      bookmark.FeedID,
-					FeedName:    bookmark.FeedName,
-				}
-			}
-
-		default:
-			// Try to parse as post number
-			if postNum, err := strconv.Atoi(input); err == nil && postNum >= 1 && postNum <= len(posts) {
-				post := posts[postNum-1]
-				fmt.Printf("\nOpening: %s\n", post.Title)
-				fmt.Printf("URL: %s\n", post.Url)
-
-				if err := openURL(post.Url); err != nil {
-					fmt.Printf("Error opening URL: %v\n", err)
-					fmt.Printf("Please open this URL manually: %s\n", post.Url)
-				} else {
-					fmt.Println("Opened in browser!")
-				}
-
-				fmt.Print("Press Enter to continue...")
-				reader.ReadString('\n')
-			} else {
-				fmt.Println("Invalid command. Press Enter to continue...")
-				reader.ReadString('\n')
-			}
-		}
-	}
-}
-
-func main() {
-	// Read the config file
-	cfg, err := config.Read()
+	tracer, traceFile, err := newSQLTracer(&cfg)
 	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	defer traceFile.Close()
+	tracer.SetEnabled(sqlTrace)
+	observer := dbtrace.Multi{tracer}
+	var counter *dbtrace.Counter
+	if debug {
+		counter = &dbtrace.Counter{}
+		observer = append(observer, counter)
+	}
 
 	// Open database connection
-	db, err := sql.Open("postgres", cfg.DBUrl)
+	dbURL := cfg.DBUrl
+	if readOnly {
+		dbURL, err = readOnlyDSN(dbURL)
+		if err != nil {
+			fmt.Printf("Error parsing db_url: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	db, err := openDB(dbURL, observer)
 	if err != nil {
 		fmt.Printf("Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 	defer db.Close()
+	if err := configurePool(db, &cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Catch a database that is behind the code before some query trips over
+	// a missing column. If the database can't be reached the command that
+	// needs it reports that.
+	problems, err := checkSchema(db)
+	if err == nil && len(problems) > 0 {
+		fmt.Println("Error: the database schema doesn't match this version of gator:")
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+		fmt.Println("Run the migrations to update it:")
+		fmt.Println("  cd sql/schema && goose postgres \"<db_url from ~/.gatorconfig.json>\" up")
+		os.Exit(1)
+	}
 
 	// Create database queries instance
 	dbQueries := database.New(db)
 
 	// Create state with config and database
 	programState := &state{
-		db:  dbQueries,
-		cfg: &cfg,
+		db:       dbQueries,
+		conn:     db,
+		cfg:      &cfg,
+		hooks:    newDispatcher(&cfg),
+		readOnly: readOnly,
+		sqlTrace: tracer,
 	}
 
 	// Create commands with initialized map
@@ -846,24 +1718,66 @@ func main() {
 
 	// Register commands
 	cmds.register("login", handlerLogin)
-	cmds.register("register", handlerRegister)
-	cmds.register("reset", handlerReset)
+	cmds.register("register", middlewareWrites(handlerRegister))
+	cmds.register("reset", middlewareWrites(handlerReset))
 	cmds.register("users", handlerUsers)
-	cmds.register("agg", handlerAgg)
-	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
+	cmds.register("user", handlerUser)
+	cmds.register("agg", middlewareWrites(handlerAgg))
+	cmds.register("stats", handlerStats)
+	cmds.register("addfeed", middlewareWrites(middlewareLoggedIn(handlerAddFeed)))
+	cmds.register("import", middlewareWrites(middlewareLoggedIn(handlerImport)))
+	cmds.register("import-state", middlewareWrites(middlewareLoggedIn(handlerImportState)))
 	cmds.register("feeds", handlerFeeds)
-	cmds.register("follow", middlewareLoggedIn(handlerFollow))
+	cmds.register("feed-errors", handlerFeedErrors)
+	cmds.register("feed", middlewareLoggedIn(handlerFeed))
+	cmds.register("quarantine", handlerQuarantine)
+	cmds.register("follow", middlewareWrites(middlewareLoggedIn(handlerFollow)))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
-	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
+	cmds.register("unfollow", middlewareWrites(middlewareLoggedIn(handlerUnfollow)))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
 	cmds.register("search", middlewareLoggedIn(handlerSearch))
-	cmds.register("bookmark", middlewareLoggedIn(handlerBookmark))
-	cmds.register("unbookmark", middlewareLoggedIn(handlerUnbookmark))
+	cmds.register("reindex", middlewareWrites(handlerReindex))
+	cmds.register("cache", handlerCache)
+	cmds.register("open", middlewareLoggedIn(handlerOpen))
+	cmds.register("read", middlewareWrites(middlewareLoggedIn(handlerRead)))
+	cmds.register("unread", middlewareWrites(middlewareLoggedIn(handlerUnread)))
+	cmds.register("mark-read", middlewareWrites(middlewareLoggedIn(handlerMarkRead)))
+	cmds.register("pack", middlewareLoggedIn(handlerPack))
+	cmds.register("newspaper", middlewareLoggedIn(handlerNewspaper))
+	cmds.register("export-reading", middlewareLoggedIn(handlerExportReading))
+	cmds.register("tts", middlewareLoggedIn(handlerTTS))
+	cmds.register("print", middlewareLoggedIn(handlerPrint))
+	cmds.register("bookmark", middlewareWrites(middlewareLoggedIn(handlerBookmark)))
+	cmds.register("unbookmark", middlewareWrites(middlewareLoggedIn(handlerUnbookmark)))
 	cmds.register("bookmarks", middlewareLoggedIn(handlerBookmarks))
+	cmds.register("collection", middlewareLoggedIn(handlerCollection))
+	cmds.register("tag", middlewareWrites(middlewareLoggedIn(handlerTag)))
+	cmds.register("untag", middlewareWrites(middlewareLoggedIn(handlerUntag)))
+	cmds.register("tags", middlewareLoggedIn(handlerTags))
+	cmds.register("rule", middlewareLoggedIn(handlerRule))
+	cmds.register("mute", middlewareWrites(middlewareLoggedIn(handlerMute)))
+	cmds.register("unmute", middlewareWrites(middlewareLoggedIn(handlerUnmute)))
+	cmds.register("domains", middlewareLoggedIn(handlerDomains))
+	cmds.register("links", middlewareLoggedIn(handlerLinks))
+	cmds.register("fetch-content", middlewareWrites(middlewareLoggedIn(handlerFetchContent)))
+	cmds.register("enrich", middlewareWrites(middlewareLoggedIn(handlerEnrich)))
+	cmds.register("undo", middlewareWrites(middlewareLoggedIn(handlerUndo)))
 	cmds.register("tui", middlewareLoggedIn(handlerTUI))
+	cmds.register("passwd", middlewareWrites(middlewareLoggedIn(handlerPasswd)))
+	cmds.register("token", middlewareLoggedIn(handlerToken))
+	cmds.register("serve", handlerServe)
 
 	// Get command-line arguments
-	args := os.Args
+	args, userOverride := extractValueFlag(args, "--user")
+	if userOverride == "" {
+		userOverride = os.Getenv("GATOR_USER")
+	}
+	if userOverride != "" {
+		// Only this invocation acts as the user, it is never written back
+		// to the config
+		cfg.CurrentUserName = userOverride
+		programState.userOverride = true
+	}
 	if len(args) < 2 {
 		fmt.Println("Error: not enough arguments provided")
 		os.Exit(1)
@@ -881,8 +1795,16 @@ func main() {
 		args: cmdArgs,
 	}
 
-	// Run the command
+	// Run the command, not counting the schema check in the debug report
+	started := time.Now()
+	if debug {
+		counter.Reset()
+	}
 	err = cmds.run(programState, cmd)
+	if debug {
+		printDebugReport(cmd, started, counter)
+	}
+	programState.hooks.Close(webhookFlushTimeout)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/epub"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const (
+	defaultNewspaperWindow = 24 * time.Hour
+	defaultNewspaperLimit  = 100
+)
+
+// handlerNewspaper bundles recent posts from followed feeds into an EPUB
+// with one section per feed
+func handlerNewspaper(s *state, cmd command, user database.User) error {
+	outPath := ""
+	since := defaultNewspaperWindow
+	limit := int32(defaultNewspaperLimit)
+	full := false
+	unreadOnly := false
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--out=") {
+			outPath = strings.TrimPrefix(arg, "--out=")
+		} else if strings.HasPrefix(arg, "--since=") {
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--since="))
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --since: %s", arg)
+			}
+			since = d
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = int32(l)
+		} else if arg == "--full" {
+			full = true
+		} else if arg == "--unread" {
+			unreadOnly = true
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	now := time.Now().UTC()
+	if outPath == "" {
+		outPath = fmt.Sprintf("gator-%s.epub", now.Format("2006-01-02"))
+	}
+	if strings.ToLower(filepath.Ext(outPath)) != ".epub" {
+		return errors.New("the newspaper can only be written as .epub")
+	}
+
+	posts, err := s.db.GetPostsForUserSince(context.Background(), database.GetPostsForUserSinceParams{
+		UserID:      user.ID,
+		PublishedAt: sql.NullTime{Time: now.Add(-since), Valid: true},
+		Limit:       limit,
+		Column4:     unreadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No posts in the last %s\n", since)
+		return nil
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+	pages, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	book := epub.Book{
+		Title:   fmt.Sprintf("gator daily, %s", now.Format("Mon, 02 Jan 2006")),
+		Author:  "gator",
+		Created: now,
+	}
+	for i, post := range posts {
+		content := article.Sanitize(post.Description.String, post.Url)
+		// Pages are only downloaded on request, a day of posts can take a
+		// while to fetch
+		if full {
+			fmt.Printf("Fetching %d/%d: %s\n", i+1, len(posts), post.Title)
+			content = string(fetchArticle(client, pages, post.Url, post.Title, post.FeedName, post.Description.String, post.PublishedAt.Time).Content)
+		}
+
+		subtitle := post.FeedName
+		if post.PublishedAt.Valid {
+			subtitle += ", " + post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04")
+		}
+		book.Chapters = append(book.Chapters, epub.Chapter{
+			Title:    post.Title,
+			Subtitle: subtitle,
+			Section:  post.FeedName,
+			Body:     article.StripImages(content),
+		})
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := book.Write(file); err != nil {
+		return fmt.Errorf("couldn't write %s: %w", outPath, err)
+	}
+
+	fmt.Printf("Wrote %d posts from the last %s to %s\n", len(posts), since, outPath)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// Where a post was opened from, recorded with each open
+const (
+	openSourceCLI = "cli"
+	openSourceTUI = "tui"
+	openSourceWeb = "web"
+)
+
+// recordOpen counts an open of a post, printing rather than failing when it
+// can't as the post is already open
+func recordOpen(s *state, user database.User, postID uuid.UUID, source string) {
+	if s.readOnly {
+		return
+	}
+	err := s.db.CreatePostOpen(context.Background(), database.CreatePostOpenParams{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		PostID:   postID,
+		OpenedAt: time.Now().UTC(),
+		Source:   source,
+	})
+	if err != nil {
+		fmt.Printf("Couldn't record open of post: %v\n", err)
+	}
+}
+
+func handlerOpen(s *state, cmd command, user database.User) error {
+	embed := false
+	var positional []string
+	for _, arg := range cmd.args {
+		if arg == "--embed" {
+			embed = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: gator open <post_url|number> [--embed]")
+	}
+
+	post, err := resolvePost(s, user, positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+
+	url := postLink(post)
+	if embed {
+		if !post.EmbedUrl.Valid {
+			return fmt.Errorf("post %q has no embed player URL", post.Title)
+		}
+		url = post.EmbedUrl.String
+	}
+
+	if err := openURL(url); err != nil {
+		return fmt.Errorf("couldn't open %s: %w", url, err)
+	}
+	markRead(s, user, post)
+	recordOpen(s, user, post.ID, openSourceCLI)
+	fmt.Printf("Opened: %s\n", url)
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/cache"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/epub"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const articleFetchTimeout = 30 * time.Second
+
+// offlinePost is a post with the content that goes into an offline bundle
+type offlinePost struct {
+	Title     string
+	URL       string
+	FeedName  string
+	Published time.Time
+	Content   template.HTML
+}
+
+// fetchArticle returns the cleaned full text of a post, falling back to the
+// feed's description when the page can't be fetched. Pages are kept in c,
+// which may be nil, so bundling the same posts again doesn't refetch them.
+func fetchArticle(client *http.Client, c *cache.Cache, url, title, feedName, description string, published time.Time) offlinePost {
+	post := offlinePost{
+		Title:     title,
+		URL:       url,
+		FeedName:  feedName,
+		Published: published,
+	}
+
+	key := "article:" + url
+	if c != nil {
+		if content, ok := c.Get(key); ok {
+			post.Content = template.HTML(content)
+			return post
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), articleFetchTimeout)
+	defer cancel()
+
+	a, err := article.Fetch(ctx, client, url)
+	if err == nil && a.Content != "" {
+		if c != nil {
+			if err := c.Put(key, []byte(a.Content)); err != nil {
+				fmt.Printf("Couldn't cache %s: %v\n", url, err)
+			}
+		}
+		post.Content = template.HTML(a.Content)
+		return post
+	}
+	if err != nil {
+		fmt.Printf("Couldn't fetch %s, using the feed summary instead: %v\n", url, err)
+	}
+	post.Content = template.HTML(article.Sanitize(description, url))
+	return post
+}
+
+func handlerPack(s *state, cmd command, user database.User) error {
+	outPath := ""
+	limit := int32(20)
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--out=") {
+			outPath = strings.TrimPrefix(arg, "--out=")
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = int32(l)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 || outPath == "" {
+		return errors.New("usage: gator pack <query> --out=reading.epub|reading.html [--limit=N]")
+	}
+	format := strings.ToLower(filepath.Ext(outPath))
+	if format != ".epub" && format != ".html" {
+		return fmt.Errorf("unsupported output format %q, use .epub or .html", format)
+	}
+
+	query := strings.Join(positional, " ")
+	posts, err := s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
+		UserID:  user.ID,
+		Column2: sql.NullString{String: query, Valid: true},
+		Limit:   limit,
+		Column4: []string{},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't search posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No posts found matching \"%s\"\n", query)
+		return nil
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+	pages, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	var packed []offlinePost
+	for i, post := range posts {
+		fmt.Printf("Fetching %d/%d: %s\n", i+1, len(posts), post.Title)
+		packed = append(packed, fetchArticle(client, pages, post.Url, post.Title, post.FeedName, post.Description.String, post.PublishedAt.Time))
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create output file: %w", err)
+	}
+	defer file.Close()
+
+	title := fmt.Sprintf("gator: %s", query)
+	if format == ".epub" {
+		err = writeEPUB(file, title, packed)
+	} else {
+		err = writeHTMLBundle(file, title, packed)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write %s: %w", outPath, err)
+	}
+
+	fmt.Printf("Packed %d posts into %s\n", len(packed), outPath)
+	return nil
+}
+
+func writeEPUB(w io.Writer, title string, posts []offlinePost) error {
+	book := epub.Book{
+		Title:   title,
+		Author:  "gator",
+		Created: time.Now().UTC(),
+	}
+	for _, post := range posts {
+		subtitle := post.FeedName
+		if !post.Published.IsZero() {
+			subtitle += ", " + post.Published.Format("Mon, 02 Jan 2006")
+		}
+		book.Chapters = append(book.Chapters, epub.Chapter{
+			Title:    post.Title,
+			Subtitle: subtitle,
+			// E-readers can't load remote images
+			Body: article.StripImages(string(post.Content)),
+		})
+	}
+	return book.Write(w)
+}
+
+var htmlBundleTmpl = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 40em; margin: 2em auto; padding: 0 1em; font-family: Georgia, serif; line-height: 1.6; }
+img { max-width: 100%; height: auto; }
+pre { overflow-x: auto; }
+article { border-top: 1px solid #ccc; margin-top: 3em; padding-top: 1em; page-break-before: always; }
+.meta { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ol>
+{{- range $i, $post := .Posts}}
+<li><a href="#post-{{$i}}">{{$post.Title}}</a></li>
+{{- end}}
+</ol>
+{{- range $i, $post := .Posts}}
+<article id="post-{{$i}}">
+<h2>{{$post.Title}}</h2>
+<p class="meta">{{$post.FeedName}}{{if not $post.Published.IsZero}}, {{$post.Published.Format "Mon, 02 Jan 2006"}}{{end}} &middot; <a href="{{$post.URL}}">original</a></p>
+{{$post.Content}}
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTMLBundle writes posts as a single self-contained HTML page
+func writeHTMLBundle(w io.Writer, title string, posts []offlinePost) error {
+	return htmlBundleTmpl.Execute(w, struct {
+		Title string
+		Posts []offlinePost
+	}{title, posts})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/ratelimit"
+)
+
+const (
+	// pageWorkers download article pages for agg, apart from the workers
+	// fetching feeds so a feed with hundreds of new posts can't hold one
+	pageWorkers = 2
+
+	// pagesPerCycle caps the article pages queued per agg cycle. Posts past
+	// it go without, gator enrich and fetch-content can still get them.
+	pagesPerCycle = 200
+)
+
+// pageJob is an article page to download for a post agg just ingested
+type pageJob struct {
+	post   database.Post
+	client *http.Client
+	// what is what fetch stores, for the log
+	what  string
+	fetch func(s *state, client *http.Client, post database.Post) error
+}
+
+// pageQueue downloads article pages in the background, spaced out per host
+// by the same limiter as the feeds
+type pageQueue struct {
+	s     *state
+	hosts *ratelimit.Limiter
+	jobs  chan pageJob
+
+	mu      sync.Mutex
+	queued  int
+	dropped int
+}
+
+func newPageQueue(s *state, hosts *ratelimit.Limiter) *pageQueue {
+	q := &pageQueue{
+		s:     s,
+		hosts: hosts,
+		jobs:  make(chan pageJob, pagesPerCycle),
+	}
+	for i := 0; i < pageWorkers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// add queues a job unless this cycle's share of pages is used up
+func (q *pageQueue) add(job pageJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued >= pagesPerCycle {
+		q.dropped++
+		return
+	}
+	select {
+	case q.jobs <- job:
+		q.queued++
+	default:
+		q.dropped++
+	}
+}
+
+// endCycle reports the pages left out this cycle and opens the next share
+func (q *pageQueue) endCycle() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.dropped > 0 {
+		slog.Warn("skipped article pages over the per-cycle limit", "queued", q.queued, "skipped", q.dropped, "limit", pagesPerCycle)
+	}
+	q.queued, q.dropped = 0, 0
+}
+
+func (q *pageQueue) work() {
+	for job := range q.jobs {
+		time.Sleep(time.Until(q.hosts.Reserve(feedHost(job.post.Url))))
+		if err := job.fetch(q.s, job.client, job.post); err != nil {
+			slog.Warn("couldn't fetch "+job.what, "url", job.post.Url, "err", err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+const minPasswordLength = 8
+
+// stdinLines reads passwords piped in rather than typed, one per line
+var stdinLines = bufio.NewReader(os.Stdin)
+
+// readPassword asks for a password without echoing it, or reads a line
+// when stdin isn't a terminal so scripts can pipe one in
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := stdinLines.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("couldn't read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// readNewPassword asks for a password twice and checks it is long enough
+func readNewPassword() (string, error) {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("passwords need at least %d characters", minPasswordLength)
+	}
+	again, err := readPassword("Repeat it: ")
+	if err != nil {
+		return "", err
+	}
+	if again != password {
+		return "", errors.New("the passwords don't match")
+	}
+	return password, nil
+}
+
+// passwordHash returns the bcrypt hash of the user's password, empty if
+// they have none
+func passwordHash(s *state, user database.User) (string, error) {
+	hash, err := s.db.GetUserPasswordHash(context.Background(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't get password: %w", err)
+	}
+	return hash, nil
+}
+
+func setPassword(s *state, user database.User, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("couldn't hash password: %w", err)
+	}
+	err = s.db.SetUserPassword(context.Background(), database.SetUserPasswordParams{
+		UserID:       user.ID,
+		PasswordHash: string(hash),
+		UpdatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set password: %w", err)
+	}
+	return nil
+}
+
+// checkPassword prompts for the user's password and compares it with hash
+func checkPassword(user database.User, hash string) error {
+	password, err := readPassword(fmt.Sprintf("Password for %s: ", user.Name))
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return errors.New("wrong password")
+	}
+	return nil
+}
+
+// startSession logs user in with a new login session, ending the one the
+// config held before
+func startSession(s *state, user database.User) error {
+	ctx := context.Background()
+	if s.cfg.SessionToken != "" {
+		if err := s.db.DeleteLoginSession(ctx, hashAPIToken(s.cfg.SessionToken)); err != nil {
+			return fmt.Errorf("couldn't end the previous session: %w", err)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("couldn't generate session: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	err := s.db.CreateLoginSession(ctx, database.CreateLoginSessionParams{
+		TokenHash: hashAPIToken(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create session: %w", err)
+	}
+	if err := s.cfg.SetLogin(user.Name, token); err != nil {
+		return fmt.Errorf("couldn't set user: %w", err)
+	}
+	return nil
+}
+
+// authenticate lets a command act as a user with a password only with the
+// config's login session, one of the user's API tokens in GATOR_TOKEN (for
+// scripts run with --user, which have no session), or the password typed
+// in now
+func authenticate(s *state, user database.User) error {
+	hash, err := passwordHash(s, user)
+	if err != nil || hash == "" {
+		return err
+	}
+	ctx := context.Background()
+	if s.cfg.SessionToken != "" {
+		userID, err := s.db.GetLoginSessionUserID(ctx, hashAPIToken(s.cfg.SessionToken))
+		if err == nil && userID == user.ID {
+			return nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("couldn't check session: %w", err)
+		}
+	}
+	if token := os.Getenv("GATOR_TOKEN"); token != "" {
+		tokenUser, err := s.db.GetUserByAPITokenHash(ctx, hashAPIToken(token))
+		if err == nil && tokenUser.ID == user.ID {
+			return nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("couldn't check token: %w", err)
+		}
+		return fmt.Errorf("GATOR_TOKEN isn't one of %s's tokens", user.Name)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("%s has a password, log in with: gator login %s, or set GATOR_TOKEN to one of their tokens", user.Name, user.Name)
+	}
+	return checkPassword(user, hash)
+}
+
+// handlerPasswd sets or changes the current user's password, or removes
+// it with --remove. Every other session of the user ends.
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	remove := false
+	for _, arg := range cmd.args {
+		if arg == "--remove" {
+			remove = true
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	// With --user the config stays logged in as whoever it was
+	ctx := context.Background()
+	ownSession := !s.userOverride
+
+	if remove {
+		if err := s.db.DeleteUserPassword(ctx, user.ID); err != nil {
+			return fmt.Errorf("couldn't remove password: %w", err)
+		}
+		if err := s.db.DeleteLoginSessionsForUser(ctx, user.ID); err != nil {
+			return fmt.Errorf("couldn't end sessions: %w", err)
+		}
+		if ownSession {
+			if err := s.cfg.SetUser(user.Name); err != nil {
+				return fmt.Errorf("couldn't set user: %w", err)
+			}
+		}
+		fmt.Printf("Removed the password of %s\n", user.Name)
+		return nil
+	}
+
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	if err := setPassword(s, user, password); err != nil {
+		return err
+	}
+	if err := s.db.DeleteLoginSessionsForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("couldn't end sessions: %w", err)
+	}
+	if ownSession {
+		// The config's session ended with the others, start a new one
+		s.cfg.SessionToken = ""
+		if err := startSession(s, user); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Password of %s changed, other sessions are logged out\n", user.Name)
+	return nil
+}
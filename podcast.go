@@ -0,0 +1,40 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// episodeLabel describes a podcast episode, e.g. "S2E5 (bonus)". It returns
+// "" for posts without episode metadata.
+func episodeLabel(season, episode sql.NullInt32, episodeType sql.NullString) string {
+	label := ""
+	if season.Valid {
+		label += fmt.Sprintf("S%d", season.Int32)
+	}
+	if episode.Valid {
+		label += fmt.Sprintf("E%d", episode.Int32)
+	}
+	// Full episodes are the norm, only call out trailers and bonus content
+	if episodeType.Valid && episodeType.String != "full" {
+		if label != "" {
+			label += " "
+		}
+		label += "(" + episodeType.String + ")"
+	}
+	return label
+}
+
+// printMediaInfo prints the podcast and video details of a post listing
+func printMediaInfo(season, episode sql.NullInt32, episodeType sql.NullString, durationSeconds sql.NullInt32, views sql.NullInt64) {
+	if label := episodeLabel(season, episode, episodeType); label != "" {
+		fmt.Printf("   Episode: %s\n", label)
+	}
+	if durationSeconds.Valid {
+		fmt.Printf("   Duration: %s\n", time.Duration(durationSeconds.Int32)*time.Second)
+	}
+	if views.Valid {
+		fmt.Printf("   Views: %d\n", views.Int64)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// sparsePost reports whether a post's feed item came without a title or a
+// description, so the Open Graph preview of its page is worth having
+func sparsePost(post database.Post) bool {
+	return strings.TrimSpace(post.Title) == "" || strings.TrimSpace(post.Description.String) == ""
+}
+
+// savePreview stores the preview of a post's page, if the page had one
+func savePreview(s *state, postID uuid.UUID, p article.Preview) error {
+	if p.IsZero() {
+		return nil
+	}
+	err := s.db.SavePostPreview(context.Background(), database.SavePostPreviewParams{
+		PostID:      postID,
+		FetchedAt:   time.Now().UTC(),
+		Title:       p.Title,
+		Description: p.Description,
+		ImageUrl:    p.Image,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save preview: %w", err)
+	}
+	return nil
+}
+
+// fetchPreview downloads the page behind post and stores its preview
+func fetchPreview(s *state, client *http.Client, post database.Post) error {
+	ctx, cancel := context.WithTimeout(context.Background(), articleFetchTimeout)
+	defer cancel()
+
+	a, err := article.Fetch(ctx, client, post.Url)
+	if err != nil {
+		return err
+	}
+	if a.Preview.IsZero() {
+		return errors.New("the page has no title, description or image")
+	}
+	return savePreview(s, post.ID, a.Preview)
+}
+
+// enrichPosts queues the sparse posts agg just ingested for their previews.
+// With agg --full the article download stores them instead.
+func enrichPosts(sc *scraper, feed database.Feed, posts []database.Post) {
+	if sc.fullContent {
+		return
+	}
+	for _, post := range posts {
+		if sparsePost(post) {
+			sc.pages.add(pageJob{post: post, client: sc.clientFor(feed), what: "preview", fetch: fetchPreview})
+		}
+	}
+}
+
+// previewed fills in a post's missing title and description from its
+// stored preview, and returns the preview image if there is one. Posts
+// with both are returned as they are without a lookup.
+func previewed(s *state, postID uuid.UUID, title string, description sql.NullString) (string, sql.NullString, string) {
+	if strings.TrimSpace(title) != "" && strings.TrimSpace(description.String) != "" {
+		return title, description, ""
+	}
+	p, err := s.db.GetPostPreview(context.Background(), postID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("couldn't get preview", "post", postID, "err", err)
+		}
+		return title, description, ""
+	}
+
+	if strings.TrimSpace(title) == "" {
+		title = p.Title
+	}
+	if strings.TrimSpace(description.String) == "" && p.Description != "" {
+		// Descriptions are HTML, the preview is plain text
+		description = sql.NullString{String: html.EscapeString(p.Description), Valid: true}
+	}
+	return title, description, p.ImageUrl
+}
+
+func handlerEnrich(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("usage: gator enrich <post_url|number>...")
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+
+	for _, ref := range cmd.args {
+		post, err := resolvePost(s, user, ref)
+		if err != nil {
+			return fmt.Errorf("couldn't find post: %w", err)
+		}
+		if err := fetchPreview(s, client, post); err != nil {
+			return fmt.Errorf("couldn't fetch preview of %s: %w", post.Url, err)
+		}
+		fmt.Printf("Stored preview of: %s\n", post.Url)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+var printTmpl = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 40em; margin: 2em auto; padding: 0 1em; font-family: Georgia, serif; font-size: 12pt; line-height: 1.5; color: #000; }
+h1 { font-size: 20pt; margin-bottom: 0.2em; }
+img { max-width: 100%; height: auto; page-break-inside: avoid; }
+pre { white-space: pre-wrap; }
+a { color: #000; }
+.meta, .source { color: #444; font-size: 10pt; }
+.source { border-top: 1px solid #999; margin-top: 2em; padding-top: 0.5em; word-break: break-all; }
+@media print {
+  body { margin: 0; max-width: none; }
+  h1, h2, h3 { page-break-after: avoid; }
+}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{.FeedName}}{{if not .Published.IsZero}}, {{.Published.Format "Mon, 02 Jan 2006"}}{{end}}</p>
+{{.Content}}
+<p class="source">Source: {{.URL}}</p>
+</body>
+</html>
+`))
+
+// handlerPrint writes a post as printable HTML, or as PDF through
+// wkhtmltopdf
+func handlerPrint(s *state, cmd command, user database.User) error {
+	outPath := ""
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--out=") {
+			outPath = strings.TrimPrefix(arg, "--out=")
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: gator print <post_url|number> [--out=file.html|file.pdf]")
+	}
+
+	ctx := context.Background()
+	post, err := resolvePost(s, user, positional[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+	feed, err := s.db.GetFeedByID(ctx, post.FeedID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed: %w", err)
+	}
+
+	if outPath == "" {
+		outPath = fileSlug(post.Title) + ".html"
+	}
+	format := strings.ToLower(filepath.Ext(outPath))
+	if format != ".html" && format != ".pdf" {
+		return fmt.Errorf("unsupported output format %q, use .html or .pdf", format)
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+	pages, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+	printed := fetchArticle(client, pages, post.Url, post.Title, feed.Name, post.Description.String, post.PublishedAt.Time)
+
+	htmlPath := outPath
+	if format == ".pdf" {
+		tmp, err := os.CreateTemp("", "gator-print-*.html")
+		if err != nil {
+			return fmt.Errorf("couldn't create temporary file: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		htmlPath = tmp.Name()
+	}
+
+	file, err := os.Create(htmlPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create output file: %w", err)
+	}
+	err = printTmpl.Execute(file, printed)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("couldn't write %s: %w", htmlPath, err)
+	}
+
+	if format == ".pdf" {
+		output, err := exec.Command("wkhtmltopdf", "--quiet", htmlPath, outPath).CombinedOutput()
+		if err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return errors.New("PDF output needs wkhtmltopdf installed, or use --out=file.html and print from a browser")
+			}
+			return fmt.Errorf("wkhtmltopdf failed: %w\n%s", err, output)
+		}
+	}
+
+	fmt.Printf("Wrote %s\n", outPath)
+	return nil
+}
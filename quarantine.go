@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/layout"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+func quarantineItem(s *state, feed database.Feed, item rss.RSSItem, reason error) {
+	err := s.db.QuarantineItem(context.Background(), database.QuarantineItemParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		FeedID:      feed.ID,
+		Title:       item.Title,
+		Url:         item.Link,
+		Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
+		Reason:      reason.Error(),
+	})
+	if err != nil {
+		slog.Error("couldn't quarantine item", "feed", feed.Name, "err", err)
+	}
+}
+
+// handlerQuarantine dispatches the "quarantine <subcommand>" family of commands
+func handlerQuarantine(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: list")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	switch sub.name {
+	case "list":
+		return handlerQuarantineList(s, sub)
+	default:
+		return fmt.Errorf("unknown quarantine subcommand: %s", sub.name)
+	}
+}
+
+func handlerQuarantineList(s *state, cmd command) error {
+	limit := int32(20)
+
+	// Parse optional limit argument
+	if len(cmd.args) > 0 {
+		if l, err := strconv.Atoi(cmd.args[0]); err == nil && l > 0 {
+			limit = int32(l)
+		}
+	}
+
+	items, err := s.db.GetQuarantinedItems(context.Background(), limit)
+	if err != nil {
+		return fmt.Errorf("couldn't get quarantined items: %w", err)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("No quarantined items.")
+		return nil
+	}
+
+	for i, item := range items {
+		title := item.Title
+		if title == "" {
+			title = "(no title)"
+		}
+		fmt.Printf("%d. %s\n", i+1, layout.Truncate(title, 150))
+		fmt.Printf("   Reason: %s\n", item.Reason)
+		fmt.Printf("   Link: %s\n", layout.Truncate(item.Url, 150))
+		fmt.Printf("   Feed: %s\n", item.FeedName)
+		fmt.Printf("   Quarantined: %s\n", item.CreatedAt.Format("Mon, 02 Jan 2006 15:04:05 MST"))
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+)
+
+// markRead records that user has read post, printing rather than failing
+// when it can't, for commands where that is a side effect
+func markRead(s *state, user database.User, post database.Post) {
+	if s.readOnly {
+		return
+	}
+	err := s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Printf("Couldn't mark %s as read: %v\n", post.Title, err)
+	}
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("post URL or number is required")
+	}
+
+	for _, ref := range cmd.args {
+		post, err := resolvePost(s, user, ref)
+		if err != nil {
+			return fmt.Errorf("couldn't find post: %w", err)
+		}
+
+		err = s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			ReadAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark post as read: %w", err)
+		}
+		fmt.Printf("Marked as read: %s\n", post.Title)
+	}
+	return nil
+}
+
+func handlerUnread(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("post URL or number is required")
+	}
+
+	for _, ref := range cmd.args {
+		post, err := resolvePost(s, user, ref)
+		if err != nil {
+			return fmt.Errorf("couldn't find post: %w", err)
+		}
+
+		err = s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark post as unread: %w", err)
+		}
+		fmt.Printf("Marked as unread: %s\n", post.Title)
+	}
+	return nil
+}
+
+func handlerMarkRead(s *state, cmd command, user database.User) error {
+	feedFilter := ""
+	exactFeeds := false
+	all := false
+	var before time.Duration
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--feed=") {
+			feedFilter = strings.TrimPrefix(arg, "--feed=")
+		} else if strings.HasPrefix(arg, "--before=") {
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--before="))
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --before: %s", arg)
+			}
+			before = d
+		} else if arg == "--exact" {
+			exactFeeds = true
+		} else if arg == "--all" {
+			all = true
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	// Marking everything read can't be undone, so it has to be asked for
+	if feedFilter == "" && before == 0 && !all {
+		return errors.New("usage: gator mark-read --feed=<names> [--exact] | --before=<duration> | --all")
+	}
+
+	now := time.Now().UTC()
+	marked, err := s.db.MarkPostsRead(context.Background(), database.MarkPostsReadParams{
+		UserID:  user.ID,
+		ReadAt:  now,
+		Column3: feedPatterns(feedFilter, exactFeeds),
+		Column4: now.Add(-before),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark posts as read: %w", err)
+	}
+
+	fmt.Printf("Marked %d posts as read\n", marked)
+	return nil
+}
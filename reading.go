@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/cache"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const (
+	defaultReadingWindow = 24 * time.Hour
+	defaultReadingLimit  = 100
+)
+
+// readingPost is a post picked for export-reading, before its content is
+// looked up
+type readingPost struct {
+	ID          uuid.UUID
+	Title       string
+	URL         string
+	FeedName    string
+	Description sql.NullString
+	Published   sql.NullTime
+}
+
+// offlineContent uses the article stored by fetch-content or agg --full,
+// and only downloads the page when there is none
+func offlineContent(s *state, client *http.Client, pages *cache.Cache, post readingPost) offlinePost {
+	stored, err := s.db.GetPostContent(context.Background(), post.ID)
+	if err == nil {
+		return offlinePost{
+			Title:     post.Title,
+			URL:       post.URL,
+			FeedName:  post.FeedName,
+			Published: post.Published.Time,
+			Content:   template.HTML(article.Sanitize(stored.Content, post.URL)),
+		}
+	}
+	return fetchArticle(client, pages, post.URL, post.Title, post.FeedName, post.Description.String, post.Published.Time)
+}
+
+// handlerExportReading bundles recent unread or bookmarked posts into one
+// EPUB or HTML file to read offline
+func handlerExportReading(s *state, cmd command, user database.User) error {
+	format := "epub"
+	outPath := ""
+	bookmarked := false
+	limit := defaultReadingLimit
+	now := time.Now().UTC()
+	since := now.Add(-defaultReadingWindow)
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--format=") {
+			format = strings.TrimPrefix(arg, "--format=")
+		} else if strings.HasPrefix(arg, "--out=") {
+			outPath = strings.TrimPrefix(arg, "--out=")
+		} else if strings.HasPrefix(arg, "--since=") {
+			t, err := parsePostTime(strings.TrimPrefix(arg, "--since="), now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			since = t
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = l
+		} else if arg == "--bookmarked" {
+			bookmarked = true
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+	if format != "epub" && format != "html" {
+		return fmt.Errorf("unsupported format %q, use epub or html", format)
+	}
+	if outPath == "" {
+		outPath = fmt.Sprintf("gator-reading-%s.%s", now.Local().Format("2006-01-02"), format)
+	}
+
+	var posts []readingPost
+	if bookmarked {
+		bookmarks, err := s.db.GetAllBookmarksForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't get bookmarks: %w", err)
+		}
+		// Newest bookmarks first, like the unread posts
+		for i := len(bookmarks) - 1; i >= 0 && len(posts) < limit; i-- {
+			b := bookmarks[i]
+			if b.BookmarkedAt.Before(since) {
+				break
+			}
+			posts = append(posts, readingPost{b.ID, b.Title, b.Url, b.FeedName, b.Description, b.PublishedAt})
+		}
+	} else {
+		unread, err := s.db.GetPostsForUserSince(context.Background(), database.GetPostsForUserSinceParams{
+			UserID:      user.ID,
+			PublishedAt: sql.NullTime{Time: since, Valid: true},
+			Limit:       int32(limit),
+			Column4:     true,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get posts: %w", err)
+		}
+		for _, p := range unread {
+			posts = append(posts, readingPost{p.ID, p.Title, p.Url, p.FeedName, p.Description, p.PublishedAt})
+		}
+	}
+
+	kind := "unread posts"
+	if bookmarked {
+		kind = "bookmarks"
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No %s since %s\n", kind, since.Local().Format("Mon, 02 Jan 2006 15:04"))
+		return nil
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+	pages, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	bundle := make([]offlinePost, 0, len(posts))
+	for i, post := range posts {
+		fmt.Printf("Adding %d/%d: %s\n", i+1, len(posts), post.Title)
+		bundle = append(bundle, offlineContent(s, client, pages, post))
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create output file: %w", err)
+	}
+	defer file.Close()
+
+	title := fmt.Sprintf("gator reading, %s", now.Local().Format("Mon, 02 Jan 2006"))
+	if format == "epub" {
+		err = writeEPUB(file, title, bundle)
+	} else {
+		err = writeHTMLBundle(file, title, bundle)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write %s: %w", outPath, err)
+	}
+
+	fmt.Printf("Wrote %d %s to %s\n", len(bundle), kind, outPath)
+	return nil
+}
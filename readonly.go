@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var errReadOnly = errors.New("gator is in read-only mode, this command would change the database")
+
+// middlewareWrites marks a command as changing the database, so it is
+// refused in read-only mode
+func middlewareWrites(handler func(s *state, cmd command) error) func(*state, command) error {
+	return func(s *state, cmd command) error {
+		if s.readOnly {
+			return fmt.Errorf("%s: %w", cmd.name, errReadOnly)
+		}
+		return handler(s, cmd)
+	}
+}
+
+// extractReadOnlyFlag removes --read-only from args and reports whether it
+// was given
+func extractReadOnlyFlag(args []string) ([]string, bool) {
+	readOnly := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--read-only" {
+			readOnly = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, readOnly
+}
+
+// readOnlyDSN makes every session opened with dsn read-only on the server
+// as well, so a write that slips past the command checks still fails.
+// Parameters pq doesn't know itself are passed on to the server.
+func readOnlyDSN(dsn string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("default_transaction_read_only", "on")
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	return dsn + " default_transaction_read_only=on", nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// Rule actions. They only act for the rule's owner: skip hides the post
+// from them, the feed's other followers still see it.
+const (
+	ruleActionSkip     = "skip"
+	ruleActionBookmark = "bookmark"
+	ruleActionTag      = "tag"
+	ruleActionMarkRead = "mark-read"
+)
+
+// postRule is a rule with its pattern compiled
+type postRule struct {
+	database.Rule
+	re *regexp.Regexp
+}
+
+// compileRulePattern matches rule patterns against titles and descriptions
+// ignoring case
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func (r postRule) matches(title, description string) bool {
+	return r.re.MatchString(title) || r.re.MatchString(description)
+}
+
+// loadRules returns the rules that apply to posts from feed. Rules whose
+// pattern no longer compiles are reported and left out.
+func loadRules(s *state, feed database.Feed) []postRule {
+	rows, err := s.db.GetRulesForFeed(context.Background(), uuid.NullUUID{UUID: feed.ID, Valid: true})
+	if err != nil {
+		slog.Error("couldn't get rules", "feed", feed.Name, "err", err)
+		return nil
+	}
+
+	rules := make([]postRule, 0, len(rows))
+	for _, row := range rows {
+		re, err := compileRulePattern(row.Pattern)
+		if err != nil {
+			slog.Warn("ignoring rule", "pattern", row.Pattern, "err", err)
+			continue
+		}
+		rules = append(rules, postRule{Rule: row, re: re})
+	}
+	return rules
+}
+
+// applyRules runs the rules matching a post that was just stored.
+// description is the one from the feed, the post may have been stored
+// without it. A user whose skip rule matches gets the post hidden and none
+// of their other rules run on it.
+func applyRules(s *state, rules []postRule, post database.Post, description string) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	hidden := map[uuid.UUID]bool{}
+	for _, rule := range rules {
+		if rule.Action != ruleActionSkip || hidden[rule.UserID] || !rule.matches(post.Title, description) {
+			continue
+		}
+		err := s.db.HidePost(ctx, database.HidePostParams{
+			UserID:   rule.UserID,
+			PostID:   post.ID,
+			HiddenAt: now,
+		})
+		if err != nil {
+			slog.Error("couldn't apply rule", "action", rule.Action, "title", post.Title, "err", err)
+			continue
+		}
+		hidden[rule.UserID] = true
+	}
+
+	for _, rule := range rules {
+		if rule.Action == ruleActionSkip || hidden[rule.UserID] || !rule.matches(post.Title, description) {
+			continue
+		}
+
+		var err error
+		switch rule.Action {
+		case ruleActionBookmark:
+			_, err = s.db.CreateBookmark(ctx, database.CreateBookmarkParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    rule.UserID,
+				PostID:    post.ID,
+			})
+			if err != nil && strings.Contains(err.Error(), "duplicate key") {
+				// Another rule of the same user bookmarked it already
+				err = nil
+			}
+		case ruleActionTag:
+			_, err = s.db.AddPostTag(ctx, database.AddPostTagParams{
+				UserID:    rule.UserID,
+				PostID:    post.ID,
+				Tag:       rule.Tag.String,
+				CreatedAt: now,
+			})
+		case ruleActionMarkRead:
+			err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+				UserID: rule.UserID,
+				PostID: post.ID,
+				ReadAt: now,
+			})
+		}
+		if err != nil {
+			slog.Error("couldn't apply rule", "action", rule.Action, "title", post.Title, "err", err)
+		}
+	}
+}
+
+// handlerRule dispatches the "rule <subcommand>" family of commands
+func handlerRule(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: add, list, remove")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	if s.readOnly && sub.name != "list" {
+		return fmt.Errorf("rule %s: %w", sub.name, errReadOnly)
+	}
+	switch sub.name {
+	case "add":
+		return handlerRuleAdd(s, sub, user)
+	case "list":
+		return handlerRuleList(s, sub, user)
+	case "remove":
+		return handlerRuleRemove(s, sub, user)
+	default:
+		return fmt.Errorf("unknown rule subcommand: %s", sub.name)
+	}
+}
+
+func handlerRuleAdd(s *state, cmd command, user database.User) error {
+	pattern := ""
+	action := ""
+	tag := ""
+	feedURL := ""
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--match=") {
+			pattern = strings.TrimPrefix(arg, "--match=")
+		} else if strings.HasPrefix(arg, "--action=") {
+			action = strings.TrimPrefix(arg, "--action=")
+		} else if strings.HasPrefix(arg, "--tag=") {
+			tag = normalizeTag(strings.TrimPrefix(arg, "--tag="))
+		} else if strings.HasPrefix(arg, "--feed=") {
+			feedURL = strings.TrimPrefix(arg, "--feed=")
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+	if pattern == "" || action == "" {
+		return errors.New("usage: gator rule add --match=<regexp> --action=skip|bookmark|tag|mark-read [--tag=<tag>] [--feed=<url>]")
+	}
+
+	if _, err := compileRulePattern(pattern); err != nil {
+		return fmt.Errorf("invalid --match: %w", err)
+	}
+	switch action {
+	case ruleActionSkip, ruleActionBookmark, ruleActionMarkRead:
+		if tag != "" {
+			return errors.New("--tag only goes with --action=tag")
+		}
+	case ruleActionTag:
+		if _, err := parseTags([]string{tag}); err != nil {
+			return errors.New("--action=tag needs a valid --tag")
+		}
+	default:
+		return fmt.Errorf("unknown action %q, use skip, bookmark, tag or mark-read", action)
+	}
+
+	feedID := uuid.NullUUID{}
+	scope := "feeds you follow"
+	if feedURL != "" {
+		feed, err := s.db.GetFeedByURL(context.Background(), feedURL)
+		if err != nil {
+			return fmt.Errorf("couldn't find feed: %w", err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		scope = feed.Name
+	}
+
+	_, err := s.db.CreateRule(context.Background(), database.CreateRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Pattern:   pattern,
+		Action:    action,
+		Tag:       sql.NullString{String: tag, Valid: tag != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create rule: %w", err)
+	}
+
+	fmt.Printf("New posts from %s matching %q will be handled with: %s\n", scope, pattern, describeRuleAction(action, tag))
+	return nil
+}
+
+func describeRuleAction(action, tag string) string {
+	if action == ruleActionTag {
+		return "tag " + tag
+	}
+	return action
+}
+
+func handlerRuleList(s *state, cmd command, user database.User) error {
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get rules: %w", err)
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules yet. Add one with: gator rule add --match=<regexp> --action=<action>")
+		return nil
+	}
+
+	fmt.Printf("Your %d rule(s):\n", len(rules))
+	for i, rule := range rules {
+		scope := "all followed feeds"
+		if rule.FeedID.Valid {
+			scope = rule.FeedName
+		}
+		fmt.Printf("%d. %q -> %s (%s)\n", i+1, rule.Pattern, describeRuleAction(rule.Action, rule.Tag.String), scope)
+	}
+	return nil
+}
+
+// handlerRuleRemove deletes a rule by its number in rule list
+func handlerRuleRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator rule remove <number>")
+	}
+	n, err := strconv.Atoi(cmd.args[0])
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid rule number: %s", cmd.args[0])
+	}
+
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get rules: %w", err)
+	}
+	if n > len(rules) {
+		return fmt.Errorf("no rule #%d, you have %d", n, len(rules))
+	}
+	rule := rules[n-1]
+
+	if _, err := s.db.DeleteRule(context.Background(), database.DeleteRuleParams{
+		ID:     rule.ID,
+		UserID: user.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't remove rule: %w", err)
+	}
+
+	fmt.Printf("Removed rule %q -> %s\n", rule.Pattern, describeRuleAction(rule.Action, rule.Tag.String))
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const (
+	defaultMinFetchInterval = 15 * time.Minute
+	defaultMaxFetchInterval = 24 * time.Hour
+	defaultFetchTimeout     = 30 * time.Second
+	defaultHostInterval     = time.Second
+
+	// minFeedInterval is the least time between two fetches of the same
+	// feed, whatever min_fetch_interval or feed set-interval ask for
+	minFeedInterval = 5 * time.Minute
+
+	// defaultRateLimitDelay is how long a feed answering 429 without
+	// Retry-After waits, maxRateLimitDelay caps what Retry-After can ask
+	defaultRateLimitDelay = time.Hour
+	maxRateLimitDelay     = 7 * 24 * time.Hour
+
+	// cadenceWindow is how far back posts are counted to learn how often a
+	// feed publishes
+	cadenceWindow = 30 * 24 * time.Hour
+)
+
+// fetchBounds returns the configured minimum and maximum time between two
+// fetches of the same feed
+func fetchBounds(cfg *config.Config) (time.Duration, time.Duration, error) {
+	minInterval, maxInterval := defaultMinFetchInterval, defaultMaxFetchInterval
+
+	if cfg.MinFetchInterval != "" {
+		d, err := time.ParseDuration(cfg.MinFetchInterval)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid min_fetch_interval: %w", err)
+		}
+		minInterval = d
+	}
+	if cfg.MaxFetchInterval != "" {
+		d, err := time.ParseDuration(cfg.MaxFetchInterval)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid max_fetch_interval: %w", err)
+		}
+		maxInterval = d
+	}
+
+	if minInterval > maxInterval {
+		return 0, 0, fmt.Errorf("min_fetch_interval %s is greater than max_fetch_interval %s", minInterval, maxInterval)
+	}
+	return max(minInterval, minFeedInterval), max(maxInterval, minFeedInterval), nil
+}
+
+// fetchTimeout returns how long a single fetch may take before it is
+// abandoned and its slot goes to the next feed
+func fetchTimeout(cfg *config.Config) (time.Duration, error) {
+	if cfg.FetchTimeout == "" {
+		return defaultFetchTimeout, nil
+	}
+	d, err := time.ParseDuration(cfg.FetchTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fetch_timeout: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("fetch_timeout must be positive, got %s", d)
+	}
+	return d, nil
+}
+
+// hostRequestInterval returns the minimum time between two fetches from the
+// same host, 0 turns the limit off
+func hostRequestInterval(cfg *config.Config) (time.Duration, error) {
+	if cfg.HostRequestInterval == "" {
+		return defaultHostInterval, nil
+	}
+	d, err := time.ParseDuration(cfg.HostRequestInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid host_request_interval: %w", err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("host_request_interval can't be negative, got %s", d)
+	}
+	return d, nil
+}
+
+// fetchInterval aims for roughly one new post per fetch: a feed publishing
+// 24 posts a day is polled hourly, one publishing weekly hits the maximum
+func fetchInterval(postsPerDay float64, minInterval, maxInterval time.Duration) time.Duration {
+	if postsPerDay <= 0 {
+		return maxInterval
+	}
+
+	interval := time.Duration(float64(24*time.Hour) / postsPerDay)
+	if interval < minInterval {
+		return minInterval
+	}
+	if interval > maxInterval {
+		return maxInterval
+	}
+	return interval
+}
+
+// scheduleNextFetch sets when feed is next due: after its fixed interval if
+// one is set, otherwise based on its posting cadence over the trailing month,
+// but not before atLeast
+func scheduleNextFetch(s *state, sc *scraper, feed database.Feed, atLeast time.Duration) {
+	if feed.FetchIntervalSeconds.Valid {
+		setNextFetch(s, feed, max(time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second, atLeast))
+		return
+	}
+
+	count, err := s.db.CountPostsForFeedSince(context.Background(), database.CountPostsForFeedSinceParams{
+		FeedID:      feed.ID,
+		PublishedAt: sql.NullTime{Time: time.Now().UTC().Add(-cadenceWindow), Valid: true},
+	})
+	if err != nil {
+		slog.Error("couldn't count feed posts", "feed", feed.Name, "err", err)
+		return
+	}
+
+	postsPerDay := float64(count) / cadenceWindow.Hours() * 24
+	setNextFetch(s, feed, max(fetchInterval(postsPerDay, sc.minInterval, sc.maxInterval), atLeast))
+}
+
+// rateLimitDelay is how long a feed that answered 429 waits: as long as
+// its Retry-After asked, within reason, and never less than a usual fetch
+func rateLimitDelay(sc *scraper, fetchErr error) time.Duration {
+	delay := defaultRateLimitDelay
+	var statusErr *rss.StatusError
+	if errors.As(fetchErr, &statusErr) && statusErr.RetryAfter > 0 {
+		delay = min(statusErr.RetryAfter, maxRateLimitDelay)
+	}
+	return max(delay, sc.minInterval)
+}
+
+// unavailableDelay is how long a feed that answered 503 asked to be left
+// alone with Retry-After, within reason, and 0 for any other error
+func unavailableDelay(fetchErr error) time.Duration {
+	var statusErr *rss.StatusError
+	if errors.As(fetchErr, &statusErr) && statusErr.StatusCode == http.StatusServiceUnavailable {
+		return min(statusErr.RetryAfter, maxRateLimitDelay)
+	}
+	return 0
+}
+
+func setNextFetch(s *state, feed database.Feed, interval time.Duration) {
+	interval = max(interval, minFeedInterval)
+	err := s.db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+		ID:          feed.ID,
+		NextFetchAt: sql.NullTime{Time: time.Now().UTC().Add(interval), Valid: true},
+	})
+	if err != nil {
+		slog.Error("couldn't schedule feed", "feed", feed.Name, "err", err)
+	}
+}
+
+// handlerFeedSetInterval fixes how often a feed is fetched, or with "auto"
+// goes back to following its posting cadence
+func handlerFeedSetInterval(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return errors.New("usage: gator feed set-interval <url> <duration|auto>")
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkCanManageFeed(s, feed, user); err != nil {
+		return err
+	}
+
+	interval := sql.NullInt32{}
+	if cmd.args[1] != "auto" {
+		d, err := time.ParseDuration(cmd.args[1])
+		if err != nil || d < minFeedInterval {
+			return fmt.Errorf("invalid interval %q, use a duration of at least %s (e.g. 30m, 6h) or auto", cmd.args[1], minFeedInterval)
+		}
+		interval = sql.NullInt32{Int32: int32(d.Seconds()), Valid: true}
+	}
+
+	err = s.db.SetFeedFetchInterval(ctx, database.SetFeedFetchIntervalParams{
+		ID:                   feed.ID,
+		FetchIntervalSeconds: interval,
+		UpdatedAt:            time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set fetch interval: %w", err)
+	}
+
+	// Apply the new interval from now on rather than after the next fetch
+	feed.FetchIntervalSeconds = interval
+	if interval.Valid {
+		setNextFetch(s, feed, time.Duration(interval.Int32)*time.Second)
+		fmt.Printf("%s will be fetched every %s\n", feed.Name, cmd.args[1])
+	} else {
+		fmt.Printf("%s will be fetched according to how often it publishes\n", feed.Name)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrations are the goose migrations this build was written against, the
+// expected schema is rebuilt from them rather than kept in a second place
+//
+//go:embed sql/schema/*.sql
+var migrations embed.FS
+
+// schemaCheckTimeout bounds the startup check, so an unreachable database
+// fails in the command that needs it instead of hanging here
+const schemaCheckTimeout = 5 * time.Second
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)\s*\((.*?)\n\);`)
+	addColumnRe   = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+	dropColumnRe  = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\w+)\s+DROP\s+COLUMN\s+(?:IF\s+EXISTS\s+)?(\w+)`)
+	dropTableRe   = regexp.MustCompile(`(?i)DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\w+)`)
+	leadingWordRe = regexp.MustCompile(`^\w+`)
+)
+
+// expectedSchema is the migration version and the columns of each table
+// the code expects
+type expectedSchema struct {
+	version int64
+	tables  map[string]map[string]bool
+}
+
+// loadExpectedSchema replays the Up sections of the embedded migrations
+func loadExpectedSchema() (expectedSchema, error) {
+	names, err := migrations.ReadDir("sql/schema")
+	if err != nil {
+		return expectedSchema{}, err
+	}
+
+	schema := expectedSchema{tables: map[string]map[string]bool{}}
+	// ReadDir sorts by name, and the numbered prefixes sort by version
+	for _, entry := range names {
+		name := entry.Name()
+		version, err := strconv.ParseInt(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		data, err := migrations.ReadFile(path.Join("sql/schema", name))
+		if err != nil {
+			return expectedSchema{}, err
+		}
+		up := string(data)
+		if i := strings.Index(up, "-- +goose Down"); i >= 0 {
+			up = up[:i]
+		}
+
+		for _, m := range createTableRe.FindAllStringSubmatch(up, -1) {
+			columns := map[string]bool{}
+			for _, line := range strings.Split(m[2], "\n") {
+				word := leadingWordRe.FindString(strings.TrimSpace(line))
+				switch strings.ToUpper(word) {
+				case "", "PRIMARY", "UNIQUE", "FOREIGN", "CONSTRAINT", "CHECK", "EXCLUDE":
+					continue
+				}
+				columns[strings.ToLower(word)] = true
+			}
+			schema.tables[strings.ToLower(m[1])] = columns
+		}
+		for _, m := range addColumnRe.FindAllStringSubmatch(up, -1) {
+			if columns, ok := schema.tables[strings.ToLower(m[1])]; ok {
+				columns[strings.ToLower(m[2])] = true
+			}
+		}
+		for _, m := range dropColumnRe.FindAllStringSubmatch(up, -1) {
+			if columns, ok := schema.tables[strings.ToLower(m[1])]; ok {
+				delete(columns, strings.ToLower(m[2]))
+			}
+		}
+		for _, m := range dropTableRe.FindAllStringSubmatch(up, -1) {
+			delete(schema.tables, strings.ToLower(m[1]))
+		}
+
+		if version > schema.version {
+			schema.version = version
+		}
+	}
+	return schema, nil
+}
+
+// checkSchema compares the connected database with the migrations this
+// build expects. It returns a description of every difference, empty when
+// the database is usable, and an error only when the check itself couldn't
+// run.
+func checkSchema(db *sql.DB) ([]string, error) {
+	expected, err := loadExpectedSchema()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read migrations: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaCheckTimeout)
+	defer cancel()
+
+	var problems []string
+
+	var applied sql.NullInt64
+	err = db.QueryRowContext(ctx, "SELECT max(version_id) FROM goose_db_version WHERE is_applied").Scan(&applied)
+	if err != nil && !strings.Contains(err.Error(), "does not exist") {
+		return nil, err
+	}
+	switch {
+	case !applied.Valid:
+		problems = append(problems, "no migrations have been applied")
+	case applied.Int64 < expected.version:
+		problems = append(problems, fmt.Sprintf("database is at migration %d, this gator needs %d", applied.Int64, expected.version))
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := map[string]map[string]bool{}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if actual[table] == nil {
+			actual[table] = map[string]bool{}
+		}
+		actual[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(expected.tables))
+	for table := range expected.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if actual[table] == nil {
+			problems = append(problems, fmt.Sprintf("missing table %s", table))
+			continue
+		}
+		var missing []string
+		for column := range expected.tables[table] {
+			if !actual[table][column] {
+				missing = append(missing, column)
+			}
+		}
+		sort.Strings(missing)
+		for _, column := range missing {
+			problems = append(problems, fmt.Sprintf("missing column %s.%s", table, column))
+		}
+	}
+
+	return problems, nil
+}
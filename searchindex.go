@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/search"
+)
+
+const (
+	// reindexBatchSize is how many posts reindex sends to the backend at once
+	reindexBatchSize = 500
+
+	// unreadOverfetch is how many times the limit of hits are asked from the
+	// backend for --unread, as read posts are only dropped afterwards
+	unreadOverfetch = 5
+)
+
+// newSearchBackend returns the configured external search backend, or nil
+// when search runs on the database alone
+func newSearchBackend(cfg *config.Config) (search.Backend, error) {
+	if cfg.SearchBackend == nil {
+		return nil, nil
+	}
+	backend, err := search.New(cfg.SearchBackend.Type, cfg.SearchBackend.URL, cfg.SearchBackend.Index, cfg.SearchBackend.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search_backend: %w", err)
+	}
+	return backend, nil
+}
+
+func searchDocument(id, feedID uuid.UUID, feedName, title string, description sql.NullString, url string, published sql.NullTime) search.Document {
+	doc := search.Document{
+		ID:          id.String(),
+		FeedID:      feedID.String(),
+		FeedName:    feedName,
+		Title:       title,
+		Description: description.String,
+		URL:         url,
+	}
+	if published.Valid {
+		doc.PublishedAt = published.Time.Unix()
+	}
+	return doc
+}
+
+// indexPosts adds the posts just ingested from feed to the search backend.
+// Posts that fail to index are still stored, reindex catches them up.
+func indexPosts(sc *scraper, feed database.Feed, posts []database.Post) {
+	if sc.search == nil || len(posts) == 0 {
+		return
+	}
+
+	docs := make([]search.Document, len(posts))
+	for i, post := range posts {
+		docs[i] = searchDocument(post.ID, feed.ID, feed.Name, post.Title, post.Description, post.Url, post.PublishedAt)
+	}
+	if err := sc.search.Index(context.Background(), docs); err != nil {
+		slog.Error("couldn't index posts", "feed", feed.Name, "err", err)
+	}
+}
+
+// searchExternal runs query against the search backend and loads the
+// matching posts the user can see, in the backend's order. Feeds and dates
+// are filtered by the backend, read state only by the database, so more
+// hits are asked for when filtering on it.
+func searchExternal(s *state, backend search.Backend, user database.User, query string, filter postFilter, limit int) ([]database.SearchPostsForUserRow, error) {
+	ctx := context.Background()
+	feedIDs, err := s.db.GetFollowedFeedIDs(ctx, database.GetFollowedFeedIDsParams{
+		UserID:  user.ID,
+		Column2: filter.Feeds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get followed feeds: %w", err)
+	}
+
+	backendFilter := search.Filter{FeedIDs: make([]string, len(feedIDs))}
+	for i, id := range feedIDs {
+		backendFilter.FeedIDs[i] = id.String()
+	}
+	if filter.Since.Valid {
+		backendFilter.Since = filter.Since.Time
+	}
+	if filter.Until.Valid {
+		backendFilter.Until = filter.Until.Time
+	}
+
+	hitLimit := limit
+	if filter.Unread {
+		hitLimit *= unreadOverfetch
+	}
+	hits, err := backend.Search(ctx, query, backendFilter, hitLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(hits))
+	for _, hit := range hits {
+		// The index may hold posts that were deleted since, or IDs from
+		// something else entirely
+		if id, err := uuid.Parse(hit); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	rows, err := s.db.GetPostsForUserByIDs(ctx, database.GetPostsForUserByIDsParams{
+		UserID:  user.ID,
+		Column2: ids,
+		Column3: filter.Unread,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get posts: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]database.SearchPostsForUserRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = database.SearchPostsForUserRow(row)
+	}
+	posts := make([]database.SearchPostsForUserRow, 0, len(rows))
+	for _, id := range ids {
+		if post, ok := byID[id]; ok && len(posts) < limit {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// handlerReindex sets up the search backend's index and sends it every
+// stored post, for a new backend or one that fell behind
+func handlerReindex(s *state, cmd command) error {
+	backend, err := newSearchBackend(s.cfg)
+	if err != nil {
+		return err
+	}
+	if backend == nil {
+		return errors.New("no search_backend is configured")
+	}
+
+	ctx := context.Background()
+	if err := backend.Setup(ctx); err != nil {
+		return fmt.Errorf("couldn't set up search index: %w", err)
+	}
+
+	indexed := 0
+	for {
+		posts, err := s.db.GetPostsForIndex(ctx, database.GetPostsForIndexParams{
+			Limit:  reindexBatchSize,
+			Offset: int32(indexed),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get posts: %w", err)
+		}
+		if len(posts) == 0 {
+			break
+		}
+
+		docs := make([]search.Document, len(posts))
+		for i, post := range posts {
+			docs[i] = searchDocument(post.ID, post.FeedID, post.FeedName, post.Title, post.Description, post.Url, post.PublishedAt)
+		}
+		if err := backend.Index(ctx, docs); err != nil {
+			return fmt.Errorf("couldn't index posts: %w", err)
+		}
+
+		indexed += len(posts)
+		fmt.Printf("Indexed %d posts\n", indexed)
+	}
+
+	fmt.Printf("Reindexed %d posts\n", indexed)
+	return nil
+}
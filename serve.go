@@ -0,0 +1,735 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+const (
+	defaultServeAddr = ":8080"
+	apiDefaultLimit  = 50
+	apiMaxLimit      = 500
+	apiMaxBody       = 1 << 20
+)
+
+// apiServer answers the HTTP API of gator serve, acting for the user whose
+// token comes with each request
+type apiServer struct {
+	s *state
+}
+
+// apiError is an error with the HTTP status to answer it with. Any other
+// error a handler returns is a 500.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e apiError) Error() string {
+	return e.message
+}
+
+type apiFeed struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	Category   string     `json:"category,omitempty"`
+	Owner      string     `json:"owner,omitempty"`
+	FollowedAt *time.Time `json:"followed_at,omitempty"`
+}
+
+// apiPost is a post as the API returns it. IsRead and IsBookmarked are
+// left out where the listing doesn't know them.
+type apiPost struct {
+	ID           uuid.UUID  `json:"id"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	CanonicalURL string     `json:"canonical_url,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	ImageURL     string     `json:"image_url,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	FeedID       uuid.UUID  `json:"feed_id"`
+	FeedName     string     `json:"feed_name"`
+	IsRead       *bool      `json:"is_read,omitempty"`
+	IsBookmarked *bool      `json:"is_bookmarked,omitempty"`
+	BookmarkedAt *time.Time `json:"bookmarked_at,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func handlerServe(s *state, cmd command) error {
+	addr := defaultServeAddr
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--addr=") {
+			addr = strings.TrimPrefix(arg, "--addr=")
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+	if len(s.cfg.APITokens) == 0 {
+		count, err := s.db.CountAPITokens(context.Background())
+		if err != nil {
+			return fmt.Errorf("couldn't count API tokens: %w", err)
+		}
+		if count == 0 {
+			return errors.New("no API tokens yet, create one with: gator token create <name>")
+		}
+	}
+
+	a := &apiServer{s: s}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           a.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	watchSQLTraceToggle(s)
+	slog.Info("serving API", "addr", addr, "read_only", s.readOnly)
+	return server.ListenAndServe()
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/feeds", a.authed(a.handleFeeds))
+	mux.HandleFunc("GET /api/follows", a.authed(a.handleFollows))
+	mux.HandleFunc("POST /api/follows", a.writes(a.handleFollow))
+	mux.HandleFunc("DELETE /api/follows", a.writes(a.handleUnfollow))
+	mux.HandleFunc("GET /api/posts", a.authed(a.handlePosts))
+	mux.HandleFunc("GET /api/search", a.authed(a.handleSearch))
+	mux.HandleFunc("GET /api/bookmarks", a.authed(a.handleBookmarks))
+	mux.HandleFunc("PUT /api/posts/{id}/bookmark", a.writes(a.handleBookmark))
+	mux.HandleFunc("DELETE /api/posts/{id}/bookmark", a.writes(a.handleUnbookmark))
+	mux.HandleFunc("PUT /api/posts/{id}/read", a.writes(a.handleRead))
+	mux.HandleFunc("DELETE /api/posts/{id}/read", a.writes(a.handleUnread))
+	mux.HandleFunc("POST /api/mark-read", a.writes(a.handleMarkRead))
+	mux.HandleFunc("/fever", a.handleFever)
+	mux.HandleFunc("/fever/", a.handleFever)
+	a.greaderRoutes(mux)
+	a.webRoutes(mux)
+	a.sharedRoutes(mux)
+	return mux
+}
+
+// authed is the API's middlewareLoggedIn: it finds the user for the
+// request's bearer token and turns the handler's error into a response
+func (a *apiServer) authed(handler func(w http.ResponseWriter, r *http.Request, user database.User) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := a.userFor(r)
+		if err == nil {
+			err = handler(w, r, user)
+		}
+		if err == nil {
+			return
+		}
+
+		var apiErr apiError
+		if !errors.As(err, &apiErr) {
+			slog.Error("couldn't answer API request", "method", r.Method, "path", r.URL.Path, "err", err)
+			apiErr = apiError{http.StatusInternalServerError, "internal error"}
+		}
+		if apiErr.status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+		}
+		writeJSON(w, apiErr.status, map[string]string{"error": apiErr.message})
+	}
+}
+
+// writes is the API's middlewareWrites, refusing requests that would
+// change the database in read-only mode
+func (a *apiServer) writes(handler func(w http.ResponseWriter, r *http.Request, user database.User) error) http.HandlerFunc {
+	return a.authed(func(w http.ResponseWriter, r *http.Request, user database.User) error {
+		if a.s.readOnly {
+			return apiError{http.StatusForbidden, errReadOnly.Error()}
+		}
+		return handler(w, r, user)
+	})
+}
+
+// userFor looks up the user whose token is in the Authorization header
+func (a *apiServer) userFor(r *http.Request) (database.User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return database.User{}, apiError{http.StatusUnauthorized, "missing bearer token"}
+	}
+	return a.userForToken(r.Context(), token)
+}
+
+// userForToken looks up the user an API token acts as: one made with gator
+// token create, or one of the api_tokens in the config file. Every
+// configured token is compared so the time taken doesn't give away how
+// much of one matched.
+func (a *apiServer) userForToken(ctx context.Context, token string) (database.User, error) {
+	hash := hashAPIToken(token)
+	user, err := a.s.db.GetUserByAPITokenHash(ctx, hash)
+	if err == nil && !user.IsSystem {
+		a.touchToken(ctx, hash)
+		return user, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("couldn't get user: %w", err)
+	}
+
+	name := ""
+	for t, userName := range a.s.cfg.APITokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			name = userName
+		}
+	}
+	if name == "" {
+		return database.User{}, apiError{http.StatusUnauthorized, "invalid token"}
+	}
+
+	user, err = a.s.db.GetUserByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.IsSystem) {
+		return database.User{}, apiError{http.StatusUnauthorized, "the token's user doesn't exist"}
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("couldn't get user: %w", err)
+	}
+	return user, nil
+}
+
+// touchToken records that the token with the given hash, or Fever key
+// hash, was used. It's only bookkeeping, so failures are just logged.
+func (a *apiServer) touchToken(ctx context.Context, hash string) {
+	if a.s.readOnly {
+		return
+	}
+	err := a.s.db.TouchAPIToken(ctx, database.TouchAPITokenParams{
+		TokenHash:  hash,
+		LastUsedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		slog.Warn("couldn't record token use", "err", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("couldn't write API response", "err", err)
+	}
+}
+
+func readJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, apiMaxBody)).Decode(v); err != nil {
+		return apiError{http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err)}
+	}
+	return nil
+}
+
+// queryLimit reads the limit query parameter, capped at apiMaxLimit
+func queryLimit(r *http.Request) (int32, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return apiDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, apiError{http.StatusBadRequest, "invalid limit: " + value}
+	}
+	return int32(min(limit, apiMaxLimit)), nil
+}
+
+// visiblePost finds the post named by the id path parameter among the
+// posts user can see
+func (a *apiServer) visiblePost(r *http.Request, user database.User) (database.GetPostsForUserByIDsRow, error) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return database.GetPostsForUserByIDsRow{}, apiError{http.StatusBadRequest, "invalid post id"}
+	}
+	posts, err := a.s.db.GetPostsForUserByIDs(r.Context(), database.GetPostsForUserByIDsParams{
+		UserID:  user.ID,
+		Column2: []uuid.UUID{id},
+	})
+	if err != nil {
+		return database.GetPostsForUserByIDsRow{}, fmt.Errorf("couldn't get post: %w", err)
+	}
+	if len(posts) == 0 {
+		return database.GetPostsForUserByIDsRow{}, apiError{http.StatusNotFound, "post not found"}
+	}
+	return posts[0], nil
+}
+
+func (a *apiServer) handleFeeds(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feeds, err := a.s.db.GetFeedsWithUsers(r.Context())
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds: %w", err)
+	}
+	result := make([]apiFeed, len(feeds))
+	for i, feed := range feeds {
+		result[i] = apiFeed{
+			ID:    feed.ID,
+			Name:  feed.FeedName,
+			URL:   feed.FeedUrl,
+			Owner: feed.UserName,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+	return nil
+}
+
+func (a *apiServer) handleFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feeds, err := a.s.db.GetFollowedFeedsForUser(r.Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+	result := make([]apiFeed, len(feeds))
+	for i, feed := range feeds {
+		result[i] = apiFeed{
+			ID:         feed.ID,
+			Name:       feed.Name,
+			URL:        feed.Url,
+			Category:   feed.Category.String,
+			FollowedAt: &feed.FollowedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+	return nil
+}
+
+func (a *apiServer) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		return err
+	}
+	if body.URL == "" {
+		return apiError{http.StatusBadRequest, "url is required"}
+	}
+
+	feed, err := a.s.db.GetFeedByURL(r.Context(), body.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError{http.StatusNotFound, "feed not found, add it with gator addfeed first"}
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	if err := checkFeedPolicy(a.s.cfg, feed.Url); err != nil {
+		return apiError{http.StatusForbidden, err.Error()}
+	}
+
+	now := time.Now().UTC()
+	follow, err := a.s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "feed_follows_user_id_feed_id_key") {
+			return apiError{http.StatusConflict, "you are already following this feed"}
+		}
+		return fmt.Errorf("couldn't create feed follow: %w", err)
+	}
+
+	writeJSON(w, http.StatusCreated, apiFeed{
+		ID:         feed.ID,
+		Name:       feed.Name,
+		URL:        feed.Url,
+		Category:   follow.Category.String,
+		FollowedAt: &follow.CreatedAt,
+	})
+	return nil
+}
+
+func (a *apiServer) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := r.URL.Query().Get("url")
+	if feedURL == "" {
+		return apiError{http.StatusBadRequest, "url is required"}
+	}
+	err := a.s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		UserID:    user.ID,
+		Url:       feedURL,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unfollow feed: %w", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// handlePosts lists the latest posts like gator browse, taking its options
+// as query parameters
+func (a *apiServer) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	q := r.URL.Query()
+	limit, err := queryLimit(r)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if value := q.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return apiError{http.StatusBadRequest, "invalid offset: " + value}
+		}
+	}
+	exact := q.Get("exact") == "true"
+
+	posts, err := a.s.db.GetPostsForUserWithPagination(r.Context(), database.GetPostsForUserWithPaginationParams{
+		UserID:   user.ID,
+		Column2:  feedPatterns(q.Get("feed"), exact),
+		Column3:  q.Get("sort"),
+		Limit:    limit,
+		Offset:   int32(offset),
+		Column8:  feedPatterns(q.Get("exclude_feed"), exact),
+		Column9:  q.Get("unread") == "true",
+		Column10: normalizeTag(q.Get("tag")),
+		Column11: strings.TrimSpace(q.Get("category")),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get posts: %w", err)
+	}
+
+	result := make([]apiPost, len(posts))
+	for i, p := range posts {
+		title, description, image := previewed(a.s, p.ID, p.Title, p.Description)
+		result[i] = apiPost{
+			ID:           p.ID,
+			Title:        title,
+			URL:          p.Url,
+			CanonicalURL: p.CanonicalUrl.String,
+			Description:  description.String,
+			ImageURL:     image,
+			PublishedAt:  nullTimePtr(p.PublishedAt),
+			FeedID:       p.FeedID,
+			FeedName:     p.FeedName,
+			IsRead:       &p.IsRead,
+			IsBookmarked: &p.IsBookmarked,
+			Tags:         p.Tags,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+	return nil
+}
+
+// handleSearch searches like gator search, on the search backend if one is
+// configured
+func (a *apiServer) handleSearch(w http.ResponseWriter, r *http.Request, user database.User) error {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		return apiError{http.StatusBadRequest, "q is required"}
+	}
+	limit, err := queryLimit(r)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	filter := postFilter{
+		Feeds:  feedPatterns(q.Get("feed"), q.Get("exact") == "true"),
+		Unread: q.Get("unread") == "true",
+	}
+	if value := q.Get("since"); value != "" {
+		t, err := parsePostTime(value, now)
+		if err != nil {
+			return apiError{http.StatusBadRequest, fmt.Sprintf("invalid since: %v", err)}
+		}
+		filter.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	if value := q.Get("until"); value != "" {
+		t, err := parsePostTime(value, now)
+		if err != nil {
+			return apiError{http.StatusBadRequest, fmt.Sprintf("invalid until: %v", err)}
+		}
+		filter.Until = sql.NullTime{Time: t, Valid: true}
+	}
+
+	posts, err := a.search(r.Context(), user, query, filter, limit)
+	if err != nil {
+		return err
+	}
+
+	result := make([]apiPost, len(posts))
+	for i, p := range posts {
+		bookmarked, err := a.s.db.IsPostBookmarked(r.Context(), database.IsPostBookmarkedParams{
+			UserID: user.ID,
+			PostID: p.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't check bookmark status: %w", err)
+		}
+		title, description, image := previewed(a.s, p.ID, p.Title, p.Description)
+		result[i] = apiPost{
+			ID:           p.ID,
+			Title:        title,
+			URL:          p.Url,
+			CanonicalURL: p.CanonicalUrl.String,
+			Description:  description.String,
+			ImageURL:     image,
+			PublishedAt:  nullTimePtr(p.PublishedAt),
+			FeedID:       p.FeedID,
+			FeedName:     p.FeedName,
+			IsRead:       &p.IsRead,
+			IsBookmarked: &bookmarked,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+	return nil
+}
+
+// search finds posts on the search backend if one is configured, falling
+// back to the database when there is none or it fails
+func (a *apiServer) search(ctx context.Context, user database.User, query string, filter postFilter, limit int32) ([]database.SearchPostsForUserRow, error) {
+	backend, err := newSearchBackend(a.s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	var posts []database.SearchPostsForUserRow
+	if backend != nil {
+		posts, err = searchExternal(a.s, backend, user, query, filter, int(limit))
+		if err != nil {
+			slog.Warn("search backend failed, searching the database instead", "err", err)
+		}
+	}
+	if backend == nil || err != nil {
+		posts, err = a.s.db.SearchPostsForUser(ctx, database.SearchPostsForUserParams{
+			UserID:        user.ID,
+			Column2:       sql.NullString{String: query, Valid: true},
+			Limit:         limit,
+			Column4:       filter.Feeds,
+			PublishedAt:   filter.Since,
+			PublishedAt_2: filter.Until,
+			Column7:       filter.Unread,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't search posts: %w", err)
+		}
+	}
+	return posts, nil
+}
+
+func (a *apiServer) handleBookmarks(w http.ResponseWriter, r *http.Request, user database.User) error {
+	limit, err := queryLimit(r)
+	if err != nil {
+		return err
+	}
+	bookmarks, err := a.s.db.GetBookmarksForUser(r.Context(), database.GetBookmarksForUserParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get bookmarks: %w", err)
+	}
+
+	bookmarked := true
+	result := make([]apiPost, len(bookmarks))
+	for i, b := range bookmarks {
+		title, description, image := previewed(a.s, b.ID, b.Title, b.Description)
+		result[i] = apiPost{
+			ID:           b.ID,
+			Title:        title,
+			URL:          b.Url,
+			CanonicalURL: b.CanonicalUrl.String,
+			Description:  description.String,
+			ImageURL:     image,
+			PublishedAt:  nullTimePtr(b.PublishedAt),
+			FeedID:       b.FeedID,
+			FeedName:     b.FeedName,
+			IsBookmarked: &bookmarked,
+			BookmarkedAt: &b.BookmarkedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+	return nil
+}
+
+func (a *apiServer) handleBookmark(w http.ResponseWriter, r *http.Request, user database.User) error {
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	if err := setBookmarked(r.Context(), a.s, user, post.ID, true); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (a *apiServer) handleUnbookmark(w http.ResponseWriter, r *http.Request, user database.User) error {
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	if err := setBookmarked(r.Context(), a.s, user, post.ID, false); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// setBookmarked bookmarks a post for user or removes the bookmark, doing
+// nothing if it is already in that state
+func setBookmarked(ctx context.Context, s *state, user database.User, postID uuid.UUID, bookmarked bool) error {
+	if !bookmarked {
+		err := s.db.DeleteBookmark(ctx, database.DeleteBookmarkParams{
+			UserID:    user.ID,
+			PostID:    postID,
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't remove bookmark: %w", err)
+		}
+		return nil
+	}
+
+	isBookmarked, err := s.db.IsPostBookmarked(ctx, database.IsPostBookmarkedParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't check bookmark status: %w", err)
+	}
+	if isBookmarked {
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err = s.db.CreateBookmark(ctx, database.CreateBookmarkParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		PostID:    postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create bookmark: %w", err)
+	}
+	return nil
+}
+
+func (a *apiServer) handleRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	if err := setRead(r.Context(), a.s, user, post.ID, true); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (a *apiServer) handleUnread(w http.ResponseWriter, r *http.Request, user database.User) error {
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	if err := setRead(r.Context(), a.s, user, post.ID, false); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// setRead marks a post read or unread for user
+func setRead(ctx context.Context, s *state, user database.User, postID uuid.UUID, read bool) error {
+	if !read {
+		err := s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: postID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark post as unread: %w", err)
+		}
+		return nil
+	}
+
+	err := s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+		ReadAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark post as read: %w", err)
+	}
+	return nil
+}
+
+// handleMarkRead marks the listed post IDs as read, or like gator
+// mark-read every post of some feeds, older than a duration or all of them
+func (a *apiServer) handleMarkRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		IDs    []uuid.UUID `json:"ids"`
+		Feed   string      `json:"feed"`
+		Exact  bool        `json:"exact"`
+		Before string      `json:"before"`
+		All    bool        `json:"all"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var marked int64
+	if len(body.IDs) > 0 {
+		// Only posts the user can see, which also drops unknown IDs
+		posts, err := a.s.db.GetPostsForUserByIDs(r.Context(), database.GetPostsForUserByIDsParams{
+			UserID:  user.ID,
+			Column2: body.IDs,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get posts: %w", err)
+		}
+		ids := make([]uuid.UUID, len(posts))
+		for i, post := range posts {
+			ids[i] = post.ID
+		}
+		marked, err = a.s.db.MarkPostIDsRead(r.Context(), database.MarkPostIDsReadParams{
+			UserID:  user.ID,
+			ReadAt:  now,
+			Column3: ids,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts as read: %w", err)
+		}
+	} else {
+		var before time.Duration
+		if body.Before != "" {
+			d, err := time.ParseDuration(body.Before)
+			if err != nil || d <= 0 {
+				return apiError{http.StatusBadRequest, "invalid before: " + body.Before}
+			}
+			before = d
+		}
+		// Marking everything read can't be undone, so it has to be asked for
+		if body.Feed == "" && before == 0 && !body.All {
+			return apiError{http.StatusBadRequest, "one of ids, feed, before or all is required"}
+		}
+
+		var err error
+		marked, err = a.s.db.MarkPostsRead(r.Context(), database.MarkPostsReadParams{
+			UserID:  user.ID,
+			ReadAt:  now,
+			Column3: feedPatterns(body.Feed, body.Exact),
+			Column4: now.Add(-before),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts as read: %w", err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int64{"marked": marked})
+	return nil
+}
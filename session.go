@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// readingSession keeps track of a time-boxed TUI session, see tui --session
+type readingSession struct {
+	budget  time.Duration
+	started time.Time
+	opened  map[uuid.UUID]bool
+	read    map[uuid.UUID]bool
+}
+
+func newReadingSession(budget time.Duration) *readingSession {
+	return &readingSession{
+		budget:  budget,
+		started: time.Now(),
+		opened:  map[uuid.UUID]bool{},
+		read:    map[uuid.UUID]bool{},
+	}
+}
+
+// status is the header line showing how much of the budget is used
+func (rs *readingSession) status() string {
+	used := time.Since(rs.started).Round(time.Minute)
+	return fmt.Sprintf("Session: %s of %s used", formatMinutes(used), formatMinutes(rs.budget))
+}
+
+// warning nudges the reader once the last tenth of the budget, or its last
+// minute for short sessions, has started. It is empty before that.
+func (rs *readingSession) warning() string {
+	left := rs.budget - time.Since(rs.started)
+	nearly := max(rs.budget/10, time.Minute)
+	switch {
+	case left <= 0:
+		return fmt.Sprintf("Your %s are up, time to take a break.", formatMinutes(rs.budget))
+	case left <= nearly:
+		return fmt.Sprintf("Only %s left in this session.", formatMinutes(left.Round(time.Minute)))
+	default:
+		return ""
+	}
+}
+
+// report summarizes the session when the TUI exits
+func (rs *readingSession) report() {
+	spent := time.Since(rs.started).Round(time.Second)
+	fmt.Printf("Session over after %s (budget %s): %d post(s) read, %d opened in the browser\n",
+		spent, formatMinutes(rs.budget), len(rs.read), len(rs.opened))
+}
+
+// formatMinutes renders a whole number of minutes such as "20m" or "1h5m"
+// without the trailing seconds time.Duration prints
+func formatMinutes(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
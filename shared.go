@@ -0,0 +1,154 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+)
+
+var webCollectionTmpl = template.Must(template.ParseFS(webFiles, "web/layout.html", "web/collection.html")).Lookup("collection.html")
+
+// sharedCollectionSize is how many bookmarks a shared collection shows
+const sharedCollectionSize = 200
+
+type webCollectionPage struct {
+	Name        string
+	Description string
+	User        string
+	FeedURL     string
+	Posts       []webPost
+}
+
+// sharedRSS is the RSS 2.0 document of a shared collection
+type sharedRSS struct {
+	XMLName xml.Name         `xml:"rss"`
+	Version string           `xml:"version,attr"`
+	Channel sharedRSSChannel `xml:"channel"`
+}
+
+type sharedRSSChannel struct {
+	Title       string          `xml:"title"`
+	Link        string          `xml:"link"`
+	Description string          `xml:"description"`
+	Items       []sharedRSSItem `xml:"item"`
+}
+
+type sharedRSSItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+// sharedRoutes adds the public, read-only pages of shared collections.
+// The share token in the path is all the access they need.
+func (a *apiServer) sharedRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /shared/{token}", a.shared(a.handleSharedPage))
+	mux.HandleFunc("GET /shared/{token}/rss", a.shared(a.handleSharedRSS))
+}
+
+// shared looks up the collection of the share token and its bookmarks for
+// handler, answering errors as plain text
+func (a *apiServer) shared(handler func(w http.ResponseWriter, r *http.Request, c database.GetSharedCollectionRow, posts []database.GetBookmarksForUserRow) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := func() error {
+			c, err := a.s.db.GetSharedCollection(r.Context(), sql.NullString{String: r.PathValue("token"), Valid: true})
+			if errors.Is(err, sql.ErrNoRows) {
+				return apiError{http.StatusNotFound, "no such collection, it may no longer be shared"}
+			}
+			if err != nil {
+				return fmt.Errorf("couldn't get collection: %w", err)
+			}
+			posts, err := a.s.db.GetBookmarksForUser(r.Context(), database.GetBookmarksForUserParams{
+				UserID:  c.UserID,
+				Limit:   sharedCollectionSize,
+				Column3: c.Tag,
+				Column4: "manual",
+			})
+			if err != nil {
+				return fmt.Errorf("couldn't get bookmarks: %w", err)
+			}
+			return handler(w, r, c, posts)
+		}()
+		if err == nil {
+			return
+		}
+
+		var apiErr apiError
+		if !errors.As(err, &apiErr) {
+			slog.Error("couldn't answer shared collection request", "path", r.URL.Path, "err", err)
+			apiErr = apiError{http.StatusInternalServerError, "internal error"}
+		}
+		http.Error(w, apiErr.message, apiErr.status)
+	}
+}
+
+func (a *apiServer) handleSharedPage(w http.ResponseWriter, r *http.Request, c database.GetSharedCollectionRow, posts []database.GetBookmarksForUserRow) error {
+	page := webCollectionPage{
+		Name:        c.Name,
+		Description: c.Description,
+		User:        c.UserName,
+		FeedURL:     r.URL.Path + "/rss",
+	}
+	for _, p := range posts {
+		title, description, _ := previewed(a.s, p.ID, p.Title, p.Description)
+		page.Posts = append(page.Posts, newWebPost(p.ID.String(), title, p.Url, p.FeedName, p.PublishedAt.Time, description.String, false, false))
+	}
+	renderWeb(w, http.StatusOK, webCollectionTmpl, page)
+	return nil
+}
+
+func (a *apiServer) handleSharedRSS(w http.ResponseWriter, r *http.Request, c database.GetSharedCollectionRow, posts []database.GetBookmarksForUserRow) error {
+	// Behind a proxy the request's host and scheme may not be the public ones
+	link := shareURL(a.s, r.PathValue("token"))
+	if a.s.cfg.PublicURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		link = fmt.Sprintf("%s://%s/shared/%s", scheme, r.Host, r.PathValue("token"))
+	}
+	description := c.Description
+	if description == "" {
+		description = "A reading list by " + c.UserName
+	}
+
+	doc := sharedRSS{
+		Version: "2.0",
+		Channel: sharedRSSChannel{
+			Title:       c.Name,
+			Link:        link,
+			Description: description,
+		},
+	}
+	for _, p := range posts {
+		title, summary, _ := previewed(a.s, p.ID, p.Title, p.Description)
+		item := sharedRSSItem{
+			Title:       title,
+			Link:        p.Url,
+			GUID:        p.Url,
+			Description: summary.String,
+		}
+		if p.PublishedAt.Valid {
+			item.PubDate = p.PublishedAt.Time.UTC().Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't render feed: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/sink"
+)
+
+// newSinks builds the sinks configured under "sinks"
+func newSinks(cfg *config.Config) ([]sink.Sink, error) {
+	sinks := make([]sink.Sink, 0, len(cfg.Sinks))
+	for i, sc := range cfg.Sinks {
+		switch sc.Type {
+		case "file":
+			if sc.Path == "" {
+				return nil, fmt.Errorf("sink %d: file sink needs a path", i+1)
+			}
+			sinks = append(sinks, sink.NewFile(sc.Path))
+		case "command":
+			cmd, err := sink.NewCommand(sc.Command)
+			if err != nil {
+				return nil, fmt.Errorf("sink %d: %w", i+1, err)
+			}
+			sinks = append(sinks, cmd)
+		default:
+			return nil, fmt.Errorf("sink %d: unknown type %q, use \"file\" or \"command\"", i+1, sc.Type)
+		}
+	}
+	return sinks, nil
+}
+
+// exportPosts streams the posts just ingested from feed to every sink. A
+// failing sink is reported but doesn't fail the fetch.
+func exportPosts(sc *scraper, feed database.Feed, posts []database.Post) {
+	if len(sc.sinks) == 0 || len(posts) == 0 {
+		return
+	}
+
+	records := make([]sink.Post, len(posts))
+	for i, post := range posts {
+		records[i] = sink.Post{
+			ID:          post.ID.String(),
+			FeedID:      feed.ID.String(),
+			FeedName:    feed.Name,
+			FeedURL:     feed.Url,
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String,
+			IngestedAt:  post.CreatedAt,
+		}
+		if post.PublishedAt.Valid {
+			published := post.PublishedAt.Time
+			records[i].PublishedAt = &published
+		}
+	}
+
+	var errs []error
+	for _, s := range sc.sinks {
+		if err := s.Write(records); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		slog.Error("couldn't export posts", "feed", feed.Name, "err", err)
+	}
+}
@@ -0,0 +1,19 @@
+-- name: CreateAggCycle :one
+INSERT INTO agg_cycles (id, started_at, duration_ms, feeds_attempted, feeds_succeeded, feeds_failed, feeds_skipped, new_posts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetRecentAggCycles :many
+SELECT * FROM agg_cycles
+ORDER BY started_at DESC
+LIMIT $1;
+
+-- name: GetAggCycleTotals :one
+SELECT
+    COUNT(*) AS cycles,
+    COALESCE(SUM(feeds_attempted), 0)::BIGINT AS feeds_attempted,
+    COALESCE(SUM(feeds_succeeded), 0)::BIGINT AS feeds_succeeded,
+    COALESCE(SUM(feeds_failed), 0)::BIGINT AS feeds_failed,
+    COALESCE(SUM(new_posts), 0)::BIGINT AS new_posts
+FROM agg_cycles
+WHERE started_at >= $1;
@@ -0,0 +1,29 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, user_id, name, token_hash, fever_key_hash, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAPITokensForUser :many
+SELECT * FROM api_tokens WHERE user_id = $1 ORDER BY created_at;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens WHERE user_id = $1 AND name = $2;
+
+-- name: CountAPITokens :one
+SELECT count(*) FROM api_tokens;
+
+-- name: GetUserByAPITokenHash :one
+SELECT users.* FROM users
+INNER JOIN api_tokens ON api_tokens.user_id = users.id
+WHERE api_tokens.token_hash = $1;
+
+-- name: GetUserByFeverKeyHash :one
+SELECT users.* FROM users
+INNER JOIN api_tokens ON api_tokens.user_id = users.id
+WHERE api_tokens.fever_key_hash = $1;
+
+-- name: TouchAPIToken :exec
+-- Written at most once a minute per token, not on every request
+UPDATE api_tokens SET last_used_at = $2
+WHERE (token_hash = $1 OR fever_key_hash = $1)
+AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute');
@@ -4,23 +4,82 @@ VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: DeleteBookmark :exec
-DELETE FROM bookmarks
-WHERE user_id = $1 AND post_id = $2;
+UPDATE bookmarks
+SET deleted_at = $3
+WHERE user_id = $1 AND post_id = $2 AND deleted_at IS NULL;
+
+-- name: RestoreBookmark :exec
+UPDATE bookmarks
+SET deleted_at = NULL
+WHERE id = $1;
 
 -- name: GetBookmarksForUser :many
+-- Only bookmarks of posts tagged $3 unless it is empty, and none from
+-- removed feeds. Sorting by manual puts the bookmarks placed in $3's order
+-- first, the rest newest first.
 SELECT posts.*, feeds.name AS feed_name, bookmarks.created_at AS bookmarked_at
 FROM bookmarks
 INNER JOIN posts ON bookmarks.post_id = posts.id
 INNER JOIN feeds ON posts.feed_id = feeds.id
-WHERE bookmarks.user_id = $1
-ORDER BY bookmarks.created_at DESC
+LEFT JOIN bookmark_positions ON bookmark_positions.user_id = bookmarks.user_id
+  AND bookmark_positions.post_id = bookmarks.post_id AND bookmark_positions.tag = $3::TEXT
+WHERE bookmarks.user_id = $1 AND bookmarks.deleted_at IS NULL AND feeds.deleted_at IS NULL
+AND ($3::TEXT = '' OR EXISTS (
+  SELECT 1 FROM post_tags WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id AND post_tags.tag = $3
+))
+ORDER BY
+  CASE WHEN $4::TEXT = 'manual' THEN bookmark_positions.position END ASC NULLS LAST,
+  CASE WHEN $4::TEXT = 'published' THEN posts.published_at END DESC NULLS LAST,
+  CASE WHEN $4::TEXT = 'title' THEN lower(posts.title) END ASC,
+  bookmarks.created_at DESC
 LIMIT $2;
 
+-- name: SaveBookmarkPositions :exec
+-- Places the posts in the order given, in the list of the tag
+INSERT INTO bookmark_positions (user_id, tag, post_id, position)
+SELECT sqlc.arg(user_id)::UUID, sqlc.arg(tag)::TEXT, ordered.post_id, ordered.position
+FROM unnest(sqlc.arg(post_ids)::UUID[]) WITH ORDINALITY AS ordered(post_id, position)
+ON CONFLICT (user_id, tag, post_id) DO UPDATE SET position = EXCLUDED.position;
+
+-- name: RenameBookmarkPositions :exec
+-- A tag that already has an order keeps it
+UPDATE bookmark_positions SET tag = sqlc.arg(to_tag)::TEXT
+WHERE user_id = sqlc.arg(user_id)::UUID AND tag = sqlc.arg(from_tag)::TEXT
+AND NOT EXISTS (
+  SELECT 1 FROM bookmark_positions existing
+  WHERE existing.user_id = sqlc.arg(user_id)::UUID AND existing.tag = sqlc.arg(to_tag)::TEXT
+);
+
+-- name: DeleteBookmarkPositions :exec
+DELETE FROM bookmark_positions WHERE user_id = $1 AND tag = $2;
+
 -- name: IsPostBookmarked :one
 SELECT EXISTS(
     SELECT 1 FROM bookmarks
-    WHERE user_id = $1 AND post_id = $2
+    WHERE user_id = $1 AND post_id = $2 AND deleted_at IS NULL
 ) AS is_bookmarked;
 
 -- name: GetPostByURL :one
-SELECT * FROM posts WHERE url = $1;
\ No newline at end of file
+-- A post's canonical URL finds it as well as the URL from its feed
+SELECT * FROM posts WHERE url = $1 OR canonical_url = $1
+ORDER BY url = $1 DESC
+LIMIT 1;
+
+-- name: GetAllBookmarksForUser :many
+SELECT posts.*, feeds.name AS feed_name, feeds.url AS feed_url, bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+INNER JOIN posts ON bookmarks.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE bookmarks.user_id = $1 AND bookmarks.deleted_at IS NULL
+ORDER BY bookmarks.created_at ASC;
+
+-- name: GetBookmarkPositionsForUser :many
+-- Positions of the user's current bookmarks, per tag
+SELECT bookmark_positions.tag, posts.url, bookmark_positions.position
+FROM bookmark_positions
+INNER JOIN posts ON posts.id = bookmark_positions.post_id
+INNER JOIN bookmarks ON bookmarks.post_id = bookmark_positions.post_id
+  AND bookmarks.user_id = bookmark_positions.user_id
+  AND bookmarks.deleted_at IS NULL
+WHERE bookmark_positions.user_id = $1
+ORDER BY bookmark_positions.tag, bookmark_positions.position;
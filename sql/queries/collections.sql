@@ -0,0 +1,29 @@
+-- name: CreateCollection :one
+INSERT INTO collections (id, user_id, tag, name, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetCollectionsForUser :many
+SELECT * FROM collections WHERE user_id = $1 ORDER BY lower(name);
+
+-- name: SetCollectionShareToken :execrows
+UPDATE collections SET share_token = $3, updated_at = $4
+WHERE user_id = $1 AND tag = $2;
+
+-- name: DeleteCollection :execrows
+DELETE FROM collections WHERE user_id = $1 AND tag = $2;
+
+-- name: GetSharedCollection :one
+SELECT collections.*, users.name AS user_name
+FROM collections
+INNER JOIN users ON users.id = collections.user_id
+WHERE collections.share_token = $1;
+
+-- name: RenameCollectionTag :exec
+-- A tag that already has a collection keeps it
+UPDATE collections SET tag = sqlc.arg(to_tag)::TEXT, updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id)::UUID AND tag = sqlc.arg(from_tag)::TEXT
+AND NOT EXISTS (
+  SELECT 1 FROM collections existing
+  WHERE existing.user_id = sqlc.arg(user_id)::UUID AND existing.tag = sqlc.arg(to_tag)::TEXT
+);
@@ -20,12 +20,69 @@ SELECT
 FROM feed_follows ff
 INNER JOIN users ON users.id = ff.user_id
 INNER JOIN feeds ON feeds.id = ff.feed_id
-WHERE ff.user_id = $1
+WHERE ff.user_id = $1 AND ff.deleted_at IS NULL AND feeds.deleted_at IS NULL
 ORDER BY feeds.name ASC;
 
 -- name: DeleteFeedFollow :exec
-DELETE FROM feed_follows
-USING feeds
+UPDATE feed_follows
+SET deleted_at = $3
+FROM feeds
 WHERE feed_follows.feed_id = feeds.id
   AND feed_follows.user_id = $1
-  AND feeds.url = $2;
\ No newline at end of file
+  AND feeds.url = $2
+  AND feed_follows.deleted_at IS NULL
+  AND feeds.deleted_at IS NULL;
+
+-- name: RestoreFeedFollow :exec
+UPDATE feed_follows
+SET deleted_at = NULL
+WHERE id = $1;
+
+-- name: GetFollowedFeedsForUser :many
+SELECT feeds.*, ff.created_at AS followed_at, ff.category
+FROM feed_follows ff
+INNER JOIN feeds ON feeds.id = ff.feed_id
+WHERE ff.user_id = $1 AND ff.deleted_at IS NULL AND feeds.deleted_at IS NULL
+ORDER BY ff.created_at ASC;
+
+
+-- name: SetFollowCategory :execrows
+-- Files the user's follow of a feed, or of an alias of it, under a category
+UPDATE feed_follows SET category = sqlc.arg(category), updated_at = sqlc.arg(updated_at)
+WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+AND feed_id IN (
+  SELECT feeds.id FROM feeds
+  WHERE feeds.id = sqlc.arg(feed_id)::UUID OR feeds.canonical_feed_id = sqlc.arg(feed_id)::UUID
+);
+
+-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+SELECT gen_random_uuid(), ff.created_at, NOW(), ff.user_id, sqlc.arg(to_feed_id)::UUID, ff.category
+FROM feed_follows ff
+WHERE ff.feed_id = sqlc.arg(from_feed_id)::UUID
+  AND ff.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMovedFeedFollows :exec
+-- Deletes the follows feed merge copied from an alias to its canonical feed,
+-- recognised by the created_at they kept from the alias's follows
+DELETE FROM feed_follows moved
+USING feed_follows original
+WHERE original.feed_id = sqlc.arg(from_feed_id)::UUID
+  AND original.deleted_at IS NULL
+  AND moved.feed_id = sqlc.arg(to_feed_id)::UUID
+  AND moved.user_id = original.user_id
+  AND moved.created_at = original.created_at;
+
+-- name: GetFollowedFeedIDs :many
+-- Following an alias of a feed counts as following the feed itself
+SELECT feeds.id FROM feeds
+WHERE feeds.deleted_at IS NULL
+AND (cardinality($2::TEXT[]) = 0 OR feeds.name ILIKE ANY($2::TEXT[]))
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+);
@@ -0,0 +1,21 @@
+-- name: CreateFeedRequest :one
+INSERT INTO feed_requests (id, created_at, user_id, url, name, reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPendingFeedRequests :many
+SELECT feed_requests.*, users.name AS user_name
+FROM feed_requests
+INNER JOIN users ON users.id = feed_requests.user_id
+WHERE feed_requests.status = 'pending'
+ORDER BY feed_requests.created_at;
+
+-- name: GetFeedRequestsForUser :many
+SELECT * FROM feed_requests WHERE user_id = $1 ORDER BY created_at DESC;
+
+-- name: DecideFeedRequest :execrows
+-- Only pending requests can be decided, so two admins can't both decide
+-- the same one
+UPDATE feed_requests
+SET status = $2, decided_by = $3, decided_at = $4, note = $5
+WHERE id = $1 AND status = 'pending';
@@ -1,19 +1,34 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, insecure_skip_verify)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetFeedsWithUsers :many
 SELECT 
+    feeds.id,
     feeds.name AS feed_name,
     feeds.url AS feed_url,
-    users.name AS user_name
+    feeds.insecure_skip_verify,
+    feeds.title_rules,
+    feeds.fetch_interval_seconds,
+    feeds.fetch_timeout_seconds,
+    feeds.fetch_retries,
+    feeds.max_body_bytes,
+    feeds.disabled_at,
+    feeds.consecutive_failures,
+    feeds.last_error,
+    feeds.last_http_status,
+    feeds.headlines_only,
+    users.name AS user_name,
+    COALESCE(canonical.name, '') AS canonical_feed_name
 FROM feeds
 INNER JOIN users ON feeds.user_id = users.id
+LEFT JOIN feeds canonical ON canonical.id = feeds.canonical_feed_id
+WHERE feeds.deleted_at IS NULL
 ORDER BY feeds.name ASC;
 
 -- name: GetFeedByURL :one
-SELECT * FROM feeds WHERE url = $1;
+SELECT * FROM feeds WHERE url = $1 AND deleted_at IS NULL;
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
@@ -22,10 +37,142 @@ WHERE id = $1;
 
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
+WHERE deleted_at IS NULL
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1;
 
--- name: GetNextFeedsToFetch :many
+-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = (
+    SELECT id FROM feeds
+    WHERE deleted_at IS NULL
+      AND disabled_at IS NULL
+      AND canonical_feed_id IS NULL
+      -- next_fetch_at holds UTC wall-clock times written by gator
+      AND (next_fetch_at IS NULL OR next_fetch_at <= (NOW() AT TIME ZONE 'UTC'))
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RenewFeedClaim :execrows
+-- Moves the lease of a claimed feed ahead, unless the feed was rescheduled
+-- since
+UPDATE feeds
+SET next_fetch_at = sqlc.arg(until)
+WHERE id = sqlc.arg(id) AND next_fetch_at = sqlc.arg(lease);
+
+-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1;
+
+-- name: SetFeedError :one
+UPDATE feeds
+SET last_error_kind = $2, last_error = $3, last_http_status = $4, last_error_at = NOW(),
+    consecutive_failures = consecutive_failures + 1
+WHERE id = $1
+RETURNING consecutive_failures;
+
+-- name: ClearFeedError :exec
+UPDATE feeds
+SET last_error_kind = NULL, last_error = NULL, last_http_status = NULL, last_error_at = NULL,
+    consecutive_failures = 0
+WHERE id = $1;
+
+-- name: DisableFeed :exec
+UPDATE feeds
+SET disabled_at = COALESCE(disabled_at, NOW())
+WHERE id = $1;
+
+-- name: EnableFeed :exec
+UPDATE feeds
+SET disabled_at = NULL, consecutive_failures = 0, next_fetch_at = NULL, updated_at = $2
+WHERE id = $1;
+
+-- name: GetFeedsWithErrors :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1;
\ No newline at end of file
+WHERE last_error_kind IS NOT NULL AND deleted_at IS NULL
+ORDER BY last_error_at DESC;
+
+-- name: SetFeedTitleRules :exec
+UPDATE feeds
+SET title_rules = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedsCreatedByUser :many
+SELECT * FROM feeds
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at ASC;
+
+-- name: DeleteFeed :exec
+UPDATE feeds
+SET deleted_at = $2
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreFeed :exec
+UPDATE feeds
+SET deleted_at = NULL
+WHERE id = $1;
+
+
+-- name: ReleaseFeedAliases :exec
+-- Aliases aren't fetched, so once their canonical feed is removed or disabled
+-- they go back to being fetched on their own
+UPDATE feeds
+SET canonical_feed_id = NULL, next_fetch_at = NULL, updated_at = NOW()
+WHERE canonical_feed_id = $1;
+
+-- name: SetFeedResolvedURL :exec
+UPDATE feeds
+SET resolved_url = $2
+WHERE id = $1;
+
+-- name: GetCanonicalFeedForResolvedURL :one
+SELECT * FROM feeds
+WHERE resolved_url = $1
+  AND id <> $2
+  AND deleted_at IS NULL
+  AND canonical_feed_id IS NULL
+ORDER BY created_at ASC
+LIMIT 1;
+
+-- name: SetFeedCanonical :exec
+UPDATE feeds
+SET canonical_feed_id = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = $1;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds SET user_id = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetFeedHeadlinesOnly :exec
+UPDATE feeds SET headlines_only = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetFeedFetchInterval :exec
+UPDATE feeds SET fetch_interval_seconds = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetFeedFetchPolicy :exec
+UPDATE feeds
+SET fetch_timeout_seconds = $2, fetch_retries = $3, max_body_bytes = $4, updated_at = $5
+WHERE id = $1;
+
+-- name: SetFeedURL :exec
+UPDATE feeds SET url = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: CreateFeedURLChange :exec
+INSERT INTO feed_url_changes (id, feed_id, changed_at, old_url, new_url)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetFeedURLChanges :many
+SELECT * FROM feed_url_changes
+WHERE feed_id = $1
+ORDER BY changed_at DESC;
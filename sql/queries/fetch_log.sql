@@ -0,0 +1,13 @@
+-- name: CreateFetchLog :exec
+INSERT INTO fetch_log (id, feed_id, fetched_at, status_code, bytes, duration_ms, new_posts, error_kind, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetFetchLogForFeed :many
+SELECT * FROM fetch_log
+WHERE feed_id = $1
+ORDER BY fetched_at DESC
+LIMIT $2;
+
+-- name: DeleteFetchLogBefore :execrows
+DELETE FROM fetch_log
+WHERE fetched_at < $1;
@@ -0,0 +1,11 @@
+-- name: HidePost :exec
+INSERT INTO hidden_posts (user_id, post_id, hidden_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetHiddenPostsForUser :many
+SELECT posts.url, hidden_posts.hidden_at
+FROM hidden_posts
+INNER JOIN posts ON posts.id = hidden_posts.post_id
+WHERE hidden_posts.user_id = $1
+ORDER BY hidden_posts.hidden_at ASC;
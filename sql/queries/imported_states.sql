@@ -0,0 +1,43 @@
+-- name: SaveImportedStates :exec
+-- Links must be unique within a call. A link imported again keeps being
+-- read or starred if it was before.
+INSERT INTO imported_states (user_id, url, is_read, is_starred, imported_at)
+SELECT $1, unnest($2::TEXT[]), unnest($3::BOOLEAN[]), unnest($4::BOOLEAN[]), $5
+ON CONFLICT (user_id, url) DO UPDATE
+SET is_read = imported_states.is_read OR EXCLUDED.is_read,
+    is_starred = imported_states.is_starred OR EXCLUDED.is_starred,
+    imported_at = EXCLUDED.imported_at;
+
+-- name: ApplyImportedReads :execrows
+-- Marks the fetched posts of imported read states read, for one user or
+-- everyone if null
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT imported_states.user_id, posts.id, imported_states.imported_at
+FROM imported_states
+INNER JOIN posts ON posts.url = imported_states.url OR posts.canonical_url = imported_states.url
+WHERE imported_states.is_read
+AND (sqlc.narg(user_id)::UUID IS NULL OR imported_states.user_id = sqlc.narg(user_id))
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: ApplyImportedStars :execrows
+-- Bookmarks the fetched posts of imported starred states, for one user or
+-- everyone if null
+INSERT INTO bookmarks (id, created_at, updated_at, user_id, post_id)
+SELECT gen_random_uuid(), imported_states.imported_at, imported_states.imported_at, imported_states.user_id, posts.id
+FROM imported_states
+INNER JOIN posts ON posts.url = imported_states.url OR posts.canonical_url = imported_states.url
+WHERE imported_states.is_starred
+AND (sqlc.narg(user_id)::UUID IS NULL OR imported_states.user_id = sqlc.narg(user_id))
+ON CONFLICT (user_id, post_id) WHERE deleted_at IS NULL DO NOTHING;
+
+-- name: DeleteAppliedImportedStates :exec
+-- Drops the states whose post has been fetched, which the Apply queries
+-- have handled, and those imported before the cutoff
+DELETE FROM imported_states
+WHERE imported_at < $1
+OR EXISTS (
+  SELECT 1 FROM posts WHERE posts.url = imported_states.url OR posts.canonical_url = imported_states.url
+);
+
+-- name: CountImportedStates :one
+SELECT count(*) FROM imported_states WHERE user_id = $1;
@@ -0,0 +1,11 @@
+-- name: ClearListedPosts :exec
+DELETE FROM listed_posts WHERE user_id = $1;
+
+-- name: AddListedPost :exec
+INSERT INTO listed_posts (user_id, position, post_id)
+VALUES ($1, $2, $3);
+
+-- name: GetListedPost :one
+SELECT posts.* FROM listed_posts
+INNER JOIN posts ON posts.id = listed_posts.post_id
+WHERE listed_posts.user_id = $1 AND listed_posts.position = $2;
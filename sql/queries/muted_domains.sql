@@ -0,0 +1,39 @@
+-- name: MuteDomain :execrows
+INSERT INTO muted_domains (user_id, domain, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, domain) DO NOTHING;
+
+-- name: UnmuteDomain :execrows
+DELETE FROM muted_domains WHERE user_id = $1 AND domain = $2;
+
+-- name: GetMutedDomains :many
+SELECT domain, created_at FROM muted_domains
+WHERE user_id = $1
+ORDER BY domain;
+
+-- name: GetDomainStats :many
+-- Posts per linked domain in the feeds the user follows, muted ones
+-- included so they can be told apart
+SELECT posts.domain::TEXT AS domain,
+  COUNT(*) AS posts,
+  COUNT(DISTINCT posts.feed_id) AS feeds,
+  EXISTS (
+    SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+    AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+  ) AS muted
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND posts.domain IS NOT NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND COALESCE(posts.published_at, posts.created_at) >= $2
+GROUP BY posts.domain
+ORDER BY posts DESC, domain
+LIMIT $3;
@@ -0,0 +1,24 @@
+-- name: GetUserPasswordHash :one
+SELECT password_hash FROM user_passwords WHERE user_id = $1;
+
+-- name: SetUserPassword :exec
+INSERT INTO user_passwords (user_id, password_hash, updated_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET password_hash = EXCLUDED.password_hash, updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteUserPassword :exec
+DELETE FROM user_passwords WHERE user_id = $1;
+
+-- name: CreateLoginSession :exec
+INSERT INTO login_sessions (token_hash, user_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: GetLoginSessionUserID :one
+SELECT user_id FROM login_sessions WHERE token_hash = $1;
+
+-- name: DeleteLoginSession :exec
+DELETE FROM login_sessions WHERE token_hash = $1;
+
+-- name: DeleteLoginSessionsForUser :exec
+DELETE FROM login_sessions WHERE user_id = $1;
@@ -0,0 +1,7 @@
+-- name: SavePostContent :exec
+INSERT INTO post_contents (post_id, fetched_at, content)
+VALUES ($1, $2, $3)
+ON CONFLICT (post_id) DO UPDATE SET fetched_at = EXCLUDED.fetched_at, content = EXCLUDED.content;
+
+-- name: GetPostContent :one
+SELECT * FROM post_contents WHERE post_id = $1;
@@ -0,0 +1,33 @@
+-- name: CreatePostLink :exec
+INSERT INTO post_links (post_id, position, url, text)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetLinksForPost :many
+SELECT url, text FROM post_links
+WHERE post_id = $1
+ORDER BY position;
+
+-- name: GetMostLinkedURLs :many
+-- URLs linked from the most posts of the feeds the user follows, with the
+-- longest anchor text used for them
+SELECT post_links.url,
+  (array_agg(post_links.text ORDER BY length(post_links.text) DESC))[1]::TEXT AS text,
+  COUNT(DISTINCT post_links.post_id) AS posts,
+  COUNT(DISTINCT posts.feed_id) AS feeds
+FROM post_links
+INNER JOIN posts ON post_links.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND COALESCE(posts.published_at, posts.created_at) >= $2
+GROUP BY post_links.url
+ORDER BY posts DESC, feeds DESC, post_links.url
+LIMIT $3;
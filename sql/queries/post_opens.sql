@@ -0,0 +1,20 @@
+-- name: CreatePostOpen :exec
+INSERT INTO post_opens (id, user_id, post_id, opened_at, source)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetOpenTotals :one
+SELECT
+    COUNT(*) AS opens,
+    COUNT(DISTINCT post_id) AS posts
+FROM post_opens
+WHERE user_id = $1 AND opened_at >= $2;
+
+-- name: GetMostOpenedPosts :many
+SELECT posts.title, posts.url, feeds.name AS feed_name, COUNT(*) AS opens
+FROM post_opens
+INNER JOIN posts ON posts.id = post_opens.post_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE post_opens.user_id = $1 AND post_opens.opened_at >= $2
+GROUP BY posts.id, posts.title, posts.url, feeds.name
+ORDER BY opens DESC, MAX(post_opens.opened_at) DESC
+LIMIT $3;
@@ -0,0 +1,8 @@
+-- name: SavePostPreview :exec
+INSERT INTO post_previews (post_id, fetched_at, title, description, image_url)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id) DO UPDATE SET fetched_at = EXCLUDED.fetched_at,
+  title = EXCLUDED.title, description = EXCLUDED.description, image_url = EXCLUDED.image_url;
+
+-- name: GetPostPreview :one
+SELECT * FROM post_previews WHERE post_id = $1;
@@ -0,0 +1,65 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM post_reads WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkPostsRead :execrows
+-- Marks posts the user can see as read, from feeds matching any of the
+-- ILIKE patterns (all feeds if empty) and published before the cutoff
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT $1, posts.id, $2
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND (cardinality($3::TEXT[]) = 0 OR feeds.name ILIKE ANY($3::TEXT[]))
+AND COALESCE(posts.published_at, posts.created_at) < $4::TIMESTAMP
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostIDsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT $1, unnest($3::uuid[]), $2
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetUnreadCountsPerFeed :many
+-- Every feed the user follows with its number of unread posts, zero
+-- included
+SELECT feeds.id, feeds.name,
+  COUNT(posts.id) FILTER (WHERE NOT EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AND NOT EXISTS (
+    SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+    AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+  ) AND NOT EXISTS (
+    SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+  )) AS unread
+FROM feeds
+LEFT JOIN posts ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+GROUP BY feeds.id, feeds.name
+ORDER BY feeds.name;
+
+-- name: GetReadsForUser :many
+SELECT posts.url, post_reads.read_at
+FROM post_reads
+INNER JOIN posts ON posts.id = post_reads.post_id
+WHERE post_reads.user_id = $1
+ORDER BY post_reads.read_at ASC;
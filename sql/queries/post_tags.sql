@@ -0,0 +1,49 @@
+-- name: AddPostTag :execrows
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: RemovePostTag :execrows
+DELETE FROM post_tags WHERE user_id = $1 AND post_id = $2 AND tag = $3;
+
+-- name: GetTagsForUser :many
+SELECT tag, COUNT(*) AS posts
+FROM post_tags
+WHERE user_id = $1
+GROUP BY tag
+ORDER BY tag;
+
+-- name: GetTagsForPost :many
+SELECT tag FROM post_tags
+WHERE user_id = $1 AND post_id = $2
+ORDER BY tag;
+
+-- name: TagFeedPosts :execrows
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT sqlc.arg(user_id)::UUID, posts.id, sqlc.arg(tag)::TEXT, sqlc.arg(created_at)::TIMESTAMP
+FROM posts
+WHERE posts.feed_id = ANY(sqlc.arg(feed_ids)::UUID[])
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: RenamePostTags :execrows
+-- Posts that already have the new tag just lose the old one. The DELETE
+-- doesn't see the rows the INSERT adds, so only the old tag goes.
+WITH renamed AS (
+  INSERT INTO post_tags (user_id, post_id, tag, created_at)
+  SELECT source.user_id, source.post_id, sqlc.arg(to_tag)::TEXT, source.created_at
+  FROM post_tags source
+  WHERE source.user_id = sqlc.arg(user_id)::UUID AND source.tag = sqlc.arg(from_tag)::TEXT
+  ON CONFLICT (user_id, post_id, tag) DO NOTHING
+)
+DELETE FROM post_tags
+WHERE post_tags.user_id = sqlc.arg(user_id)::UUID AND post_tags.tag = sqlc.arg(from_tag)::TEXT;
+
+-- name: DeleteTag :execrows
+DELETE FROM post_tags WHERE user_id = $1 AND tag = $2;
+
+-- name: GetPostTagsForUser :many
+SELECT posts.url, post_tags.tag, post_tags.created_at
+FROM post_tags
+INNER JOIN posts ON posts.id = post_tags.post_id
+WHERE post_tags.user_id = $1
+ORDER BY post_tags.tag, post_tags.created_at;
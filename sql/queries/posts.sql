@@ -1,24 +1,104 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id,
+  duration_seconds, episode, season, image_url, episode_type, embed_url, view_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING *;
+
+-- name: CreatePosts :many
+-- Saves a feed's items in one statement, skipping the URLs already stored.
+-- Empty strings, zeros and the zero time stand for NULL.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id,
+  duration_seconds, episode, season, image_url, episode_type, embed_url, view_count)
+SELECT item.id, sqlc.arg(created_at)::TIMESTAMP, sqlc.arg(created_at)::TIMESTAMP, item.title, item.url,
+  NULLIF(item.description, ''), NULLIF(item.published_at, '0001-01-01'::TIMESTAMP), sqlc.arg(feed_id)::UUID,
+  NULLIF(item.duration_seconds, 0), NULLIF(item.episode, 0), NULLIF(item.season, 0),
+  NULLIF(item.image_url, ''), NULLIF(item.episode_type, ''), NULLIF(item.embed_url, ''), NULLIF(item.view_count, 0)
+FROM unnest(
+  sqlc.arg(ids)::UUID[], sqlc.arg(titles)::TEXT[], sqlc.arg(urls)::TEXT[], sqlc.arg(descriptions)::TEXT[],
+  sqlc.arg(published_ats)::TIMESTAMP[], sqlc.arg(durations_seconds)::INTEGER[], sqlc.arg(episodes)::INTEGER[],
+  sqlc.arg(seasons)::INTEGER[], sqlc.arg(image_urls)::TEXT[], sqlc.arg(episode_types)::TEXT[],
+  sqlc.arg(embed_urls)::TEXT[], sqlc.arg(view_counts)::BIGINT[]
+) AS item(id, title, url, description, published_at, duration_seconds, episode, season,
+  image_url, episode_type, embed_url, view_count)
+ON CONFLICT (url) DO NOTHING
 RETURNING *;
 
 -- name: GetPostsForUser :many
 SELECT posts.*, feeds.name AS feed_name
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
 ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
 LIMIT $2;
 
 -- name: GetPostsForUserWithPagination :many
-SELECT posts.*, feeds.name AS feed_name
+SELECT posts.*, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read,
+  COALESCE((
+    SELECT array_agg(post_tags.tag ORDER BY post_tags.tag) FROM post_tags
+    WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id
+  ), '{}')::TEXT[] AS tags,
+  EXISTS (
+    SELECT 1 FROM bookmarks
+    WHERE bookmarks.user_id = $1 AND bookmarks.post_id = posts.id AND bookmarks.deleted_at IS NULL
+  ) AS is_bookmarked
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-AND ($2::TEXT = '' OR feeds.name ILIKE '%' || $2 || '%')
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+-- Feed filters are ILIKE patterns, empty include list means all feeds
+AND (cardinality($2::TEXT[]) = 0 OR feeds.name ILIKE ANY($2::TEXT[]))
+AND NOT (feeds.name ILIKE ANY($8::TEXT[]))
+AND (NOT $9::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+AND ($10::TEXT = '' OR EXISTS (
+  SELECT 1 FROM post_tags WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id AND post_tags.tag = $10
+))
+AND ($11::TEXT = '' OR EXISTS (
+  -- Categories are the user's own, on their follow of the feed or an alias
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1 AND feed_follows.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+  AND lower(feed_follows.category) = lower($11::TEXT)
+))
+-- Duration bounds are in seconds, 0 means unbounded
+AND ($6::INTEGER = 0 OR posts.duration_seconds >= $6)
+AND ($7::INTEGER = 0 OR posts.duration_seconds <= $7)
 ORDER BY 
   CASE WHEN $3 = 'title' THEN posts.title END ASC,
   CASE WHEN $3 = 'title_desc' THEN posts.title END DESC,
@@ -26,24 +106,191 @@ ORDER BY
   CASE WHEN $3 = 'published_desc' OR $3 = '' THEN posts.published_at END DESC NULLS LAST,
   CASE WHEN $3 = 'feed' THEN feeds.name END ASC,
   CASE WHEN $3 = 'feed_desc' THEN feeds.name END DESC,
+  CASE WHEN $3 = 'episode' THEN posts.season END ASC NULLS LAST,
+  CASE WHEN $3 = 'episode' THEN posts.episode END ASC NULLS LAST,
+  CASE WHEN $3 = 'episode_desc' THEN posts.season END DESC NULLS LAST,
+  CASE WHEN $3 = 'episode_desc' THEN posts.episode END DESC NULLS LAST,
+  CASE WHEN $3 = 'duration' THEN posts.duration_seconds END ASC NULLS LAST,
+  CASE WHEN $3 = 'duration_desc' THEN posts.duration_seconds END DESC NULLS LAST,
   posts.created_at DESC
 LIMIT $4 OFFSET $5;
 
 -- name: SearchPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name
+SELECT posts.*, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
 AND (
   posts.title ILIKE '%' || $2 || '%' 
   OR posts.description ILIKE '%' || $2 || '%'
   OR feeds.name ILIKE '%' || $2 || '%'
 )
+-- Feed filters are ILIKE patterns, empty means all feeds
+AND (cardinality($4::TEXT[]) = 0 OR feeds.name ILIKE ANY($4::TEXT[]))
+AND (posts.published_at >= $5 OR $5 IS NULL)
+AND (posts.published_at < $6 OR $6 IS NULL)
+AND (NOT $7::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
 ORDER BY 
   CASE WHEN posts.title ILIKE '%' || $2 || '%' THEN 1 END,
   CASE WHEN feeds.name ILIKE '%' || $2 || '%' THEN 2 END,
   CASE WHEN posts.description ILIKE '%' || $2 || '%' THEN 3 END,
   posts.published_at DESC NULLS LAST,
   posts.created_at DESC
-LIMIT $3;
\ No newline at end of file
+LIMIT $3;
+
+-- name: GetPostsForUserByIDs :many
+SELECT posts.*, feeds.name AS feed_name,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  ) AS is_read
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND posts.id = ANY($2::uuid[])
+AND (NOT $3::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+);
+
+-- name: GetPostsForIndex :many
+SELECT posts.id, posts.feed_id, posts.title, posts.description, posts.url, posts.published_at,
+  feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+ORDER BY posts.id
+LIMIT $1 OFFSET $2;
+
+-- name: CountPostsForFeedSince :one
+SELECT COUNT(*) FROM posts
+WHERE feed_id = $1
+AND COALESCE(published_at, created_at) >= $2;
+
+-- name: GetPostsForUserSince :many
+SELECT posts.*, feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+AND COALESCE(posts.published_at, posts.created_at) >= $2
+AND (NOT $4::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+))
+ORDER BY feeds.name, COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT $3;
+
+-- name: GetPostsForFeedSince :many
+SELECT id, title, url, COALESCE(published_at, created_at)::TIMESTAMP AS posted_at
+FROM posts
+WHERE feed_id = $1
+AND COALESCE(published_at, created_at) >= $2
+ORDER BY posted_at DESC;
+
+-- name: SetPostCanonicalURL :exec
+UPDATE posts SET canonical_url = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetOtherPostWithURL :one
+-- The post a canonical URL belongs to in the same feed, other than the one
+-- it was found for
+SELECT * FROM posts
+WHERE (url = sqlc.arg(url) OR canonical_url = sqlc.arg(url)) AND id <> sqlc.arg(id)
+AND feed_id IN (
+  -- Only the same feed or its aliases, posts of other feeds have followers
+  -- of their own
+  SELECT family.id FROM feeds family, feeds own
+  WHERE own.id = sqlc.arg(feed_id)::UUID
+  AND COALESCE(family.canonical_feed_id, family.id) = COALESCE(own.canonical_feed_id, own.id)
+)
+ORDER BY created_at
+LIMIT 1;
+
+-- name: CanonicalURLExists :one
+SELECT EXISTS (
+  SELECT 1 FROM posts WHERE canonical_url = sqlc.arg(canonical_url)
+  AND feed_id IN (
+    -- Only the same feed or its aliases, posts of other feeds have followers
+    -- of their own
+    SELECT family.id FROM feeds family, feeds own
+    WHERE own.id = sqlc.arg(feed_id)::UUID
+    AND COALESCE(family.canonical_feed_id, family.id) = COALESCE(own.canonical_feed_id, own.id)
+  )
+);
+
+-- name: MovePostBookmarks :exec
+-- Users who bookmarked both posts keep the bookmark they already have on
+-- the target
+UPDATE bookmarks SET post_id = sqlc.arg(to_post_id)::UUID
+WHERE bookmarks.post_id = sqlc.arg(from_post_id)::UUID
+AND (bookmarks.deleted_at IS NOT NULL OR NOT EXISTS (
+  SELECT 1 FROM bookmarks target
+  WHERE target.user_id = bookmarks.user_id AND target.post_id = sqlc.arg(to_post_id)::UUID
+  AND target.deleted_at IS NULL
+));
+
+-- name: CopyPostReads :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT source.user_id, sqlc.arg(to_post_id)::UUID, source.read_at
+FROM post_reads source
+WHERE source.post_id = sqlc.arg(from_post_id)::UUID
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: CopyPostTags :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT source.user_id, sqlc.arg(to_post_id)::UUID, source.tag, source.created_at
+FROM post_tags source
+WHERE source.post_id = sqlc.arg(from_post_id)::UUID
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: DeletePost :exec
+DELETE FROM posts WHERE id = $1;
@@ -0,0 +1,11 @@
+-- name: QuarantineItem :exec
+INSERT INTO quarantined_items (id, created_at, feed_id, title, url, description, reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING;
+
+-- name: GetQuarantinedItems :many
+SELECT quarantined_items.*, feeds.name AS feed_name
+FROM quarantined_items
+INNER JOIN feeds ON feeds.id = quarantined_items.feed_id
+ORDER BY quarantined_items.created_at DESC
+LIMIT $1;
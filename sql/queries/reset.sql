@@ -1,2 +1,9 @@
 -- name: DeleteAllUsers :exec
-DELETE FROM users;
\ No newline at end of file
+DELETE FROM users WHERE NOT is_system;
+
+-- name: DeleteAllFeeds :exec
+-- The announcements feed stays, new users follow it
+DELETE FROM feeds WHERE id <> '00000000-0000-0000-0000-000000000002';
+
+-- name: DeleteAnnouncements :exec
+DELETE FROM posts WHERE feed_id = '00000000-0000-0000-0000-000000000002';
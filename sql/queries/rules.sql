@@ -0,0 +1,36 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, user_id, feed_id, pattern, action, tag)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetRulesForUser :many
+SELECT rules.*, COALESCE(feeds.name, '') AS feed_name, COALESCE(feeds.url, '') AS feed_url
+FROM rules
+LEFT JOIN feeds ON feeds.id = rules.feed_id
+WHERE rules.user_id = $1
+ORDER BY rules.created_at ASC;
+
+-- name: GetRulesForFeed :many
+-- Rules for the feed itself and rules without a feed, of the users who
+-- follow it, directly or through an alias
+SELECT * FROM rules
+WHERE (rules.feed_id = $1 OR rules.feed_id IS NULL)
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = rules.user_id
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = $1 OR followed.canonical_feed_id = $1)
+)
+ORDER BY rules.created_at ASC;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules WHERE id = $1 AND user_id = $2;
+
+-- name: RenameRuleTag :execrows
+UPDATE rules SET tag = sqlc.arg(to_tag)::TEXT
+WHERE user_id = sqlc.arg(user_id) AND tag = sqlc.arg(from_tag)::TEXT;
+
+-- name: DeleteTagRules :execrows
+-- Rules that would put a deleted tag back on new posts
+DELETE FROM rules WHERE user_id = $1 AND action = 'tag' AND tag = $2;
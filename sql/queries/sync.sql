@@ -0,0 +1,180 @@
+-- name: NumberNewPosts :exec
+-- Gives every post without a sync number one, oldest first
+INSERT INTO post_numbers (post_id)
+SELECT posts.id FROM posts
+WHERE NOT EXISTS (SELECT 1 FROM post_numbers WHERE post_numbers.post_id = posts.id)
+ORDER BY posts.created_at, posts.id
+ON CONFLICT (post_id) DO NOTHING;
+
+-- name: NumberNewFeeds :exec
+INSERT INTO feed_numbers (feed_id)
+SELECT feeds.id FROM feeds
+WHERE NOT EXISTS (SELECT 1 FROM feed_numbers WHERE feed_numbers.feed_id = feeds.id)
+ORDER BY feeds.created_at, feeds.id
+ON CONFLICT (feed_id) DO NOTHING;
+
+-- name: GetSyncFeedsForUser :many
+-- The feeds whose posts the user sees, with their sync numbers
+SELECT feed_numbers.number, feeds.id, feeds.name, feeds.url, follow.category, feeds.last_fetched_at
+FROM feeds
+INNER JOIN feed_numbers ON feed_numbers.feed_id = feeds.id
+INNER JOIN LATERAL (
+  -- Following an alias of a feed counts as following the feed itself, the
+  -- category is the one the user gave either follow
+  SELECT feed_follows.category FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+  ORDER BY feed_follows.category NULLS LAST
+  LIMIT 1
+) follow ON true
+WHERE feeds.deleted_at IS NULL
+ORDER BY feeds.name;
+
+-- name: GetSyncItems :many
+-- Posts the user sees by sync number: after since_id, before max_id or
+-- among with_ids, from some feeds and not older than not_before, 0, empty
+-- or NULL meaning no bound
+SELECT post_numbers.number, feed_numbers.number AS feed_number, posts.id, posts.title,
+  posts.url, posts.description, posts.published_at, posts.created_at,
+  feeds.name AS feed_name, feeds.url AS feed_url,
+  EXISTS (
+    SELECT 1 FROM post_reads WHERE post_reads.user_id = sqlc.arg(user_id) AND post_reads.post_id = posts.id
+  ) AS is_read,
+  EXISTS (
+    SELECT 1 FROM bookmarks
+    WHERE bookmarks.user_id = sqlc.arg(user_id) AND bookmarks.post_id = posts.id AND bookmarks.deleted_at IS NULL
+  ) AS is_saved
+FROM posts
+INNER JOIN post_numbers ON post_numbers.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_numbers ON feed_numbers.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+-- Hide posts linking to a domain the user muted, or one of its subdomains
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = sqlc.arg(user_id)
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = sqlc.arg(user_id) AND hidden_posts.post_id = posts.id
+)
+AND post_numbers.number > sqlc.arg(since_id)::BIGINT
+AND (sqlc.arg(max_id)::BIGINT = 0 OR post_numbers.number < sqlc.arg(max_id)::BIGINT)
+AND (cardinality(sqlc.arg(with_ids)::BIGINT[]) = 0 OR post_numbers.number = ANY(sqlc.arg(with_ids)::BIGINT[]))
+AND (cardinality(sqlc.arg(feed_ids)::UUID[]) = 0 OR feeds.id = ANY(sqlc.arg(feed_ids)::UUID[]))
+AND (NOT sqlc.arg(unread_only)::BOOLEAN OR NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = sqlc.arg(user_id) AND post_reads.post_id = posts.id
+))
+AND (NOT sqlc.arg(saved_only)::BOOLEAN OR EXISTS (
+  SELECT 1 FROM bookmarks
+  WHERE bookmarks.user_id = sqlc.arg(user_id) AND bookmarks.post_id = posts.id AND bookmarks.deleted_at IS NULL
+))
+AND (sqlc.narg(not_before)::TIMESTAMP IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(not_before)::TIMESTAMP)
+ORDER BY
+  CASE WHEN sqlc.arg(newest_first)::BOOLEAN THEN post_numbers.number END DESC,
+  post_numbers.number ASC
+LIMIT sqlc.arg(max_items);
+
+-- name: CountSyncItems :one
+SELECT COUNT(*) FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+);
+
+-- name: GetUnreadSyncNumbers :many
+SELECT post_numbers.number FROM posts
+INNER JOIN post_numbers ON post_numbers.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = $1
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = $1 AND hidden_posts.post_id = posts.id
+)
+AND NOT EXISTS (
+  SELECT 1 FROM post_reads WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+)
+ORDER BY post_numbers.number;
+
+-- name: GetSavedSyncNumbers :many
+SELECT post_numbers.number FROM bookmarks
+INNER JOIN post_numbers ON post_numbers.post_id = bookmarks.post_id
+INNER JOIN posts ON posts.id = bookmarks.post_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE bookmarks.user_id = $1 AND bookmarks.deleted_at IS NULL AND feeds.deleted_at IS NULL
+ORDER BY post_numbers.number;
+
+-- name: GetVisiblePostIDsForNumbers :many
+-- The posts with these sync numbers that the user sees, as GetSyncItems
+-- would list them. Numbers are sequential, so anything else is left out.
+SELECT post_numbers.post_id FROM post_numbers
+INNER JOIN posts ON posts.id = post_numbers.post_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE post_numbers.number = ANY(sqlc.arg(numbers)::BIGINT[])
+AND feeds.deleted_at IS NULL
+AND EXISTS (
+  -- Following an alias of a feed counts as following the feed itself
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM muted_domains WHERE muted_domains.user_id = sqlc.arg(user_id)
+  AND (posts.domain = muted_domains.domain OR posts.domain LIKE '%.' || muted_domains.domain)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM hidden_posts WHERE hidden_posts.user_id = sqlc.arg(user_id) AND hidden_posts.post_id = posts.id
+)
+ORDER BY post_numbers.number;
+
+-- name: MarkFeedPostsReadBefore :execrows
+-- Marks the posts of some feeds the user sees as read, if gator stored them
+-- before the cutoff
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT $1, posts.id, $2
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feeds.deleted_at IS NULL
+AND EXISTS (
+  SELECT 1 FROM feed_follows
+  INNER JOIN feeds followed ON followed.id = feed_follows.feed_id
+  WHERE feed_follows.user_id = $1
+  AND feed_follows.deleted_at IS NULL AND followed.deleted_at IS NULL
+  AND (followed.id = feeds.id OR followed.canonical_feed_id = feeds.id)
+)
+AND feeds.id = ANY($3::UUID[])
+AND posts.created_at < $4::TIMESTAMP
+ON CONFLICT (user_id, post_id) DO NOTHING;
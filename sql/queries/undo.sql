@@ -0,0 +1,22 @@
+-- name: GetLastDeletedFeedFollow :one
+SELECT ff.id, feeds.name AS label, ff.deleted_at
+FROM feed_follows ff
+INNER JOIN feeds ON feeds.id = ff.feed_id
+WHERE ff.user_id = $1 AND ff.deleted_at > $2
+ORDER BY ff.deleted_at DESC
+LIMIT 1;
+
+-- name: GetLastDeletedBookmark :one
+SELECT b.id, posts.title AS label, b.deleted_at
+FROM bookmarks b
+INNER JOIN posts ON posts.id = b.post_id
+WHERE b.user_id = $1 AND b.deleted_at > $2
+ORDER BY b.deleted_at DESC
+LIMIT 1;
+
+-- name: GetLastDeletedFeed :one
+SELECT id, name AS label, deleted_at
+FROM feeds
+WHERE user_id = $1 AND deleted_at > $2
+ORDER BY deleted_at DESC
+LIMIT 1;
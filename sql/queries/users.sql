@@ -9,4 +9,7 @@ VALUES (
 RETURNING *;
 
 -- name: GetUsers :many
-SELECT * FROM users ORDER BY name ASC;
\ No newline at end of file
+SELECT * FROM users WHERE NOT is_system ORDER BY name ASC;
+
+-- name: GetSystemUser :one
+SELECT * FROM users WHERE is_system LIMIT 1;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN last_error_kind TEXT;
+ALTER TABLE feeds ADD COLUMN last_error TEXT;
+ALTER TABLE feeds ADD COLUMN last_error_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN last_error_at;
+ALTER TABLE feeds DROP COLUMN last_error;
+ALTER TABLE feeds DROP COLUMN last_error_kind;
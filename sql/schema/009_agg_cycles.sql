@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE agg_cycles (
+    id UUID PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    feeds_attempted INTEGER NOT NULL,
+    feeds_succeeded INTEGER NOT NULL,
+    feeds_failed INTEGER NOT NULL,
+    feeds_skipped INTEGER NOT NULL,
+    new_posts INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE agg_cycles;
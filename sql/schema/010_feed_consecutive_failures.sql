@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN consecutive_failures;
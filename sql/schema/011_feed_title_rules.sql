@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN title_rules TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN title_rules;
@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE feed_follows ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE bookmarks ADD COLUMN deleted_at TIMESTAMP;
+
+-- Uniqueness only applies to live rows so a deleted row can be re-created.
+-- The indexes keep the old constraint names so error handling keyed on them
+-- keeps working.
+ALTER TABLE feeds DROP CONSTRAINT feeds_url_key;
+CREATE UNIQUE INDEX feeds_url_key ON feeds (url) WHERE deleted_at IS NULL;
+
+ALTER TABLE feed_follows DROP CONSTRAINT feed_follows_user_id_feed_id_key;
+CREATE UNIQUE INDEX feed_follows_user_id_feed_id_key ON feed_follows (user_id, feed_id) WHERE deleted_at IS NULL;
+
+ALTER TABLE bookmarks DROP CONSTRAINT bookmarks_user_id_post_id_key;
+CREATE UNIQUE INDEX bookmarks_user_id_post_id_key ON bookmarks (user_id, post_id) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DELETE FROM bookmarks WHERE deleted_at IS NOT NULL;
+DELETE FROM feed_follows WHERE deleted_at IS NOT NULL;
+DELETE FROM feeds WHERE deleted_at IS NOT NULL;
+
+DROP INDEX bookmarks_user_id_post_id_key;
+ALTER TABLE bookmarks ADD CONSTRAINT bookmarks_user_id_post_id_key UNIQUE (user_id, post_id);
+
+DROP INDEX feed_follows_user_id_feed_id_key;
+ALTER TABLE feed_follows ADD CONSTRAINT feed_follows_user_id_feed_id_key UNIQUE (user_id, feed_id);
+
+DROP INDEX feeds_url_key;
+ALTER TABLE feeds ADD CONSTRAINT feeds_url_key UNIQUE (url);
+
+ALTER TABLE bookmarks DROP COLUMN deleted_at;
+ALTER TABLE feed_follows DROP COLUMN deleted_at;
+ALTER TABLE feeds DROP COLUMN deleted_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN resolved_url TEXT;
+ALTER TABLE feeds ADD COLUMN canonical_feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN canonical_feed_id;
+ALTER TABLE feeds DROP COLUMN resolved_url;
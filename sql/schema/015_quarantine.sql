@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE quarantined_items (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    reason TEXT NOT NULL
+);
+
+-- Hashes keep the index small even for the oversized titles that end up here
+CREATE UNIQUE INDEX quarantined_items_feed_item_key
+    ON quarantined_items (feed_id, md5(url), md5(title));
+
+-- +goose Down
+DROP TABLE quarantined_items;
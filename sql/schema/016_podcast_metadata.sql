@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN duration_seconds INTEGER;
+ALTER TABLE posts ADD COLUMN episode INTEGER;
+ALTER TABLE posts ADD COLUMN season INTEGER;
+ALTER TABLE posts ADD COLUMN image_url TEXT;
+ALTER TABLE posts ADD COLUMN episode_type TEXT;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN episode_type;
+ALTER TABLE posts DROP COLUMN image_url;
+ALTER TABLE posts DROP COLUMN season;
+ALTER TABLE posts DROP COLUMN episode;
+ALTER TABLE posts DROP COLUMN duration_seconds;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN embed_url TEXT;
+ALTER TABLE posts ADD COLUMN view_count BIGINT;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN view_count;
+ALTER TABLE posts DROP COLUMN embed_url;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The numbering of the last post listing shown to each user, so commands
+-- can refer to posts by number
+CREATE TABLE listed_posts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, position)
+);
+
+-- +goose Down
+DROP TABLE listed_posts;
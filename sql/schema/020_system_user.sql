@@ -0,0 +1,11 @@
+-- +goose Up
+-- The system user owns feeds that belong to the installation rather than to
+-- whoever added them. It can't log in.
+ALTER TABLE users ADD COLUMN is_system BOOLEAN NOT NULL DEFAULT false;
+
+INSERT INTO users (id, created_at, updated_at, name, is_system)
+VALUES ('00000000-0000-0000-0000-000000000001', NOW(), NOW(), '@system', true);
+
+-- +goose Down
+DELETE FROM users WHERE is_system;
+ALTER TABLE users DROP COLUMN is_system;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- A fixed time between fetches, overriding the interval learned from the
+-- feed's posting cadence. NULL means automatic.
+ALTER TABLE feeds ADD COLUMN fetch_interval_seconds INTEGER;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
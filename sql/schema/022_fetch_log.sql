@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE fetch_log (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    fetched_at TIMESTAMP NOT NULL,
+    status_code INTEGER,
+    bytes INTEGER,
+    duration_ms BIGINT NOT NULL,
+    new_posts INTEGER NOT NULL,
+    error_kind TEXT,
+    error TEXT
+);
+
+CREATE INDEX fetch_log_feed_id_fetched_at_idx ON fetch_log (feed_id, fetched_at DESC);
+CREATE INDEX fetch_log_fetched_at_idx ON fetch_log (fetched_at);
+
+-- +goose Down
+DROP TABLE fetch_log;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN fetch_timeout_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN fetch_retries INTEGER;
+ALTER TABLE feeds ADD COLUMN max_body_bytes BIGINT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN max_body_bytes;
+ALTER TABLE feeds DROP COLUMN fetch_retries;
+ALTER TABLE feeds DROP COLUMN fetch_timeout_seconds;
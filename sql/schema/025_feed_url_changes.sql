@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE feed_url_changes (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    changed_at TIMESTAMP NOT NULL,
+    old_url TEXT NOT NULL,
+    new_url TEXT NOT NULL
+);
+
+CREATE INDEX feed_url_changes_feed_id_idx ON feed_url_changes (feed_id, changed_at DESC);
+
+-- +goose Down
+DROP TABLE feed_url_changes;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE post_tags (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id, tag)
+);
+
+CREATE INDEX post_tags_user_id_tag_idx ON post_tags (user_id, tag);
+
+-- +goose Down
+DROP TABLE post_tags;
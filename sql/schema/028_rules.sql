@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- NULL applies the rule to every feed the user follows
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL,
+    tag TEXT
+);
+
+CREATE INDEX rules_feed_id_idx ON rules (feed_id);
+
+-- +goose Down
+DROP TABLE rules;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE post_opens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    opened_at TIMESTAMP NOT NULL,
+    source TEXT NOT NULL
+);
+
+CREATE INDEX post_opens_opened_at_idx ON post_opens (opened_at);
+CREATE INDEX post_opens_post_id_idx ON post_opens (post_id);
+
+-- +goose Down
+DROP TABLE post_opens;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE post_contents (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    fetched_at TIMESTAMP NOT NULL,
+    content TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE post_contents;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- NULL follows the headlines_only setting in the config
+ALTER TABLE feeds ADD COLUMN headlines_only BOOLEAN;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN headlines_only;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- The host a post links to, without www., for domain stats and muting
+ALTER TABLE posts ADD COLUMN domain TEXT GENERATED ALWAYS AS (
+    lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?(?:www\.)?([^/:?#]+)'))
+) STORED;
+
+CREATE INDEX posts_domain_idx ON posts (domain);
+
+CREATE TABLE muted_domains (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, domain)
+);
+
+-- +goose Down
+DROP TABLE muted_domains;
+ALTER TABLE posts DROP COLUMN domain;
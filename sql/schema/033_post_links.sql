@@ -0,0 +1,14 @@
+-- +goose Up
+-- Outbound links found in post descriptions at ingest
+CREATE TABLE post_links (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (post_id, url)
+);
+
+CREATE INDEX post_links_url_idx ON post_links (url);
+
+-- +goose Down
+DROP TABLE post_links;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- The URL the article page names as canonical (rel=canonical or og:url),
+-- set when its content is fetched
+ALTER TABLE posts ADD COLUMN canonical_url TEXT;
+
+CREATE INDEX posts_canonical_url_idx ON posts (canonical_url);
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN canonical_url;
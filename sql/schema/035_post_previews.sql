@@ -0,0 +1,13 @@
+-- +goose Up
+-- Open Graph metadata of the pages behind posts whose feed items came
+-- without a title or description, shown in their place
+CREATE TABLE post_previews (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    fetched_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    image_url TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE post_previews;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Integer IDs for the sync APIs of gator serve, whose clients can't take
+-- UUIDs. They are handed out as the APIs first see a post or feed, so
+-- newer posts get larger numbers.
+CREATE TABLE post_numbers (
+    number BIGSERIAL PRIMARY KEY,
+    post_id UUID NOT NULL UNIQUE REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE feed_numbers (
+    number BIGSERIAL PRIMARY KEY,
+    feed_id UUID NOT NULL UNIQUE REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_numbers;
+DROP TABLE post_numbers;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Tokens for gator serve, made with gator token create. Only hashes are
+-- kept: of the token itself, and of the Fever api_key it makes, which is
+-- the MD5 of "user name:token".
+CREATE TABLE api_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    fever_key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE api_tokens;
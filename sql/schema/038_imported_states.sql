@@ -0,0 +1,15 @@
+-- +goose Up
+-- Read and starred states imported from another reader, by article link.
+-- They are applied to posts as soon as a post with the link is fetched,
+-- and dropped once applied or after a while without a match.
+CREATE TABLE imported_states (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    is_read BOOLEAN NOT NULL,
+    is_starred BOOLEAN NOT NULL,
+    imported_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, url)
+);
+
+-- +goose Down
+DROP TABLE imported_states;
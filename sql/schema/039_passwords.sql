@@ -0,0 +1,18 @@
+-- +goose Up
+-- Optional passwords. A user with one must log in with it, which starts a
+-- login session whose token is kept in the config file.
+CREATE TABLE user_passwords (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE login_sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE login_sessions;
+DROP TABLE user_passwords;
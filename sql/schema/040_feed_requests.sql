@@ -0,0 +1,20 @@
+-- +goose Up
+-- Requests for feeds the installation's policy doesn't let users add
+-- themselves, decided by an admin
+CREATE TABLE feed_requests (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    name TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX feed_requests_pending_key ON feed_requests (user_id, url) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE feed_requests;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- gator announcements is where gator itself posts messages for its users,
+-- such as feeds it disabled or moved. It is never fetched, and every user
+-- follows it.
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, disabled_at)
+VALUES ('00000000-0000-0000-0000-000000000002', NOW(), NOW(), 'gator announcements', 'gator:announcements',
+        '00000000-0000-0000-0000-000000000001', NOW());
+
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+SELECT gen_random_uuid(), NOW(), NOW(), id, '00000000-0000-0000-0000-000000000002'
+FROM users WHERE NOT is_system;
+
+-- +goose Down
+DELETE FROM feeds WHERE id = '00000000-0000-0000-0000-000000000002';
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Manual order of bookmarks, per tag. The list of all bookmarks uses the
+-- empty tag. Rows outlive their bookmark, so a bookmark that is restored
+-- goes back to where it was.
+CREATE TABLE bookmark_positions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (user_id, tag, post_id)
+);
+
+-- +goose Down
+DROP TABLE bookmark_positions;
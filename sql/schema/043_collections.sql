@@ -0,0 +1,17 @@
+-- +goose Up
+-- A collection names the bookmarks of one tag, in that tag's manual order,
+-- and can be shared read-only with anyone who has its share token
+CREATE TABLE collections (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    share_token TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, tag)
+);
+
+-- +goose Down
+DROP TABLE collections;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Posts a skip rule took out of one user's lists. Feeds are shared, so the
+-- post itself stays for the other followers.
+CREATE TABLE hidden_posts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE hidden_posts;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Categories are how each follower files a feed, not a property of the
+-- shared feed
+ALTER TABLE feed_follows ADD COLUMN category TEXT;
+
+UPDATE feed_follows SET category = feeds.category
+FROM feeds WHERE feeds.id = feed_follows.feed_id;
+
+ALTER TABLE feeds DROP COLUMN category;
+
+-- +goose Down
+ALTER TABLE feeds ADD COLUMN category TEXT;
+
+-- The category most followers chose
+UPDATE feeds SET category = (
+    SELECT feed_follows.category FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id AND feed_follows.category IS NOT NULL
+    GROUP BY feed_follows.category
+    ORDER BY COUNT(*) DESC, feed_follows.category
+    LIMIT 1
+);
+
+ALTER TABLE feed_follows DROP COLUMN category;
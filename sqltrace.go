@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/dbtrace"
+)
+
+const (
+	defaultSQLTraceFile    = ".gator-sql.log"
+	defaultSQLTraceMaxSize = 10 << 20
+	sqlTraceBackups        = 3
+)
+
+// newSQLTracer returns the tracer for --sql-trace, disabled, logging to
+// sql_trace_file (default ~/.gator-sql.log). The file rotates at
+// sql_trace_max_size and isn't created until something is traced. Without
+// a home directory, as in containers, the default is in the temp directory.
+func newSQLTracer(cfg *config.Config) (*dbtrace.Tracer, io.Closer, error) {
+	path := cfg.SQLTraceFile
+	if path == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			dir = os.TempDir()
+		}
+		path = filepath.Join(dir, defaultSQLTraceFile)
+	}
+
+	maxSize := int64(defaultSQLTraceMaxSize)
+	if cfg.SQLTraceMaxSize != "" {
+		size, err := parseSize(cfg.SQLTraceMaxSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		maxSize = size
+	}
+
+	file := dbtrace.NewLogFile(path, maxSize, sqlTraceBackups)
+	return dbtrace.NewTracer(slog.New(slog.NewTextHandler(file, nil))), file, nil
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+// watchSQLTraceToggle does nothing where there is no SIGUSR1. Tracing can
+// still be turned on at start with --sql-trace.
+func watchSQLTraceToggle(s *state) {}
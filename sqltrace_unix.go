@@ -0,0 +1,23 @@
+//go:build unix
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSQLTraceToggle turns SQL tracing on or off whenever the process
+// gets SIGUSR1, so a long-running agg or serve can be traced while a
+// problem shows without restarting it
+func watchSQLTraceToggle(s *state) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	go func() {
+		for range signals {
+			slog.Info("SQL tracing toggled", "enabled", s.sqlTrace.Toggle())
+		}
+	}()
+}
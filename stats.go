@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/olereon/Gator/internal/database"
+)
+
+const (
+	openStatsWindow = 7 * 24 * time.Hour
+	mostOpenedLimit = 5
+)
+
+func handlerStats(s *state, cmd command) error {
+	limit := int32(10)
+
+	// Parse optional limit argument
+	if len(cmd.args) > 0 {
+		if l, err := strconv.Atoi(cmd.args[0]); err == nil && l > 0 {
+			limit = int32(l)
+		}
+	}
+
+	totals, err := s.db.GetAggCycleTotals(context.Background(), time.Now().UTC().Add(-24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("couldn't get cycle totals: %w", err)
+	}
+
+	fmt.Println("Aggregation in the last 24 hours:")
+	fmt.Printf("  Cycles: %d\n", totals.Cycles)
+	fmt.Printf("  Feeds attempted: %d (%d succeeded, %d failed)\n",
+		totals.FeedsAttempted, totals.FeedsSucceeded, totals.FeedsFailed)
+	fmt.Printf("  New posts: %d\n", totals.NewPosts)
+	fmt.Println()
+
+	// Opens are private, so they are only shown to the user who made them
+	if s.cfg.CurrentUserName != "" {
+		user, err := loggedInUser(s)
+		if err != nil {
+			return err
+		}
+		if err := printOpenStats(s, user); err != nil {
+			return err
+		}
+	}
+
+	cycles, err := s.db.GetRecentAggCycles(context.Background(), limit)
+	if err != nil {
+		return fmt.Errorf("couldn't get cycles: %w", err)
+	}
+
+	if len(cycles) == 0 {
+		fmt.Println("No aggregation cycles recorded yet.")
+		return nil
+	}
+
+	fmt.Printf("Last %d cycle(s):\n", len(cycles))
+	for _, c := range cycles {
+		fmt.Printf("* %s  %d attempted, %d succeeded, %d failed, %d skipped, %d new posts in %s\n",
+			c.StartedAt.Format("Mon, 02 Jan 2006 15:04:05 MST"), c.FeedsAttempted, c.FeedsSucceeded,
+			c.FeedsFailed, c.FeedsSkipped, c.NewPosts, time.Duration(c.DurationMs)*time.Millisecond)
+	}
+
+	return nil
+}
+
+// printOpenStats shows how often the user opened posts in the last week and
+// which ones the most
+func printOpenStats(s *state, user database.User) error {
+	since := time.Now().UTC().Add(-openStatsWindow)
+	opens, err := s.db.GetOpenTotals(context.Background(), database.GetOpenTotalsParams{
+		UserID:   user.ID,
+		OpenedAt: since,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get open totals: %w", err)
+	}
+
+	fmt.Println("Posts you opened in the last 7 days:")
+	fmt.Printf("  Opens: %d (%d different posts)\n", opens.Opens, opens.Posts)
+	if opens.Opens == 0 {
+		fmt.Println()
+		return nil
+	}
+
+	top, err := s.db.GetMostOpenedPosts(context.Background(), database.GetMostOpenedPostsParams{
+		UserID:   user.ID,
+		OpenedAt: since,
+		Limit:    mostOpenedLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get most opened posts: %w", err)
+	}
+	for _, post := range top {
+		fmt.Printf("  %dx %s (%s)\n", post.Opens, post.Title, post.FeedName)
+	}
+	fmt.Println()
+	return nil
+}
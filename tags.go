@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// normalizeTag makes "#Go", "go" and " GO " the same tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// parseTags normalizes the tags given to tag and untag
+func parseTags(args []string) ([]string, error) {
+	tags := make([]string, 0, len(args))
+	for _, arg := range args {
+		tag := normalizeTag(arg)
+		if tag == "" {
+			return nil, fmt.Errorf("invalid tag %q", arg)
+		}
+		if strings.ContainsAny(tag, ", ") {
+			return nil, fmt.Errorf("tags can't contain spaces or commas: %q", arg)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func handlerTag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) > 0 {
+		// Post references are URLs or numbers, so these can't be one
+		sub := command{name: cmd.args[0], args: cmd.args[1:]}
+		switch sub.name {
+		case "apply":
+			return handlerTagApply(s, sub, user)
+		case "rename":
+			return handlerTagRename(s, sub, user)
+		case "merge":
+			return handlerTagMerge(s, sub, user)
+		case "delete":
+			return handlerTagDelete(s, sub, user)
+		}
+	}
+	if len(cmd.args) < 2 {
+		return errors.New("usage: gator tag <post_url|number> <tag>... or gator tag apply|rename|merge|delete")
+	}
+
+	post, err := resolvePost(s, user, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+	tags, err := parseTags(cmd.args[1:])
+	if err != nil {
+		return err
+	}
+
+	var added []string
+	for _, tag := range tags {
+		n, err := s.db.AddPostTag(context.Background(), database.AddPostTagParams{
+			UserID:    user.ID,
+			PostID:    post.ID,
+			Tag:       tag,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't tag post: %w", err)
+		}
+		if n > 0 {
+			added = append(added, tag)
+		}
+	}
+
+	if len(added) == 0 {
+		fmt.Printf("%s already has those tags\n", post.Title)
+		return nil
+	}
+	fmt.Printf("Tagged %s: %s\n", post.Title, strings.Join(added, ", "))
+	return nil
+}
+
+func handlerUntag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("usage: gator untag <post_url|number> <tag>...")
+	}
+
+	post, err := resolvePost(s, user, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't find post: %w", err)
+	}
+	tags, err := parseTags(cmd.args[1:])
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, tag := range tags {
+		n, err := s.db.RemovePostTag(context.Background(), database.RemovePostTagParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			Tag:    tag,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't untag post: %w", err)
+		}
+		if n > 0 {
+			removed = append(removed, tag)
+		}
+	}
+
+	if len(removed) == 0 {
+		fmt.Printf("%s has none of those tags\n", post.Title)
+		return nil
+	}
+	fmt.Printf("Removed from %s: %s\n", post.Title, strings.Join(removed, ", "))
+	return nil
+}
+
+// handlerTagApply tags every stored post of the feeds listed in a file, one
+// URL per line
+func handlerTagApply(s *state, cmd command, user database.User) error {
+	const usage = "usage: gator tag apply <tag> --feed-url-file=<file>"
+	var tagArgs []string
+	path := ""
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--feed-url-file=") {
+			path = strings.TrimPrefix(arg, "--feed-url-file=")
+		} else {
+			tagArgs = append(tagArgs, arg)
+		}
+	}
+	if len(tagArgs) != 1 || path == "" {
+		return errors.New(usage)
+	}
+	tags, err := parseTags(tagArgs)
+	if err != nil {
+		return err
+	}
+
+	urls, err := readFeedURLFile(path)
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no feed URLs in %s", path)
+	}
+
+	feedIDs := make([]uuid.UUID, 0, len(urls))
+	for _, u := range urls {
+		feed, err := s.db.GetFeedByURL(context.Background(), u)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Skipping %s: no such feed\n", u)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get feed %s: %w", u, err)
+		}
+		// An alias's posts are stored under the feed it points to
+		if feed.CanonicalFeedID.Valid {
+			feedIDs = append(feedIDs, feed.CanonicalFeedID.UUID)
+		} else {
+			feedIDs = append(feedIDs, feed.ID)
+		}
+	}
+
+	n, err := s.db.TagFeedPosts(context.Background(), database.TagFeedPostsParams{
+		UserID:    user.ID,
+		Tag:       tags[0],
+		CreatedAt: time.Now().UTC(),
+		FeedIds:   feedIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't tag posts: %w", err)
+	}
+	fmt.Printf("Tagged %d post(s) from %d feed(s) with %s\n", n, len(feedIDs), tags[0])
+	return nil
+}
+
+// readFeedURLFile reads one URL per line, skipping blank lines and lines
+// starting with #
+func readFeedURLFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open feed URL file: %w", err)
+	}
+	defer f.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read feed URL file: %w", err)
+	}
+	return urls, nil
+}
+
+// retag moves every use of tag from to tag to, on posts and in tag rules.
+// It returns how many posts had from.
+func retag(s *state, user database.User, from, to string) (int64, error) {
+	posts, err := s.db.RenamePostTags(context.Background(), database.RenamePostTagsParams{
+		ToTag:   to,
+		UserID:  user.ID,
+		FromTag: from,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("couldn't retag posts: %w", err)
+	}
+	if _, err := s.db.RenameRuleTag(context.Background(), database.RenameRuleTagParams{
+		ToTag:   to,
+		UserID:  user.ID,
+		FromTag: from,
+	}); err != nil {
+		return posts, fmt.Errorf("couldn't update rules: %w", err)
+	}
+	if err := s.db.RenameBookmarkPositions(context.Background(), database.RenameBookmarkPositionsParams{
+		ToTag:   to,
+		UserID:  user.ID,
+		FromTag: from,
+	}); err != nil {
+		return posts, fmt.Errorf("couldn't update bookmark order: %w", err)
+	}
+	if err := s.db.DeleteBookmarkPositions(context.Background(), database.DeleteBookmarkPositionsParams{
+		UserID: user.ID,
+		Tag:    from,
+	}); err != nil {
+		return posts, fmt.Errorf("couldn't update bookmark order: %w", err)
+	}
+	if err := s.db.RenameCollectionTag(context.Background(), database.RenameCollectionTagParams{
+		ToTag:   to,
+		UserID:  user.ID,
+		FromTag: from,
+	}); err != nil {
+		return posts, fmt.Errorf("couldn't update collection: %w", err)
+	}
+	return posts, nil
+}
+
+// tagInUse reports whether any of the user's posts or tag rules use tag
+func tagInUse(s *state, user database.User, tag string) (bool, error) {
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return false, fmt.Errorf("couldn't get tags: %w", err)
+	}
+	for _, t := range tags {
+		if t.Tag == tag {
+			return true, nil
+		}
+	}
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return false, fmt.Errorf("couldn't get rules: %w", err)
+	}
+	for _, r := range rules {
+		if r.Tag.Valid && r.Tag.String == tag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func handlerTagRename(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return errors.New("usage: gator tag rename <old> <new>")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+	from, to := tags[0], tags[1]
+	if from == to {
+		return errors.New("the old and new tag are the same")
+	}
+
+	if inUse, err := tagInUse(s, user, from); err != nil {
+		return err
+	} else if !inUse {
+		return fmt.Errorf("you have no tag %s", from)
+	}
+	if inUse, err := tagInUse(s, user, to); err != nil {
+		return err
+	} else if inUse {
+		return fmt.Errorf("tag %s already exists, use: gator tag merge %s %s", to, from, to)
+	}
+
+	n, err := retag(s, user, from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s to %s on %d post(s)\n", from, to, n)
+	return nil
+}
+
+// handlerTagMerge folds one or more tags into the last one given
+func handlerTagMerge(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("usage: gator tag merge <tag>... <into>")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+	into := tags[len(tags)-1]
+
+	for _, from := range tags[:len(tags)-1] {
+		if from == into {
+			continue
+		}
+		n, err := retag(s, user, from, into)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Merged %s into %s on %d post(s)\n", from, into, n)
+	}
+	return nil
+}
+
+// handlerTagDelete removes tags from all of the user's posts, along with
+// the rules that would add them again
+func handlerTagDelete(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("usage: gator tag delete <tag>...")
+	}
+	tags, err := parseTags(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		posts, err := s.db.DeleteTag(context.Background(), database.DeleteTagParams{
+			UserID: user.ID,
+			Tag:    tag,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't delete tag: %w", err)
+		}
+		rules, err := s.db.DeleteTagRules(context.Background(), database.DeleteTagRulesParams{
+			UserID: user.ID,
+			Tag:    sql.NullString{String: tag, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't delete tag rules: %w", err)
+		}
+		err = s.db.DeleteBookmarkPositions(context.Background(), database.DeleteBookmarkPositionsParams{
+			UserID: user.ID,
+			Tag:    tag,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't delete bookmark order: %w", err)
+		}
+		fmt.Printf("Deleted %s from %d post(s)", tag, posts)
+		if rules > 0 {
+			fmt.Printf(" and %d rule(s)", rules)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// handlerTags lists the user's tags with how many posts have each, or the
+// tags of a single post
+func handlerTags(s *state, cmd command, user database.User) error {
+	if len(cmd.args) > 0 {
+		post, err := resolvePost(s, user, cmd.args[0])
+		if err != nil {
+			return fmt.Errorf("couldn't find post: %w", err)
+		}
+		tags, err := s.db.GetTagsForPost(context.Background(), database.GetTagsForPostParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get tags: %w", err)
+		}
+		if len(tags) == 0 {
+			fmt.Printf("%s has no tags\n", post.Title)
+			return nil
+		}
+		fmt.Printf("%s: %s\n", post.Title, strings.Join(tags, ", "))
+		return nil
+	}
+
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tags: %w", err)
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags yet. Add some with: gator tag <post_url|number> <tag>...")
+		return nil
+	}
+
+	fmt.Printf("Your %d tag(s):\n", len(tags))
+	for _, t := range tags {
+		fmt.Printf("* %s (%d)\n", t.Tag, t.Posts)
+	}
+	return nil
+}
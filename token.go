@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// apiTokenPrefix marks gator's tokens, so a leaked one is recognisable
+const apiTokenPrefix = "gator_"
+
+// hashAPIToken is how tokens are kept in the database. Tokens are random,
+// so a plain SHA-256 is enough to make the stored value useless.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// feverKey is the api_key a Fever client sends for a user name and token
+func feverKey(userName, token string) string {
+	sum := md5.Sum([]byte(userName + ":" + token))
+	return hex.EncodeToString(sum[:])
+}
+
+func handlerToken(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: create, list, revoke")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	if s.readOnly && sub.name != "list" {
+		return fmt.Errorf("token %s: %w", sub.name, errReadOnly)
+	}
+	switch sub.name {
+	case "create":
+		return handlerTokenCreate(s, sub, user)
+	case "list":
+		return handlerTokenList(s, sub, user)
+	case "revoke":
+		return handlerTokenRevoke(s, sub, user)
+	default:
+		return fmt.Errorf("unknown token subcommand: %s", sub.name)
+	}
+}
+
+// handlerTokenCreate makes a token for gator serve and prints it. It is
+// shown only this once, the database keeps a hash.
+func handlerTokenCreate(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 || strings.TrimSpace(cmd.args[0]) == "" {
+		return errors.New("usage: gator token create <name>")
+	}
+	name := strings.TrimSpace(cmd.args[0])
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("couldn't generate token: %w", err)
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	_, err := s.db.CreateAPIToken(context.Background(), database.CreateAPITokenParams{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Name:         name,
+		TokenHash:    hashAPIToken(token),
+		FeverKeyHash: hashAPIToken(feverKey(user.Name, token)),
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		if err.Error() == `pq: duplicate key value violates unique constraint "api_tokens_user_id_name_key"` {
+			return fmt.Errorf("you already have a token named %q", name)
+		}
+		return fmt.Errorf("couldn't create token: %w", err)
+	}
+
+	fmt.Printf("Created token %q for %s:\n\n  %s\n\n", name, user.Name, token)
+	fmt.Println("Copy it now, it can't be shown again.")
+	return nil
+}
+
+func handlerTokenList(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return errors.New("usage: gator token list")
+	}
+	tokens, err := s.db.GetAPITokensForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		fmt.Println("No tokens yet. Create one with: gator token create <name>")
+		return nil
+	}
+
+	fmt.Printf("Your %d token(s):\n", len(tokens))
+	for _, t := range tokens {
+		lastUsed := "never used"
+		if t.LastUsedAt.Valid {
+			lastUsed = "last used " + t.LastUsedAt.Time.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("* %s (created %s, %s)\n", t.Name, t.CreatedAt.Local().Format("2006-01-02"), lastUsed)
+	}
+	return nil
+}
+
+func handlerTokenRevoke(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator token revoke <name>")
+	}
+	name := strings.TrimSpace(cmd.args[0])
+	n, err := s.db.DeleteAPIToken(context.Background(), database.DeleteAPITokenParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't revoke token: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you have no token named %q", name)
+	}
+	fmt.Printf("Revoked token %q\n", name)
+	return nil
+}
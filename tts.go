@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/config"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+const defaultTTSFormat = "wav"
+
+var defaultTTSCommand = []string{"espeak-ng", "-w", "{out}", "-f", "{in}"}
+
+// ttsCommand returns the configured TTS command with its placeholders
+// filled in
+func ttsCommand(cfg *config.Config, in, out string) *exec.Cmd {
+	args := cfg.TTSCommand
+	if len(args) == 0 {
+		args = defaultTTSCommand
+	}
+
+	filled := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, "{in}", in)
+		filled[i] = strings.ReplaceAll(arg, "{out}", out)
+	}
+	return exec.Command(filled[0], filled[1:]...)
+}
+
+// fileSlug turns a post title into something safe to use in a file name
+func fileSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+		if b.Len() >= 50 {
+			break
+		}
+	}
+	if slug := strings.Trim(b.String(), "-"); slug != "" {
+		return slug
+	}
+	return "post"
+}
+
+func handlerTTS(s *state, cmd command, user database.User) error {
+	outDir := ""
+	limit := int32(10)
+	full := false
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--out=") {
+			outDir = strings.TrimPrefix(arg, "--out=")
+		} else if strings.HasPrefix(arg, "--limit=") {
+			l, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || l <= 0 {
+				return fmt.Errorf("invalid --limit: %s", arg)
+			}
+			limit = int32(l)
+		} else if arg == "--full" {
+			full = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 || outDir == "" {
+		return errors.New("usage: gator tts <query> --out=dir [--limit=N] [--full]")
+	}
+
+	format := s.cfg.TTSFormat
+	if format == "" {
+		format = defaultTTSFormat
+	}
+
+	query := strings.Join(positional, " ")
+	posts, err := s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
+		UserID:  user.ID,
+		Column2: sql.NullString{String: query, Valid: true},
+		Limit:   limit,
+		Column4: []string{},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't search posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No posts found matching \"%s\"\n", query)
+		return nil
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("couldn't create output directory: %w", err)
+	}
+
+	client, err := rss.NewClient(clientOptions(s.cfg))
+	if err != nil {
+		return fmt.Errorf("couldn't create HTTP client: %w", err)
+	}
+	pages, err := newCache(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	playlist := []string{"#EXTM3U"}
+	spoken := 0
+	for i, post := range posts {
+		content := article.Sanitize(post.Description.String, post.Url)
+		if full {
+			content = string(fetchArticle(client, pages, post.Url, post.Title, post.FeedName, post.Description.String, post.PublishedAt.Time).Content)
+		}
+		text := post.Title + ".\n" + post.FeedName + ".\n\n" + article.Text(content)
+
+		textFile, err := os.CreateTemp("", "gator-tts-*.txt")
+		if err != nil {
+			return fmt.Errorf("couldn't create temporary file: %w", err)
+		}
+		_, err = textFile.WriteString(text)
+		textFile.Close()
+		if err != nil {
+			os.Remove(textFile.Name())
+			return fmt.Errorf("couldn't write temporary file: %w", err)
+		}
+
+		name := fmt.Sprintf("%02d-%s.%s", i+1, fileSlug(post.Title), format)
+		fmt.Printf("Speaking %d/%d: %s\n", i+1, len(posts), post.Title)
+
+		tts := ttsCommand(s.cfg, textFile.Name(), filepath.Join(outDir, name))
+		output, err := tts.CombinedOutput()
+		os.Remove(textFile.Name())
+		if err != nil {
+			// A missing engine fails every post the same way
+			if errors.Is(err, exec.ErrNotFound) {
+				return fmt.Errorf("couldn't run TTS engine %q, set tts_command in your config: %w", tts.Args[0], err)
+			}
+			fmt.Printf("Error speaking %s: %v\n%s", post.Title, err, output)
+			continue
+		}
+
+		label := strings.Join(strings.Fields(post.FeedName+" - "+post.Title), " ")
+		playlist = append(playlist, "#EXTINF:-1,"+label, name)
+		spoken++
+	}
+
+	playlistPath := filepath.Join(outDir, "playlist.m3u")
+	if err := os.WriteFile(playlistPath, []byte(strings.Join(playlist, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("couldn't write playlist: %w", err)
+	}
+
+	fmt.Printf("Spoke %d of %d posts, playlist written to %s\n", spoken, len(posts), playlistPath)
+	return nil
+}
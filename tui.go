@@ -0,0 +1,722 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/layout"
+)
+
+const (
+	tuiPageSize     = 50
+	tuiSearchLimit  = 200
+	tuiRefreshEvery = time.Minute
+	// tuiLoadAhead is how close to the end of the list the cursor gets
+	// before the next page is loaded
+	tuiLoadAhead = 10
+	// tuiSideBySide is the terminal width from which the detail pane sits
+	// next to the list rather than below it, and the feed sidebar stays
+	// visible
+	tuiSideBySide  = 100
+	tuiSidebarWide = 28
+)
+
+// tuiPost is a post as the TUI lists it, whether it came from the latest
+// posts or a search
+type tuiPost struct {
+	ID          uuid.UUID
+	Title       string
+	URL         string
+	FeedID      uuid.UUID
+	FeedName    string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	IsRead      bool
+	Bookmarked  bool
+}
+
+// postsLoadedMsg carries a page of posts loaded in the background. offset
+// is where the page goes in the list, a refresh replaces the whole list.
+// query and feed are the search and feed the posts are for, so that pages
+// arriving after the reader switched to another listing are dropped.
+type postsLoadedMsg struct {
+	posts   []tuiPost
+	offset  int
+	refresh bool
+	query   string
+	feed    string
+	err     error
+}
+
+type feedCountsMsg struct {
+	feeds []database.GetUnreadCountsPerFeedRow
+	err   error
+}
+
+type refreshTickMsg time.Time
+
+type tuiModel struct {
+	s       *state
+	user    database.User
+	session *readingSession
+
+	posts     []tuiPost
+	cursor    int
+	top       int
+	loading   bool
+	exhausted bool
+
+	// query is the active search, empty while showing the latest posts
+	query     string
+	searching bool
+	input     string
+
+	// feeds is the sidebar, where the first line is all feeds, so
+	// feedCursor 1 is feeds[0]. feed is the name of the feed the list is
+	// narrowed to, empty for all.
+	feeds        []database.GetUnreadCountsPerFeedRow
+	feedCursor   int
+	feedTop      int
+	feed         string
+	sidebarFocus bool
+
+	// zoomed shows the article view of the selected post, article being
+	// its text rendered for the current width
+	zoomed      bool
+	article     []string
+	articleTop  int
+	articleFor  uuid.UUID
+	articleFull bool
+
+	width  int
+	height int
+	status string
+}
+
+func newTUIModel(s *state, user database.User, session *readingSession) *tuiModel {
+	return &tuiModel{s: s, user: user, session: session, loading: true}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.loadPosts(0, tuiPageSize, false), m.loadFeedCounts(), refreshTick())
+}
+
+func refreshTick() tea.Cmd {
+	return tea.Tick(tuiRefreshEvery, func(t time.Time) tea.Msg { return refreshTickMsg(t) })
+}
+
+// feedFilter is the ILIKE pattern list for the feed the list is narrowed to
+func (m *tuiModel) feedFilter() []string {
+	if m.feed == "" {
+		return []string{}
+	}
+	return []string{likeEscaper.Replace(m.feed)}
+}
+
+// loadPosts fetches limit of the latest posts starting at offset
+func (m *tuiModel) loadPosts(offset, limit int, refresh bool) tea.Cmd {
+	s, userID, feed, feeds := m.s, m.user.ID, m.feed, m.feedFilter()
+	return func() tea.Msg {
+		rows, err := s.db.GetPostsForUserWithPagination(context.Background(), database.GetPostsForUserWithPaginationParams{
+			UserID:  userID,
+			Column2: feeds,
+			Column3: "",
+			Limit:   int32(limit),
+			Offset:  int32(offset),
+			Column8: []string{},
+		})
+		posts := make([]tuiPost, len(rows))
+		for i, p := range rows {
+			title, description, _ := previewed(s, p.ID, p.Title, p.Description)
+			posts[i] = tuiPost{p.ID, title, p.Url, p.FeedID, p.FeedName, description, p.PublishedAt, p.IsRead, p.IsBookmarked}
+		}
+		return postsLoadedMsg{posts: posts, offset: offset, refresh: refresh, feed: feed, err: err}
+	}
+}
+
+func (m *tuiModel) loadFeedCounts() tea.Cmd {
+	s, userID := m.s, m.user.ID
+	return func() tea.Msg {
+		feeds, err := s.db.GetUnreadCountsPerFeed(context.Background(), userID)
+		return feedCountsMsg{feeds: feeds, err: err}
+	}
+}
+
+// searchPosts runs a title and description search, within the selected
+// feed if there is one. Results come in one go, there is no paging through
+// them.
+func (m *tuiModel) searchPosts(query string) tea.Cmd {
+	s, userID, feed, feeds := m.s, m.user.ID, m.feed, m.feedFilter()
+	return func() tea.Msg {
+		rows, err := s.db.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
+			UserID:  userID,
+			Column2: sql.NullString{String: query, Valid: true},
+			Limit:   tuiSearchLimit,
+			Column4: feeds,
+		})
+		posts := make([]tuiPost, len(rows))
+		for i, p := range rows {
+			bookmarked, _ := s.db.IsPostBookmarked(context.Background(), database.IsPostBookmarkedParams{
+				UserID: userID,
+				PostID: p.ID,
+			})
+			title, description, _ := previewed(s, p.ID, p.Title, p.Description)
+			posts[i] = tuiPost{p.ID, title, p.Url, p.FeedID, p.FeedName, description, p.PublishedAt, p.IsRead, bookmarked}
+		}
+		return postsLoadedMsg{posts: posts, refresh: true, query: query, feed: feed, err: err}
+	}
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.articleFor = uuid.Nil
+		m.scrollList()
+		return m, nil
+
+	case postsLoadedMsg:
+		return m, m.postsLoaded(msg)
+
+	case contentFetchedMsg:
+		m.contentFetched(msg)
+		return m, nil
+
+	case feedCountsMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Couldn't load feeds: %v", msg.err)
+			return m, nil
+		}
+		m.feeds = msg.feeds
+		m.feedCursor = min(m.feedCursor, len(m.feeds))
+		m.scrollSidebar()
+		return m, nil
+
+	case refreshTickMsg:
+		// Search results stay as they are, only the latest posts refresh
+		if m.query != "" || m.loading {
+			return m, tea.Batch(m.loadFeedCounts(), refreshTick())
+		}
+		m.loading = true
+		return m, tea.Batch(m.loadPosts(0, max(len(m.posts), tuiPageSize), true), m.loadFeedCounts(), refreshTick())
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.searching {
+			return m, m.searchKey(msg)
+		}
+		if m.zoomed {
+			return m, m.articleKey(msg)
+		}
+		if m.sidebarFocus {
+			return m, m.sidebarKey(msg)
+		}
+		return m, m.listKey(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) postsLoaded(msg postsLoadedMsg) tea.Cmd {
+	if msg.query != m.query || msg.feed != m.feed {
+		return nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Couldn't load posts: %v", msg.err)
+		return nil
+	}
+
+	if msg.refresh {
+		// Keep the selection on the same post when it is still listed
+		var selected uuid.UUID
+		if m.cursor < len(m.posts) {
+			selected = m.posts[m.cursor].ID
+		}
+		m.posts = msg.posts
+		m.cursor = 0
+		for i, post := range m.posts {
+			if post.ID == selected {
+				m.cursor = i
+				break
+			}
+		}
+		m.exhausted = m.query != "" || len(msg.posts) < tuiPageSize
+	} else if msg.offset == len(m.posts) {
+		m.posts = append(m.posts, msg.posts...)
+		m.exhausted = len(msg.posts) < tuiPageSize
+	}
+	m.scrollList()
+	return m.loadMore()
+}
+
+// loadMore fetches the next page once the cursor nears the end of the list
+func (m *tuiModel) loadMore() tea.Cmd {
+	if m.loading || m.exhausted || m.query != "" || m.cursor < len(m.posts)-tuiLoadAhead {
+		return nil
+	}
+	m.loading = true
+	return m.loadPosts(len(m.posts), tuiPageSize, false)
+}
+
+func (m *tuiModel) listKey(msg tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "j", "down":
+		m.moveCursor(1)
+		return m.loadMore()
+	case "k", "up":
+		m.moveCursor(-1)
+	case "pgdown", " ":
+		m.moveCursor(m.listHeight())
+		return m.loadMore()
+	case "pgup":
+		m.moveCursor(-m.listHeight())
+	case "g", "home":
+		m.moveCursor(-len(m.posts))
+	case "/":
+		m.searching = true
+		m.input = ""
+	case "tab":
+		m.sidebarFocus = true
+	case "esc":
+		if m.query != "" {
+			m.query = ""
+			m.posts = nil
+			m.cursor = 0
+			m.loading = true
+			return m.loadPosts(0, tuiPageSize, true)
+		}
+	case "r":
+		if m.query != "" {
+			return tea.Batch(m.searchPosts(m.query), m.loadFeedCounts())
+		}
+		m.loading = true
+		return tea.Batch(m.loadPosts(0, max(len(m.posts), tuiPageSize), true), m.loadFeedCounts())
+	}
+
+	post := m.selected()
+	if post == nil {
+		return nil
+	}
+	switch msg.String() {
+	case "enter":
+		m.openArticle()
+	case "o":
+		m.open(post)
+	case "b":
+		m.toggleBookmark(post)
+	case "m":
+		m.setRead(post, !post.IsRead)
+	}
+	return nil
+}
+
+func (m *tuiModel) sidebarKey(msg tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "j", "down":
+		m.feedCursor = min(m.feedCursor+1, len(m.feeds))
+	case "k", "up":
+		m.feedCursor = max(m.feedCursor-1, 0)
+	case "tab", "esc":
+		m.sidebarFocus = false
+	case "r":
+		return m.loadFeedCounts()
+	case "enter":
+		m.sidebarFocus = false
+		feed := ""
+		if m.feedCursor > 0 {
+			feed = m.feeds[m.feedCursor-1].Name
+		}
+		if feed == m.feed {
+			return nil
+		}
+		m.feed = feed
+		m.posts = nil
+		m.cursor = 0
+		m.top = 0
+		if m.query != "" {
+			return m.searchPosts(m.query)
+		}
+		m.loading = true
+		return m.loadPosts(0, tuiPageSize, true)
+	}
+	m.scrollSidebar()
+	return nil
+}
+
+// scrollSidebar keeps the sidebar cursor inside the visible part of it
+func (m *tuiModel) scrollSidebar() {
+	height := m.bodyHeight()
+	if m.feedCursor < m.feedTop {
+		m.feedTop = m.feedCursor
+	} else if m.feedCursor >= m.feedTop+height {
+		m.feedTop = m.feedCursor - height + 1
+	}
+}
+
+func (m *tuiModel) searchKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		query := strings.TrimSpace(m.input)
+		if query == "" {
+			return nil
+		}
+		m.query = query
+		m.posts = nil
+		m.cursor = 0
+		return m.searchPosts(query)
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	return nil
+}
+
+func (m *tuiModel) selected() *tuiPost {
+	if m.cursor < 0 || m.cursor >= len(m.posts) {
+		return nil
+	}
+	return &m.posts[m.cursor]
+}
+
+func (m *tuiModel) moveCursor(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.posts)-1))
+	m.scrollList()
+}
+
+// scrollList keeps the cursor inside the visible part of the list
+func (m *tuiModel) scrollList() {
+	height := m.listHeight()
+	if m.cursor < m.top {
+		m.top = m.cursor
+	} else if m.cursor >= m.top+height {
+		m.top = m.cursor - height + 1
+	}
+	m.top = max(0, m.top)
+}
+
+func (m *tuiModel) open(post *tuiPost) {
+	if err := openURL(post.URL); err != nil {
+		m.status = fmt.Sprintf("Couldn't open the browser, the link is %s", post.URL)
+		return
+	}
+	recordOpen(m.s, m.user, post.ID, openSourceTUI)
+	if m.session != nil {
+		m.session.opened[post.ID] = true
+	}
+	m.setRead(post, true)
+	m.status = "Opened " + post.URL
+}
+
+func (m *tuiModel) setRead(post *tuiPost, read bool) {
+	if read && m.session != nil {
+		m.session.read[post.ID] = true
+	}
+	if post.IsRead == read {
+		return
+	}
+	if m.s.readOnly {
+		m.status = "Read-only mode, read state is not saved"
+		return
+	}
+
+	var err error
+	if read {
+		err = m.s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+			ReadAt: time.Now().UTC(),
+		})
+	} else {
+		err = m.s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+		})
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Couldn't update read state: %v", err)
+		return
+	}
+	post.IsRead = read
+
+	// Keep the sidebar counts in step until the next refresh
+	for i := range m.feeds {
+		if m.feeds[i].ID == post.FeedID {
+			if read {
+				m.feeds[i].Unread = max(m.feeds[i].Unread-1, 0)
+			} else {
+				m.feeds[i].Unread++
+			}
+		}
+	}
+}
+
+func (m *tuiModel) toggleBookmark(post *tuiPost) {
+	if m.s.readOnly {
+		m.status = "Read-only mode, bookmarks can't be changed"
+		return
+	}
+
+	var err error
+	if post.Bookmarked {
+		err = m.s.db.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{
+			UserID:    m.user.ID,
+			PostID:    post.ID,
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+	} else {
+		now := time.Now().UTC()
+		_, err = m.s.db.CreateBookmark(context.Background(), database.CreateBookmarkParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    m.user.ID,
+			PostID:    post.ID,
+		})
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Couldn't update bookmark: %v", err)
+		return
+	}
+	post.Bookmarked = !post.Bookmarked
+	if post.Bookmarked {
+		m.status = "Bookmarked " + post.Title
+	} else {
+		m.status = "Removed bookmark " + post.Title
+	}
+}
+
+// headerLines is the title line plus the session status when time-boxed
+func (m *tuiModel) headerLines() []string {
+	title := "Gator - latest posts"
+	if m.query != "" {
+		title = fmt.Sprintf("Gator - search %q (%d)", m.query, len(m.posts))
+	}
+	if m.feed != "" {
+		title += " in " + m.feed
+	}
+	lines := []string{title}
+	if m.session != nil {
+		status := m.session.status()
+		if warning := m.session.warning(); warning != "" {
+			status += " - " + warning
+		}
+		lines = append(lines, status)
+	}
+	return lines
+}
+
+func (m *tuiModel) footer() string {
+	switch {
+	case m.searching:
+		return "Search: " + m.input + "_"
+	case m.status != "":
+		return m.status
+	case m.zoomed:
+		return m.articleFooter()
+	case m.sidebarFocus:
+		return "j/k move  enter show feed  r refresh counts  tab posts  q quit"
+	case m.query != "":
+		return "j/k move  enter read  o open  b bookmark  m read/unread  / search  tab feeds  esc latest  q quit"
+	default:
+		return "j/k move  enter read  o open  b bookmark  m read/unread  / search  tab feeds  r refresh  q quit"
+	}
+}
+
+// bodyHeight is the number of lines between the header and the footer
+func (m *tuiModel) bodyHeight() int {
+	return max(1, m.height-len(m.headerLines())-2)
+}
+
+func (m *tuiModel) sideBySide() bool {
+	return m.width >= tuiSideBySide
+}
+
+func (m *tuiModel) listHeight() int {
+	if m.sideBySide() {
+		return m.bodyHeight()
+	}
+	return max(1, m.bodyHeight()/2)
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var b strings.Builder
+	for _, line := range m.headerLines() {
+		b.WriteString(layout.PadRight(line, m.width) + "\n")
+	}
+	b.WriteString(strings.Repeat("-", m.width) + "\n")
+
+	var body []string
+	switch {
+	case m.zoomed:
+		body = m.articleView()
+	case m.sideBySide():
+		sidebarWidth := min(tuiSidebarWide, m.width/5)
+		rest := m.width - sidebarWidth - 3
+		listWidth := rest * 2 / 5
+		sidebar := m.sidebarLines(sidebarWidth, m.bodyHeight())
+		list := m.listLines(listWidth, m.bodyHeight())
+		detail := m.detailLines(rest - listWidth - 3)
+		for i := range m.bodyHeight() {
+			line := sidebar[i] + " | " + layout.PadRight(list[i], listWidth) + " | "
+			if i < len(detail) {
+				line += detail[i]
+			}
+			body = append(body, line)
+		}
+	case m.sidebarFocus:
+		// Narrow terminals show the sidebar in place of the posts
+		body = m.sidebarLines(m.width, m.bodyHeight())
+	default:
+		body = m.listLines(m.width, m.listHeight())
+		body = append(body, strings.Repeat("-", m.width))
+		body = append(body, m.detailLines(m.width)...)
+	}
+	for i := range m.bodyHeight() {
+		line := ""
+		if i < len(body) {
+			line = body[i]
+		}
+		b.WriteString(layout.PadRight(line, m.width) + "\n")
+	}
+
+	b.WriteString(layout.Truncate(m.footer(), m.width))
+	return b.String()
+}
+
+// sidebarLines renders height lines of the feed sidebar, padded to width,
+// each feed with its number of unread posts
+func (m *tuiModel) sidebarLines(width, height int) []string {
+	var total int64
+	for _, feed := range m.feeds {
+		total += feed.Unread
+	}
+
+	lines := make([]string, height)
+	for i := range height {
+		n := m.feedTop + i
+		if n > len(m.feeds) {
+			lines[i] = strings.Repeat(" ", width)
+			continue
+		}
+		name, unread := "All feeds", total
+		if n > 0 {
+			name, unread = m.feeds[n-1].Name, m.feeds[n-1].Unread
+		}
+		cursor := "  "
+		if n == m.feedCursor {
+			cursor = "> "
+		}
+		count := ""
+		if unread > 0 {
+			count = " " + strconv.FormatInt(unread, 10)
+		}
+		lines[i] = layout.PadRight(cursor+name, width-len(count)) + count
+	}
+	return lines
+}
+
+// listLines renders height lines of the list, one post each, padded to width
+func (m *tuiModel) listLines(width, height int) []string {
+	lines := make([]string, height)
+	if len(m.posts) == 0 {
+		if m.loading {
+			lines[0] = "Loading..."
+		} else {
+			lines[0] = "No posts found."
+		}
+		return lines
+	}
+	for i := range height {
+		n := m.top + i
+		if n >= len(m.posts) {
+			if n == len(m.posts) && m.loading {
+				lines[i] = "  Loading more..."
+			}
+			break
+		}
+		post := m.posts[n]
+		cursor := "  "
+		if n == m.cursor {
+			cursor = "> "
+		}
+		marks := " "
+		if !post.IsRead {
+			marks = "*"
+		}
+		if post.Bookmarked {
+			marks += "+ "
+		} else {
+			marks += "  "
+		}
+		lines[i] = layout.PadRight(cursor+marks+post.Title, width)
+	}
+	return lines
+}
+
+// detailLines shows the selected post's metadata and summary
+func (m *tuiModel) detailLines(width int) []string {
+	post := m.selected()
+	if post == nil {
+		return nil
+	}
+
+	lines := layout.Wrap(post.Title, width)
+	meta := post.FeedName
+	if post.PublishedAt.Valid {
+		meta += " | " + post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST")
+	}
+	lines = append(lines, layout.Wrap(meta, width)...)
+	lines = append(lines, layout.Truncate(post.URL, width), "")
+	return append(lines, layout.Wrap(article.Render(post.Description.String, post.URL).String(), width)...)
+}
+
+// handlerTUI runs the full-screen reader
+func handlerTUI(s *state, cmd command, user database.User) error {
+	var session *readingSession
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--session=") {
+			budget, err := time.ParseDuration(strings.TrimPrefix(arg, "--session="))
+			if err != nil || budget < time.Minute {
+				return fmt.Errorf("invalid --session %q, use a duration of at least 1m (e.g. 20m)", arg)
+			}
+			session = newReadingSession(budget)
+		} else {
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+
+	_, err := tea.NewProgram(newTUIModel(s, user, session), tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("couldn't run the TUI: %w", err)
+	}
+	if session != nil {
+		session.report()
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/layout"
+	"github.com/olereon/Gator/internal/rss"
+)
+
+// tuiReadingWidth caps the width of the article text, longer lines are
+// hard to follow. Wider terminals center the text.
+const tuiReadingWidth = 80
+
+// contentFetchedMsg reports a full article fetched from the article view
+type contentFetchedMsg struct {
+	postID uuid.UUID
+	err    error
+}
+
+// openArticle switches to the article view of the selected post, which
+// counts as reading it
+func (m *tuiModel) openArticle() {
+	post := m.selected()
+	if post == nil {
+		return
+	}
+	m.zoomed = true
+	m.articleTop = 0
+	m.articleFor = uuid.Nil
+	m.setRead(post, true)
+}
+
+func (m *tuiModel) articleKey(msg tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	page := m.bodyHeight()
+	switch msg.String() {
+	case "q", "esc", "enter":
+		m.zoomed = false
+	case "j", "down":
+		m.scrollArticle(1)
+	case "k", "up":
+		m.scrollArticle(-1)
+	case " ", "pgdown", "l", "right":
+		m.scrollArticle(page)
+	case "pgup", "h", "left":
+		m.scrollArticle(-page)
+	case "g", "home":
+		m.articleTop = 0
+	case "G", "end":
+		m.scrollArticle(len(m.article))
+	case "n":
+		// Next post without going back to the list
+		if m.cursor < len(m.posts)-1 {
+			m.moveCursor(1)
+			m.openArticle()
+		}
+		return m.loadMore()
+	case "p":
+		if m.cursor > 0 {
+			m.moveCursor(-1)
+			m.openArticle()
+		}
+	case "f":
+		return m.fetchFullArticle()
+	case "o":
+		if post := m.selected(); post != nil {
+			m.open(post)
+		}
+	case "b":
+		if post := m.selected(); post != nil {
+			m.toggleBookmark(post)
+		}
+	}
+	return nil
+}
+
+// fetchFullArticle downloads and stores the article of the selected post,
+// like fetch-content, in the background
+func (m *tuiModel) fetchFullArticle() tea.Cmd {
+	post := m.selected()
+	if post == nil || m.articleFull {
+		return nil
+	}
+	if m.s.readOnly {
+		m.status = "Read-only mode, the article can't be stored"
+		return nil
+	}
+
+	m.status = "Fetching the full article..."
+	s, target := m.s, database.Post{ID: post.ID, Url: post.URL}
+	return func() tea.Msg {
+		client, err := rss.NewClient(clientOptions(s.cfg))
+		if err == nil {
+			err = fetchContent(s, client, target)
+		}
+		return contentFetchedMsg{postID: target.ID, err: err}
+	}
+}
+
+func (m *tuiModel) contentFetched(msg contentFetchedMsg) {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Couldn't fetch the article: %v", msg.err)
+		return
+	}
+	if msg.postID == m.articleFor {
+		// Render it again, now from the stored article
+		m.articleFor = uuid.Nil
+		m.status = "Fetched the full article"
+	}
+}
+
+func (m *tuiModel) scrollArticle(delta int) {
+	lines := m.articleLines()
+	m.articleTop = max(0, min(m.articleTop+delta, len(lines)-m.bodyHeight()))
+}
+
+// articleWidth is the width the article text is wrapped to
+func (m *tuiModel) articleWidth() int {
+	return max(1, min(m.width-2, tuiReadingWidth))
+}
+
+// articleLines is the selected post's full text if it was fetched, or its
+// summary, wrapped for the article view. It's rendered once per post and
+// terminal width.
+func (m *tuiModel) articleLines() []string {
+	post := m.selected()
+	if post == nil {
+		return nil
+	}
+	if m.articleFor == post.ID && m.article != nil {
+		return m.article
+	}
+
+	width := m.articleWidth()
+	text, full := postText(m.s, post.ID, post.URL, post.Description)
+	lines := layout.Wrap(post.Title, width)
+	meta := post.FeedName
+	if post.PublishedAt.Valid {
+		meta += " | " + post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04:05 MST")
+	}
+	lines = append(lines, layout.Wrap(meta, width)...)
+	lines = append(lines, layout.Truncate(post.URL, width), strings.Repeat("-", width))
+	lines = append(lines, layout.Wrap(text, width)...)
+	if !full {
+		lines = append(lines, "", "(Feed summary only, press f to fetch the full article)")
+	}
+	m.article = lines
+	m.articleFor = post.ID
+	m.articleFull = full
+	return lines
+}
+
+// articleView is the page of the article on screen, centered on wide
+// terminals
+func (m *tuiModel) articleView() []string {
+	lines := m.articleLines()
+	lines = lines[min(m.articleTop, len(lines)):]
+	lines = lines[:min(m.bodyHeight(), len(lines))]
+
+	margin := strings.Repeat(" ", (m.width-m.articleWidth())/2)
+	view := make([]string, len(lines))
+	for i, line := range lines {
+		view[i] = margin + line
+	}
+	return view
+}
+
+// articleFooter shows where in the article the reader is and the keys
+func (m *tuiModel) articleFooter() string {
+	height := m.bodyHeight()
+	total := max(1, (len(m.article)+height-1)/height)
+	page := m.articleTop/height + 1
+	if m.articleTop+height >= len(m.article) {
+		page = total
+	}
+
+	keys := "space/pgdn next page  pgup back  j/k scroll  n/p next/previous post  o open  b bookmark  esc list"
+	if !m.articleFull {
+		keys = "f full article  " + keys
+	}
+	return fmt.Sprintf("Page %d/%d  %s", page, total, keys)
+}
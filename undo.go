@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olereon/Gator/internal/database"
+)
+
+// undoGracePeriod is how long after a deletion it can still be undone
+const undoGracePeriod = 24 * time.Hour
+
+type deletion struct {
+	kind      string
+	id        uuid.UUID
+	label     string
+	deletedAt time.Time
+}
+
+// lastDeletion returns the user's most recent deletion within the grace
+// period, or nil if there is none
+func lastDeletion(s *state, user database.User) (*deletion, error) {
+	ctx := context.Background()
+	since := sql.NullTime{Time: time.Now().UTC().Add(-undoGracePeriod), Valid: true}
+	var latest *deletion
+
+	consider := func(kind string, id uuid.UUID, label string, deletedAt sql.NullTime, err error) error {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if latest == nil || deletedAt.Time.After(latest.deletedAt) {
+			latest = &deletion{kind: kind, id: id, label: label, deletedAt: deletedAt.Time}
+		}
+		return nil
+	}
+
+	follow, err := s.db.GetLastDeletedFeedFollow(ctx, database.GetLastDeletedFeedFollowParams{
+		UserID:    user.ID,
+		DeletedAt: since,
+	})
+	if err := consider("follow", follow.ID, follow.Label, follow.DeletedAt, err); err != nil {
+		return nil, fmt.Errorf("couldn't get deleted follows: %w", err)
+	}
+
+	bookmark, err := s.db.GetLastDeletedBookmark(ctx, database.GetLastDeletedBookmarkParams{
+		UserID:    user.ID,
+		DeletedAt: since,
+	})
+	if err := consider("bookmark", bookmark.ID, bookmark.Label, bookmark.DeletedAt, err); err != nil {
+		return nil, fmt.Errorf("couldn't get deleted bookmarks: %w", err)
+	}
+
+	feed, err := s.db.GetLastDeletedFeed(ctx, database.GetLastDeletedFeedParams{
+		UserID:    user.ID,
+		DeletedAt: since,
+	})
+	if err := consider("feed", feed.ID, feed.Label, feed.DeletedAt, err); err != nil {
+		return nil, fmt.Errorf("couldn't get deleted feeds: %w", err)
+	}
+
+	return latest, nil
+}
+
+func handlerUndo(s *state, cmd command, user database.User) error {
+	d, err := lastDeletion(s, user)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		fmt.Printf("Nothing to undo in the last %s.\n", undoGracePeriod)
+		return nil
+	}
+
+	ctx := context.Background()
+	switch d.kind {
+	case "follow":
+		err = s.db.RestoreFeedFollow(ctx, d.id)
+	case "bookmark":
+		err = s.db.RestoreBookmark(ctx, d.id)
+	case "feed":
+		err = restoreFeed(s, d.id)
+	}
+	if err != nil {
+		// A partial unique index rejects the restore if the same follow,
+		// bookmark or feed URL has been re-created since
+		return fmt.Errorf("couldn't restore %s %s (was it re-added since?): %w", d.kind, d.label, err)
+	}
+
+	switch d.kind {
+	case "follow":
+		fmt.Printf("Undid unfollow: %s is following %s again\n", user.Name, d.label)
+	case "bookmark":
+		fmt.Printf("Undid unbookmark: %s\n", d.label)
+	case "feed":
+		fmt.Printf("Undid feed removal: %s\n", d.label)
+	}
+	return nil
+}
+
+// restoreFeed brings back a removed feed. A feed that was merged into its
+// canonical feed gets its follows back from there, so merge followed by undo
+// leaves every follower with one follow, not two
+func restoreFeed(s *state, id uuid.UUID) error {
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	if err := q.RestoreFeed(ctx, feed.ID); err != nil {
+		return err
+	}
+	if feed.CanonicalFeedID.Valid {
+		err := q.DeleteMovedFeedFollows(ctx, database.DeleteMovedFeedFollowsParams{
+			FromFeedID: feed.ID,
+			ToFeedID:   feed.CanonicalFeedID.UUID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// userExportVersion is bumped whenever a field in userExport is removed or
+// changes meaning
+const userExportVersion = 2
+
+// userExport is everything gator keeps for a user, except the hashes of
+// their password and API tokens
+type userExport struct {
+	FormatVersion int                   `json:"format_version"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	User          exportUser            `json:"user"`
+	FeedsCreated  []exportFeed          `json:"feeds_created"`
+	Follows       []exportFollow        `json:"follows"`
+	Bookmarks     []exportBookmark      `json:"bookmarks"`
+	BookmarkOrder []exportBookmarkOrder `json:"bookmark_order"`
+	Collections   []exportCollection    `json:"collections"`
+	Tags          []exportTag           `json:"tags"`
+	Reads         []exportRead          `json:"reads"`
+	HiddenPosts   []exportHiddenPost    `json:"hidden_posts"`
+	Rules         []exportRule          `json:"rules"`
+	MutedDomains  []exportMutedDomain   `json:"muted_domains"`
+	APITokens     []exportAPIToken      `json:"api_tokens"`
+}
+
+type exportUser struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type exportFeed struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type exportFollow struct {
+	FeedName   string    `json:"feed_name"`
+	FeedURL    string    `json:"feed_url"`
+	FollowedAt time.Time `json:"followed_at"`
+	Category   *string   `json:"category"`
+}
+
+type exportBookmark struct {
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	FeedName     string     `json:"feed_name"`
+	FeedURL      string     `json:"feed_url"`
+	PublishedAt  *time.Time `json:"published_at"`
+	BookmarkedAt time.Time  `json:"bookmarked_at"`
+}
+
+type exportBookmarkOrder struct {
+	Tag      string `json:"tag"`
+	URL      string `json:"url"`
+	Position int32  `json:"position"`
+}
+
+type exportCollection struct {
+	Tag         string    `json:"tag"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Shared      bool      `json:"shared"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type exportTag struct {
+	URL      string    `json:"url"`
+	Tag      string    `json:"tag"`
+	TaggedAt time.Time `json:"tagged_at"`
+}
+
+type exportRead struct {
+	URL    string    `json:"url"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+type exportHiddenPost struct {
+	URL      string    `json:"url"`
+	HiddenAt time.Time `json:"hidden_at"`
+}
+
+type exportRule struct {
+	FeedName  *string   `json:"feed_name"`
+	FeedURL   *string   `json:"feed_url"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+	Tag       *string   `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportMutedDomain struct {
+	Domain  string    `json:"domain"`
+	MutedAt time.Time `json:"muted_at"`
+}
+
+type exportAPIToken struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// handlerUser dispatches the "user <subcommand>" family of commands
+func handlerUser(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return errors.New("subcommand is required: export")
+	}
+
+	sub := command{name: cmd.args[0], args: cmd.args[1:]}
+	switch sub.name {
+	case "export":
+		return handlerUserExport(s, sub)
+	default:
+		return fmt.Errorf("unknown user subcommand: %s", sub.name)
+	}
+}
+
+func handlerUserExport(s *state, cmd command) error {
+	outPath := ""
+	var positional []string
+	for _, arg := range cmd.args {
+		if strings.HasPrefix(arg, "--out=") {
+			outPath = strings.TrimPrefix(arg, "--out=")
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 {
+		return errors.New("username is required")
+	}
+
+	ctx := context.Background()
+	user, err := s.db.GetUserByName(ctx, positional[0])
+	if err != nil {
+		return fmt.Errorf("user %s doesn't exist", positional[0])
+	}
+	if err := authenticate(s, user); err != nil {
+		return err
+	}
+
+	export := userExport{
+		FormatVersion: userExportVersion,
+		ExportedAt:    time.Now().UTC(),
+		User: exportUser{
+			ID:        user.ID.String(),
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		FeedsCreated:  []exportFeed{},
+		Follows:       []exportFollow{},
+		Bookmarks:     []exportBookmark{},
+		BookmarkOrder: []exportBookmarkOrder{},
+		Collections:   []exportCollection{},
+		Tags:          []exportTag{},
+		Reads:         []exportRead{},
+		HiddenPosts:   []exportHiddenPost{},
+		Rules:         []exportRule{},
+		MutedDomains:  []exportMutedDomain{},
+		APITokens:     []exportAPIToken{},
+	}
+
+	feeds, err := s.db.GetFeedsCreatedByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds: %w", err)
+	}
+	for _, feed := range feeds {
+		ef := exportFeed{
+			ID:        feed.ID.String(),
+			Name:      feed.Name,
+			URL:       feed.Url,
+			CreatedAt: feed.CreatedAt,
+		}
+		if feed.LastFetchedAt.Valid {
+			ef.LastFetchedAt = &feed.LastFetchedAt.Time
+		}
+		export.FeedsCreated = append(export.FeedsCreated, ef)
+	}
+
+	follows, err := s.db.GetFollowedFeedsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get follows: %w", err)
+	}
+	for _, follow := range follows {
+		ef := exportFollow{
+			FeedName:   follow.Name,
+			FeedURL:    follow.Url,
+			FollowedAt: follow.FollowedAt,
+		}
+		if follow.Category.Valid {
+			ef.Category = &follow.Category.String
+		}
+		export.Follows = append(export.Follows, ef)
+	}
+
+	bookmarks, err := s.db.GetAllBookmarksForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get bookmarks: %w", err)
+	}
+	for _, bookmark := range bookmarks {
+		eb := exportBookmark{
+			Title:        bookmark.Title,
+			URL:          bookmark.Url,
+			FeedName:     bookmark.FeedName,
+			FeedURL:      bookmark.FeedUrl,
+			BookmarkedAt: bookmark.BookmarkedAt,
+		}
+		if bookmark.PublishedAt.Valid {
+			eb.PublishedAt = &bookmark.PublishedAt.Time
+		}
+		export.Bookmarks = append(export.Bookmarks, eb)
+	}
+
+	positions, err := s.db.GetBookmarkPositionsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get bookmark order: %w", err)
+	}
+	for _, position := range positions {
+		export.BookmarkOrder = append(export.BookmarkOrder, exportBookmarkOrder{
+			Tag:      position.Tag,
+			URL:      position.Url,
+			Position: position.Position,
+		})
+	}
+
+	collections, err := s.db.GetCollectionsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get collections: %w", err)
+	}
+	for _, c := range collections {
+		// The share token is a secret link, so only whether there is one
+		export.Collections = append(export.Collections, exportCollection{
+			Tag:         c.Tag,
+			Name:        c.Name,
+			Description: c.Description,
+			Shared:      c.ShareToken.Valid,
+			CreatedAt:   c.CreatedAt,
+		})
+	}
+
+	tags, err := s.db.GetPostTagsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tags: %w", err)
+	}
+	for _, tag := range tags {
+		export.Tags = append(export.Tags, exportTag{URL: tag.Url, Tag: tag.Tag, TaggedAt: tag.CreatedAt})
+	}
+
+	reads, err := s.db.GetReadsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get reads: %w", err)
+	}
+	for _, read := range reads {
+		export.Reads = append(export.Reads, exportRead{URL: read.Url, ReadAt: read.ReadAt})
+	}
+
+	hidden, err := s.db.GetHiddenPostsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get hidden posts: %w", err)
+	}
+	for _, post := range hidden {
+		export.HiddenPosts = append(export.HiddenPosts, exportHiddenPost{URL: post.Url, HiddenAt: post.HiddenAt})
+	}
+
+	rules, err := s.db.GetRulesForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get rules: %w", err)
+	}
+	for _, rule := range rules {
+		er := exportRule{
+			Pattern:   rule.Pattern,
+			Action:    rule.Action,
+			CreatedAt: rule.CreatedAt,
+		}
+		if rule.FeedID.Valid {
+			er.FeedName = &rule.FeedName
+			er.FeedURL = &rule.FeedUrl
+		}
+		if rule.Tag.Valid {
+			er.Tag = &rule.Tag.String
+		}
+		export.Rules = append(export.Rules, er)
+	}
+
+	muted, err := s.db.GetMutedDomains(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get muted domains: %w", err)
+	}
+	for _, domain := range muted {
+		export.MutedDomains = append(export.MutedDomains, exportMutedDomain{Domain: domain.Domain, MutedAt: domain.CreatedAt})
+	}
+
+	tokens, err := s.db.GetAPITokensForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get API tokens: %w", err)
+	}
+	for _, token := range tokens {
+		et := exportAPIToken{Name: token.Name, CreatedAt: token.CreatedAt}
+		if token.LastUsedAt.Valid {
+			et.LastUsedAt = &token.LastUsedAt.Time
+		}
+		export.APITokens = append(export.APITokens, et)
+	}
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("couldn't create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("couldn't write export: %w", err)
+	}
+
+	if outPath != "" {
+		fmt.Printf("Exported %s to %s\n", user.Name, outPath)
+	}
+	return nil
+}
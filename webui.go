@@ -0,0 +1,336 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olereon/Gator/internal/article"
+	"github.com/olereon/Gator/internal/database"
+	"github.com/olereon/Gator/internal/layout"
+)
+
+//go:embed web/*.html web/style.css
+var webFiles embed.FS
+
+var (
+	webPostsTmpl = template.Must(template.ParseFS(webFiles, "web/layout.html", "web/posts.html")).Lookup("posts.html")
+	webLoginTmpl = template.Must(template.ParseFS(webFiles, "web/layout.html", "web/login.html")).Lookup("login.html")
+)
+
+const (
+	webCookie       = "gator_token"
+	webPageSize     = 30
+	webSummaryWidth = 280
+	webCookieMaxAge = 90 * 24 * time.Hour
+
+	// webCSP keeps pages to gator's own stylesheet and out of other sites'
+	// frames, so a feed's HTML that slips through can't run or clickjack
+	webCSP = "default-src 'self'; frame-ancestors 'none'"
+)
+
+type webPost struct {
+	ID           string
+	Title        string
+	URL          string
+	FeedName     string
+	PublishedAt  string
+	Summary      string
+	IsRead       bool
+	IsBookmarked bool
+}
+
+type webPostsPage struct {
+	User      string
+	Query     string
+	Unread    bool
+	ReadOnly  bool
+	Posts     []webPost
+	Back      string
+	AllURL    string
+	UnreadURL string
+	PrevURL   string
+	NextURL   string
+}
+
+// webRoutes adds the browser UI: a post list with search, read toggles and
+// bookmark stars. It logs in with the same tokens as the API, kept in a
+// cookie.
+func (a *apiServer) webRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /{$}", a.web(a.handleWebPosts))
+	mux.HandleFunc("GET /style.css", handleWebStyle)
+	mux.HandleFunc("GET /login", a.handleWebLoginPage)
+	mux.HandleFunc("POST /login", a.handleWebLogin)
+	mux.HandleFunc("POST /logout", a.handleWebLogout)
+	mux.HandleFunc("GET /posts/{id}/open", a.web(a.handleWebOpen))
+	mux.HandleFunc("POST /posts/{id}/{action}", a.web(a.handleWebPostAction))
+}
+
+// web is authed for the browser: a missing or stale cookie sends the user
+// to the login page, and errors are answered as plain text
+func (a *apiServer) web(handler func(w http.ResponseWriter, r *http.Request, user database.User) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && !sameOrigin(r) {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+
+		var err error
+		var user database.User
+		cookie, cookieErr := r.Cookie(webCookie)
+		if cookieErr != nil {
+			err = apiError{http.StatusUnauthorized, "not logged in"}
+		} else {
+			user, err = a.userForToken(r.Context(), cookie.Value)
+		}
+		if err == nil {
+			err = handler(w, r, user)
+		}
+		if err == nil {
+			return
+		}
+
+		var apiErr apiError
+		if !errors.As(err, &apiErr) {
+			slog.Error("couldn't answer web request", "method", r.Method, "path", r.URL.Path, "err", err)
+			apiErr = apiError{http.StatusInternalServerError, "internal error"}
+		}
+		if apiErr.status == http.StatusUnauthorized {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		http.Error(w, apiErr.message, apiErr.status)
+	}
+}
+
+// sameOrigin refuses form posts from other sites. Browsers that send no
+// Origin header still get the SameSite cookie's protection.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func (a *apiServer) handleWebLoginPage(w http.ResponseWriter, r *http.Request) {
+	renderWeb(w, http.StatusOK, webLoginTmpl, map[string]string{})
+}
+
+func (a *apiServer) handleWebLogin(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	token := strings.TrimSpace(r.PostFormValue("token"))
+	if _, err := a.userForToken(r.Context(), token); err != nil {
+		var apiErr apiError
+		if !errors.As(err, &apiErr) {
+			slog.Error("couldn't log in", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		renderWeb(w, http.StatusUnauthorized, webLoginTmpl, map[string]string{"Error": apiErr.message})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     webCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(webCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (a *apiServer) handleWebLogout(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     webCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// handleWebPosts lists the user's posts newest first, or the results of a
+// search when q is given
+func (a *apiServer) handleWebPosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	unread := q.Get("unread") == "true"
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	offset = max(offset, 0)
+
+	page := webPostsPage{
+		User:      user.Name,
+		Query:     query,
+		Unread:    unread,
+		ReadOnly:  a.s.readOnly,
+		Back:      r.URL.RequestURI(),
+		AllURL:    webListURL(query, false, 0),
+		UnreadURL: webListURL(query, true, 0),
+	}
+
+	if query != "" {
+		posts, err := a.search(r.Context(), user, query, postFilter{Feeds: []string{}, Unread: unread}, webPageSize)
+		if err != nil {
+			return err
+		}
+		for _, p := range posts {
+			bookmarked, err := a.s.db.IsPostBookmarked(r.Context(), database.IsPostBookmarkedParams{
+				UserID: user.ID,
+				PostID: p.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("couldn't check bookmark status: %w", err)
+			}
+			title, description, _ := previewed(a.s, p.ID, p.Title, p.Description)
+			page.Posts = append(page.Posts, newWebPost(p.ID.String(), title, p.Url, p.FeedName, p.PublishedAt.Time, description.String, p.IsRead, bookmarked))
+		}
+	} else {
+		// One more than a page tells whether there is a next one
+		posts, err := a.s.db.GetPostsForUserWithPagination(r.Context(), database.GetPostsForUserWithPaginationParams{
+			UserID:  user.ID,
+			Column2: []string{},
+			Column3: "",
+			Limit:   webPageSize + 1,
+			Offset:  int32(offset),
+			Column8: []string{},
+			Column9: unread,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't get posts: %w", err)
+		}
+		if len(posts) > webPageSize {
+			posts = posts[:webPageSize]
+			page.NextURL = webListURL("", unread, offset+webPageSize)
+		}
+		if offset > 0 {
+			page.PrevURL = webListURL("", unread, max(offset-webPageSize, 0))
+		}
+		for _, p := range posts {
+			title, description, _ := previewed(a.s, p.ID, p.Title, p.Description)
+			page.Posts = append(page.Posts, newWebPost(p.ID.String(), title, p.Url, p.FeedName, p.PublishedAt.Time, description.String, p.IsRead, p.IsBookmarked))
+		}
+	}
+
+	renderWeb(w, http.StatusOK, webPostsTmpl, page)
+	return nil
+}
+
+func newWebPost(id, title, postURL, feedName string, publishedAt time.Time, description string, read, bookmarked bool) webPost {
+	p := webPost{
+		ID:           id,
+		Title:        title,
+		URL:          postURL,
+		FeedName:     feedName,
+		Summary:      layout.Truncate(strings.Join(strings.Fields(article.Text(description)), " "), webSummaryWidth),
+		IsRead:       read,
+		IsBookmarked: bookmarked,
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		p.Title = postURL
+	}
+	if !publishedAt.IsZero() {
+		p.PublishedAt = publishedAt.Local().Format("2006-01-02 15:04")
+	}
+	return p
+}
+
+func webListURL(query string, unread bool, offset int) string {
+	v := url.Values{}
+	if query != "" {
+		v.Set("q", query)
+	}
+	if unread {
+		v.Set("unread", "true")
+	}
+	if offset > 0 {
+		v.Set("offset", strconv.Itoa(offset))
+	}
+	if len(v) == 0 {
+		return "/"
+	}
+	return "/?" + v.Encode()
+}
+
+// handleWebOpen counts an open of the post, as gator open and the TUI do,
+// then sends the browser on to the article
+func (a *apiServer) handleWebOpen(w http.ResponseWriter, r *http.Request, user database.User) error {
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	recordOpen(a.s, user, post.ID, openSourceWeb)
+
+	link := post.Url
+	if post.CanonicalUrl.Valid {
+		link = post.CanonicalUrl.String
+	}
+	http.Redirect(w, r, link, http.StatusSeeOther)
+	return nil
+}
+
+// handleWebPostAction marks a post read or unread, or stars or unstars it,
+// then goes back to the list it was done from
+func (a *apiServer) handleWebPostAction(w http.ResponseWriter, r *http.Request, user database.User) error {
+	if a.s.readOnly {
+		return apiError{http.StatusForbidden, errReadOnly.Error()}
+	}
+	post, err := a.visiblePost(r, user)
+	if err != nil {
+		return err
+	}
+	switch action := r.PathValue("action"); action {
+	case "read", "unread":
+		err = setRead(r.Context(), a.s, user, post.ID, action == "read")
+	case "bookmark", "unbookmark":
+		err = setBookmarked(r.Context(), a.s, user, post.ID, action == "bookmark")
+	default:
+		return apiError{http.StatusNotFound, "unknown action: " + action}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only go back to a path on this server
+	back := r.PostFormValue("back")
+	if !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") || strings.HasPrefix(back, "/\\") {
+		back = "/"
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+	return nil
+}
+
+// handleWebStyle serves the stylesheet of every page. The CSP rules out
+// inline styles, so it can't go in the layout.
+func handleWebStyle(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, webFiles, "web/style.css")
+}
+
+// renderWeb answers with a page, for the web UI and shared collections alike
+func renderWeb(w http.ResponseWriter, status int, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", webCSP)
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		slog.Error("couldn't render page", "err", err)
+	}
+}